@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/storage"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
 	"github.com/swarleynunez/hidra/inputs"
+	"strings"
 )
 
 const appDeployShortMsg = "Deploy a new application on the cluster"
@@ -18,18 +20,63 @@ var appDeployCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Args:                  cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Initialize and configure node
-		managers.InitNode(ctx, false)
-
 		// Get flags
 		autodeploy, err := cmd.Flags().GetBool("autodeploy")
-		utils.CheckError(err, utils.FatalMode)
+		utils.Fatal(err)
+		artifacts, err := cmd.Flags().GetStringArray("artifact")
+		utils.Fatal(err)
+		near, err := cmd.Flags().GetString("near")
+		utils.Fatal(err)
+		radius, err := cmd.Flags().GetFloat64("radius")
+		utils.Fatal(err)
+		if radius < 0 {
+			utils.Fatal(errNegativeRadius)
+		}
+
+		// Placement hint
+		ainfo := inputs.AppInfo
+		if near != "" {
+			ainfo.Clients = &types.NodeLocation{}
+			err = ainfo.Clients.UnmarshalText([]byte(near))
+			utils.Fatal(err)
+			ainfo.Radius = radius
+		}
+
+		// Artifacts are stored locally (peers fetch them by CID)
+		cinfo := inputs.CtrInfo
+		cinfo.Artifacts = append([]types.Artifact(nil), cinfo.Artifacts...)
+		for _, a := range artifacts {
+			file, target, found := strings.Cut(a, ":")
+			if !found || file == "" || target == "" {
+				utils.Fatal(errMalformedArtifact)
+			}
+
+			cid, err := putFile(storage.NewStore(cfg.Storage.Dir), file)
+			utils.Fatal(err)
+			fmt.Println("--> Artifact", file, "CID:", cid)
+
+			cinfo.Artifacts = append(cinfo.Artifacts, types.Artifact{CID: cid, Target: target})
+		}
 
 		//fmt.Println("--> Starting at", time.Now().UnixMilli())
 
 		// TODO. SDN ONOS plugin: check if the new application (VS) already exists
-		err = managers.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, autodeploy)
-		utils.CheckError(err, utils.FatalMode)
+		if cli := daemonClient(); cli != nil {
+			// Through the running daemon
+			err = cli.DeployApplication(&types.APIDeployRequest{
+				App:        ainfo,
+				Containers: []types.ContainerInfo{cinfo},
+				Autodeploy: autodeploy,
+			})
+			utils.Fatal(err)
+		} else {
+			// Initialize and configure node
+			node, err := managers.InitNode(ctx, cfg, false)
+			utils.Fatal(err)
+
+			err = node.RegisterApplication(ctx, &ainfo, []types.ContainerInfo{cinfo}, autodeploy)
+			utils.Fatal(err)
+		}
 
 		fmt.Println("--> Application deployed on the cluster")
 	},
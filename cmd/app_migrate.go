@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"strconv"
+)
+
+const appMigrateShortMsg = "Ask the cluster to migrate an application container"
+
+var appMigrateCmd = &cobra.Command{
+	Use:                   "migrate RCID [OPTIONS]",
+	Short:                 appMigrateShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + appMigrateShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get and format args
+		rcid, err := strconv.ParseUint(args[0], 10, 64)
+		utils.Fatal(err)
+
+		// Get flags
+		res, err := cmd.Flags().GetString("resource")
+		utils.Fatal(err)
+
+		var req types.APIMigrateRequest
+		err = req.Resource.UnmarshalText([]byte(res))
+		utils.Fatal(err)
+
+		if cli := daemonClient(); cli != nil {
+			// Through the running daemon
+			err = cli.MigrateContainer(rcid, &req)
+			utils.Fatal(err)
+		} else {
+			// Initialize and configure node
+			node, err := managers.InitNode(ctx, cfg, false)
+			utils.Fatal(err)
+
+			err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.MigrateContainerTask, Resource: req.Resource}, rcid)
+			utils.Fatal(err)
+		}
+
+		fmt.Println("--> Container migration requested")
+	},
+}
@@ -3,7 +3,8 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
-	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/utils"
+	"strconv"
 )
 
 const appRemoveShortMsg = "Remove an application from the cluster"
@@ -15,15 +16,18 @@ var appRemoveCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Args:                  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Initialize and configure node
-		managers.InitNode(ctx, false)
-
 		// Get and format args
-		/*appid, err := strconv.ParseUint(args[0], 10, 64)
-		utils.CheckError(err, utils.FatalMode)
+		appid, err := strconv.ParseUint(args[0], 10, 64)
+		utils.Fatal(err)
+
+		// Only through the running daemon
+		cli := daemonClient()
+		if cli == nil {
+			utils.Fatal(errDaemonNotRunning)
+		}
 
-		err = managers.RemoveDCRApplication(ctx, appid)
-		utils.CheckError(err, utils.FatalMode)*/
+		err = cli.RemoveApplication(appid)
+		utils.Fatal(err)
 
 		fmt.Println("--> Application removed from the cluster")
 	},
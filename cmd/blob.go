@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/storage"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"io"
+	"os"
+)
+
+const (
+	blobShortMsg       = "Manage blobs replicated through DEL events"
+	blobPutShortMsg    = "Store a file and replicate it to the event solver"
+	blobGetShortMsg    = "Copy a blob to a file (asking the cluster for a replica if it is not stored)"
+	blobUpdateShortMsg = "Replace a blob by the content of a file"
+	blobRemoveShortMsg = "Remove a blob from this node and from the event solver"
+	blobListShortMsg   = "List the blobs stored by this node"
+	blobArtsShortMsg   = "List the artifacts of the active containers and their replicas"
+)
+
+var blobCmd = &cobra.Command{
+	Use:                   "blob",
+	Short:                 blobShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobShortMsg,
+	DisableFlagsInUseLine: true,
+}
+
+var blobPutCmd = &cobra.Command{
+	Use:                   "put FILE",
+	Short:                 blobPutShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobPutShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		cid, err := putFile(node.GetBlobStore(), args[0])
+		utils.Fatal(err)
+		fmt.Println("--> CID:", cid)
+
+		err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.CreateTask, Resource: types.NoResource, Blob: cid}, 0)
+		utils.Fatal(err)
+
+		fmt.Println("--> Blob creation requested")
+	},
+}
+
+var blobGetCmd = &cobra.Command{
+	Use:                   "get CID FILE",
+	Short:                 blobGetShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobGetShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := storage.NewStore(cfg.Storage.Dir)
+		if store.Has(args[0]) {
+			err := getFile(store, args[0], args[1])
+			utils.Fatal(err)
+
+			fmt.Println("--> Blob copied to", args[1])
+			return
+		}
+
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		// The running daemon fetches the blob from the solver
+		err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.ReadTask, Resource: types.NoResource, Blob: args[0]}, 0)
+		utils.Fatal(err)
+
+		fmt.Println("--> Blob not stored, read requested (run this command again once the event is solved)")
+	},
+}
+
+var blobUpdateCmd = &cobra.Command{
+	Use:                   "update CID FILE",
+	Short:                 blobUpdateShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobUpdateShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !storage.ValidCID(args[0]) {
+			utils.Fatal(errMalformedCID)
+		}
+
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		cid, err := putFile(node.GetBlobStore(), args[1])
+		utils.Fatal(err)
+		fmt.Println("--> CID:", cid)
+
+		err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.UpdateTask, Resource: types.NoResource, Blob: cid, Prev: args[0]}, 0)
+		utils.Fatal(err)
+		if cid != args[0] {
+			err = node.GetBlobStore().Delete(args[0])
+			utils.Fatal(err)
+		}
+
+		fmt.Println("--> Blob update requested")
+	},
+}
+
+var blobRemoveCmd = &cobra.Command{
+	Use:                   "remove CID",
+	Aliases:               []string{"rm"},
+	Short:                 blobRemoveShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobRemoveShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.DeleteTask, Resource: types.NoResource, Blob: args[0]}, 0)
+		utils.Fatal(err)
+		err = node.GetBlobStore().Delete(args[0])
+		utils.Fatal(err)
+
+		fmt.Println("--> Blob removal requested")
+	},
+}
+
+var blobListCmd = &cobra.Command{
+	Use:                   "list",
+	Short:                 blobListShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobListShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Annotations:           map[string]string{skipValidation: ""}, // Offline command
+	Run: func(cmd *cobra.Command, args []string) {
+		blobs, err := storage.NewStore(cfg.Storage.Dir).List()
+		utils.Fatal(err)
+
+		if len(blobs) == 0 {
+			fmt.Println("--> No blobs found")
+			return
+		}
+		for _, b := range blobs {
+			fmt.Println("--> CID:", b.CID)
+			fmt.Println("    SIZE:", b.Size, "bytes")
+		}
+	},
+}
+
+var blobArtifactsCmd = &cobra.Command{
+	Use:                   "artifacts",
+	Short:                 blobArtsShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + blobArtsShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Replicas are only counted by the daemon
+		cli := daemonClient()
+		if cli == nil {
+			utils.Fatal(errDaemonNotRunning)
+		}
+
+		arts, err := cli.Artifacts()
+		utils.Fatal(err)
+
+		if len(arts) == 0 {
+			fmt.Println("--> No artifacts found")
+			return
+		}
+		for _, a := range arts {
+			fmt.Println("--> CID:", a.CID)
+			fmt.Println("    REPLICAS:", a.Replicas)
+			if uint64(a.Replicas) < cfg.Storage.MinReplicas {
+				fmt.Println("    UNDER-REPLICATED: minimum", cfg.Storage.MinReplicas)
+			}
+			fmt.Println("    CONTAINERS:", a.Containers)
+		}
+	},
+}
+
+func putFile(store *storage.Store, path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return store.Put(f)
+}
+
+func getFile(store *storage.Store, cid, path string) error {
+
+	src, err := store.Open(cid)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
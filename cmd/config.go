@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configShortMsg         = "Show or validate the node configuration"
+	configShowShortMsg     = "Show the effective configuration (secrets masked)"
+	configValidateShortMsg = "Validate the effective configuration"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:                   "config",
+		Short:                 configShortMsg,
+		Long:                  title + "\n\n" + "Info:\n  " + configShortMsg,
+		DisableFlagsInUseLine: true,
+	}
+
+	configShowCmd = &cobra.Command{
+		Use:                   "show",
+		Short:                 configShowShortMsg,
+		Long:                  title + "\n\n" + "Info:\n  " + configShowShortMsg,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(0),
+		Annotations:           map[string]string{skipValidation: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := yaml.Marshal(cfg.Masked())
+			utils.Fatal(err)
+
+			fmt.Print(string(b))
+		},
+	}
+
+	configValidateCmd = &cobra.Command{
+		Use:                   "validate",
+		Short:                 configValidateShortMsg,
+		Long:                  title + "\n\n" + "Info:\n  " + configValidateShortMsg,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(0),
+		Annotations:           map[string]string{skipValidation: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.Fatal(cfg.Validate())
+
+			fmt.Println("--> Configuration is valid")
+		},
+	}
+)
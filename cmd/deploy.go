@@ -16,10 +16,12 @@ var deployCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Initialize and configure node
-		managers.InitNode(ctx, true)
+		node, err := managers.InitNode(ctx, cfg, true)
+		utils.Fatal(err)
 
 		// Deploy a new controller
-		caddr := managers.DeployController(ctx)
+		caddr, err := node.DeployController(ctx)
+		utils.Fatal(err)
 
 		// Save the controller contract address
 		utils.SetEnv("CONTROLLER_ADDR", caddr.String())
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/devnet"
+	"github.com/swarleynunez/hidra/core/utils"
+	"os"
+)
+
+const (
+	devnetShortMsg       = "Manage a local cluster (Clique chain, controller and node daemons)"
+	devnetUpShortMsg     = "Create and start a local cluster waiting for every node to be ready"
+	devnetDownShortMsg   = "Stop the local cluster processes"
+	devnetStatusShortMsg = "Show the local cluster processes, chains and APIs"
+)
+
+var devnetCmd = &cobra.Command{
+	Use:         "devnet",
+	Short:       devnetShortMsg,
+	Long:        title + "\n\n" + "Info:\n  " + devnetShortMsg,
+	Annotations: map[string]string{skipValidation: ""}, // Node settings are generated
+}
+
+var devnetUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: devnetUpShortMsg,
+	Long: title + "\n\n" + "Info:\n  " + devnetUpShortMsg + "\n\n" +
+		"  Each node gets a directory (keystore, chain, logs and hidra.yaml) under --dir.\n" +
+		"  Loaded settings (file, environment and flags) are shared by every node.",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Annotations:           map[string]string{skipValidation: ""},
+	Run: func(cmd *cobra.Command, args []string) {
+		dc := &devnet.Config{Base: cfg}
+		dc.Dir, _ = cmd.Flags().GetString("dir")
+		dc.Nodes, _ = cmd.Flags().GetInt("nodes")
+		dc.ChainID, _ = cmd.Flags().GetUint64("chain-id")
+		dc.Period, _ = cmd.Flags().GetUint64("period")
+		dc.Geth, _ = cmd.Flags().GetString("geth")
+		dc.Iface, _ = cmd.Flags().GetString("iface")
+		dc.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+		// This same binary runs the node daemons
+		var err error
+		dc.Hidra, err = os.Executable()
+		utils.Fatal(err)
+
+		st, err := devnet.Up(ctx, dc)
+		utils.Fatal(err)
+
+		fmt.Println("--> Controller contract:", st.Controller)
+		for _, n := range st.Nodes {
+			fmt.Println("--> NODE:", n.Name, n.Address.String(), "PORT:", n.Port, "CONFIG:", n.ConfigFile)
+		}
+	},
+}
+
+var devnetDownCmd = &cobra.Command{
+	Use:                   "down",
+	Short:                 devnetDownShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + devnetDownShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Annotations:           map[string]string{skipValidation: ""},
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		purge, _ := cmd.Flags().GetBool("purge")
+
+		utils.Fatal(devnet.Down(dir, purge))
+		fmt.Println("--> Devnet stopped")
+	},
+}
+
+var devnetStatusCmd = &cobra.Command{
+	Use:                   "status",
+	Short:                 devnetStatusShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + devnetStatusShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Annotations:           map[string]string{skipValidation: ""},
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+
+		st, nss, err := devnet.Status(ctx, dir)
+		utils.Fatal(err)
+
+		fmt.Println("--> CHAIN ID:", st.ChainID, "CONTROLLER:", st.Controller)
+		for _, ns := range nss {
+			fmt.Println("--> NODE:", ns.Name, ns.Address.String())
+			fmt.Println("    GETH:", processStatus(ns.GethAlive, ns.GethPID), "BLOCK:", ns.Block, "PEERS:", ns.Peers)
+			fmt.Println("    HIDRA:", processStatus(ns.HidraAlive, ns.HidraPID), "API:", ns.APIReady)
+		}
+	},
+}
+
+func processStatus(alive bool, pid int) string {
+
+	if alive {
+		return fmt.Sprint("running (pid ", pid, ")")
+	}
+
+	return "stopped"
+}
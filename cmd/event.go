@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"sort"
+	"time"
+)
+
+const (
+	eventShortMsg     = "Inspect DEL events"
+	eventListShortMsg = "List the latest cluster events (or the stalled ones)"
+	eventPingShortMsg = "Probe the cluster nodes (their replies feed reputation)"
+)
+
+var eventCmd = &cobra.Command{
+	Use:                   "event",
+	Short:                 eventShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + eventShortMsg,
+	DisableFlagsInUseLine: true,
+}
+
+var eventListCmd = &cobra.Command{
+	Use:                   "list [--stalled] [--last N]",
+	Short:                 eventListShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + eventListShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get flags
+		stalled, err := cmd.Flags().GetBool("stalled")
+		utils.Fatal(err)
+		last, err := cmd.Flags().GetUint64("last")
+		utils.Fatal(err)
+
+		if stalled {
+			printStalledEvents()
+			return
+		}
+
+		var events []types.APIEvent
+		if cli := daemonClient(); cli != nil {
+			// Through the running daemon
+			events, err = cli.Events(last)
+			utils.Fatal(err)
+		} else {
+			// Initialize and configure node
+			node, err := managers.InitNode(ctx, cfg, false)
+			utils.Fatal(err)
+
+			latest, err := node.GetLatestEvents(last)
+			utils.Fatal(err)
+
+			for eid, event := range latest {
+				events = append(events, api.EventView(eid, event))
+			}
+			sort.Slice(events, func(i, j int) bool { return events[i].Eid > events[j].Eid })
+		}
+
+		if len(events) == 0 {
+			fmt.Println("--> No cluster events found")
+			return
+		}
+		for _, e := range events {
+			fmt.Println("--> EID:", e.Eid)
+			fmt.Println("    SENDER:", e.Sender)
+			if e.Rcid > 0 {
+				fmt.Println("    RCID:", e.Rcid)
+			}
+			if e.Type.Retry > 0 {
+				fmt.Println("    RETRY OF:", e.Type.Retry, "(attempt", e.Type.Attempt, "excluding", len(e.Type.Excluded), "nodes)")
+			}
+			if e.SolvedAt > 0 {
+				fmt.Println("    SOLVER:", e.Solver)
+				fmt.Println("    SOLVED:", e.SolvedAt-e.SentAt, "s")
+			} else {
+				fmt.Println("    PENDING: replies", e.HasRequiredReplies, "votes", e.HasRequiredVotes)
+			}
+		}
+	},
+}
+
+var eventPingCmd = &cobra.Command{
+	Use:                   "ping",
+	Short:                 eventPingShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + eventPingShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		err = node.SendEvent(ctx, &types.EventType{RequiredTask: types.PingNodeTask, Resource: types.NoResource}, 0)
+		utils.Fatal(err)
+
+		fmt.Println("--> Ping event sent")
+	},
+}
+
+// Deadlines are only tracked by the daemon
+func printStalledEvents() {
+
+	cli := daemonClient()
+	if cli == nil {
+		utils.Fatal(errDaemonNotRunning)
+	}
+
+	stalled, err := cli.StalledEvents()
+	utils.Fatal(err)
+
+	if len(stalled) == 0 {
+		fmt.Println("--> No stalled events found")
+		return
+	}
+	for _, se := range stalled {
+		fmt.Println("--> EID:", se.Eid)
+		fmt.Println("    PHASE:", se.Phase)
+		fmt.Println("    SINCE:", time.UnixMilli(se.Since).Format(time.RFC3339))
+		fmt.Println("    SENDER:", se.Sender)
+		if se.Phase == "RequiredVotes" {
+			fmt.Println("    SOLVER:", se.Solver)
+		}
+		if se.RetriedBy > 0 {
+			fmt.Println("    RETRIED BY:", se.RetriedBy)
+		}
+	}
+}
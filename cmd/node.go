@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"strconv"
+)
+
+const (
+	nodeShortMsg           = "Manage the lifecycle of the fog node"
+	nodeDrainShortMsg      = "Migrate the hosted containers away and stop being a solver candidate"
+	nodeDeregisterShortMsg = "Leave the cluster once the node hosts no containers"
+)
+
+var nodeCmd = &cobra.Command{
+	Use:                   "node",
+	Short:                 nodeShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + nodeShortMsg,
+	DisableFlagsInUseLine: true,
+}
+
+var nodeDrainCmd = &cobra.Command{
+	Use:                   "drain [OPTIONS]",
+	Short:                 nodeDrainShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + nodeDrainShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get flags
+		cancel, err := cmd.Flags().GetBool("cancel")
+		utils.Fatal(err)
+
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		if cancel {
+			err = node.SetNodeStatus(ctx, types.ActiveNode)
+			utils.Fatal(err)
+
+			fmt.Println("--> Node active")
+			return
+		}
+
+		// Migrations already requested are shown even if another one fails
+		rcids, err := node.DrainNode(ctx)
+		for _, rcid := range rcids {
+			fmt.Println("--> Container migration requested (RCID " + strconv.FormatUint(rcid, 10) + ")")
+		}
+		utils.Fatal(err)
+		fmt.Println("--> Node draining (" + strconv.Itoa(len(rcids)) + " migrations)")
+	},
+}
+
+var nodeDeregisterCmd = &cobra.Command{
+	Use:                   "deregister",
+	Short:                 nodeDeregisterShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + nodeDeregisterShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Initialize and configure node
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
+
+		err = node.DeregisterNode(ctx)
+		utils.Fatal(err)
+
+		fmt.Println("--> Node deregistered")
+	},
+}
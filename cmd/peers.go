@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/utils"
+)
+
+const peersShortMsg = "Show the peers and reputation scores seen by the running daemon"
+
+var peersCmd = &cobra.Command{
+	Use:                   "peers",
+	Short:                 peersShortMsg,
+	Long:                  title + "\n\n" + "Info:\n  " + peersShortMsg,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Peers only live in the daemon memory
+		cli := daemonClient()
+		if cli == nil {
+			utils.Fatal(errDaemonNotRunning)
+		}
+
+		peers, err := cli.Peers()
+		utils.Fatal(err)
+
+		if len(peers) == 0 {
+			fmt.Println("--> No peers found")
+			return
+		}
+		for _, p := range peers {
+			fmt.Println("--> NODE:", p.Node)
+			fmt.Println("    STATUS:", p.Status)
+			fmt.Println("    SCORE:", p.Score)
+			fmt.Println("    EPOCHS:", len(p.Values))
+			fmt.Println("    PACKETS:", p.OKPackets, "/", p.TotalPackets)
+		}
+	},
+}
@@ -17,14 +17,16 @@ var registerCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Initialize and configure node
-		managers.InitNode(ctx, false)
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
 
 		// Register node if it has not done yet
-		if !managers.IsNodeRegistered(managers.GetFromAccount()) {
+		if !node.IsNodeRegistered(node.GetFromAccount()) {
 			port, err := strconv.ParseUint(args[0], 10, 16)
-			utils.CheckError(err, utils.FatalMode)
+			utils.Fatal(err)
 
-			managers.RegisterNode(ctx, uint16(port))
+			err = node.RegisterNode(ctx, uint16(port))
+			utils.Fatal(err)
 
 			fmt.Println("--> Node registered")
 		} else {
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/experiments"
+	"github.com/swarleynunez/hidra/core/utils"
+	"os"
+	"text/tabwriter"
+)
+
+const reportShortMsg = "Aggregate experiment records of one or several runs (mean, p50 and p95)"
+
+var reportCmd = &cobra.Command{
+	Use:         "report file...",
+	Short:       reportShortMsg,
+	Long:        title + "\n\n" + "Info:\n  " + reportShortMsg + "\n\n" + "  Records are saved by the node daemon when EXPERIMENT_FILE is set (.csv or .json).",
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: map[string]string{skipValidation: ""}, // Offline command
+	Run: func(cmd *cobra.Command, args []string) {
+		evs, err := experiments.Load(args...)
+		utils.Fatal(err)
+
+		if len(evs) == 0 {
+			fmt.Println("--> No experiment records found")
+			return
+		}
+		sums := experiments.Summarize(evs)
+
+		if format, _ := cmd.Flags().GetString("format"); format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			utils.Fatal(enc.Encode(sums))
+			return
+		}
+
+		fmt.Println("--> EVENTS:", len(evs), "FILES:", len(args))
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "METRIC\tCOUNT\tMEAN\tP50\tP95\tMIN\tMAX\t")
+		for _, s := range sums {
+			fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n", s.Metric, s.Count, s.Mean, s.P50, s.P95, s.Min, s.Max)
+		}
+		utils.Fatal(tw.Flush())
+	},
+}
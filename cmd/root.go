@@ -2,22 +2,60 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/utils"
+	"os"
+	"strings"
+	"time"
 )
 
+// Commands that must work with an incomplete configuration
+const skipValidation = "skip-validation"
+
 var (
 	// Main context
 	ctx = context.Background()
 
+	// Effective node configuration
+	cfg *config.Config
+
 	// Main CLI title
 	title = `------------------------------------------------
 --- HIDRA distributed container orchestrator ---
 ------------------------------------------------`
 
+	errDaemonNotRunning  = errors.New("node daemon not running (hidra run)")
+	errMalformedCID      = errors.New("malformed blob cid (hex sha-256)")
+	errMalformedArtifact = errors.New("malformed artifact (FILE:TARGET)")
+	errNegativeRadius    = errors.New("negative placement radius")
+
 	// Root CLI command
 	rootCmd = &cobra.Command{
 		Use:  "hidra",
 		Long: title,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			var err error
+			cfg, err = loadConfig(cmd)
+			utils.Fatal(err)
+
+			// Logger settings
+			err = utils.InitLogger(&utils.LogConfig{
+				Level:      cfg.Log.Level,
+				Format:     cfg.Log.Format,
+				Outputs:    strings.Split(cfg.Log.Output, ","),
+				MaxSize:    cfg.Log.MaxSize,
+				MaxBackups: cfg.Log.Backups,
+			})
+			utils.Fatal(err)
+
+			// Invalid settings are reported before doing anything
+			if _, ok := cmd.Annotations[skipValidation]; !ok {
+				utils.Fatal(cfg.Validate())
+			}
+		},
 	}
 )
 
@@ -31,21 +69,124 @@ func init() {
 	rootCmd.AddCommand(
 		deployCmd,
 		registerCmd,
+		nodeCmd,
 		runCmd,
 		appCmd,
 		showCmd,
-		//monitorCmd,
+		peersCmd,
+		eventCmd,
+		blobCmd,
+		configCmd,
+		simulateCmd,
+		reportCmd,
+		devnetCmd,
 		versionCmd)
 
 	// Subcommands
 	appCmd.AddCommand(appDeployCmd)
 	appCmd.AddCommand(appRemoveCmd)
+	appCmd.AddCommand(appMigrateCmd)
+	nodeCmd.AddCommand(nodeDrainCmd)
+	nodeCmd.AddCommand(nodeDeregisterCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	eventCmd.AddCommand(eventListCmd)
+	eventCmd.AddCommand(eventPingCmd)
+	blobCmd.AddCommand(blobPutCmd)
+	blobCmd.AddCommand(blobGetCmd)
+	blobCmd.AddCommand(blobUpdateCmd)
+	blobCmd.AddCommand(blobRemoveCmd)
+	blobCmd.AddCommand(blobListCmd)
+	blobCmd.AddCommand(blobArtifactsCmd)
+	devnetCmd.AddCommand(devnetUpCmd)
+	devnetCmd.AddCommand(devnetDownCmd)
+	devnetCmd.AddCommand(devnetStatusCmd)
 
 	// Flags
+	rootCmd.PersistentFlags().StringP("config", "c", "", "configuration file (default $HIDRA_CONFIG or ./"+config.DefaultFile+")")
+	for _, k := range config.Keys() {
+		rootCmd.PersistentFlags().String(flagName(k.Name), "", k.Desc)
+	}
 	appDeployCmd.Flags().BoolP("autodeploy", "a", false, "deploy application in autodeploy mode")
+	appDeployCmd.Flags().StringArray("artifact", nil, "file mounted read-only in the container (FILE:TARGET, repeatable)")
+	appDeployCmd.Flags().String("near", "", "location of the application users (LAT,LONG in degrees)")
+	appDeployCmd.Flags().Float64("radius", 0, "preferred maximum host distance to the users (km, 0: closest host)")
+	appMigrateCmd.Flags().StringP("resource", "r", "cpu", "resource used to choose the new container host")
+	nodeDrainCmd.Flags().Bool("cancel", false, "make the node active again")
+	simulateCmd.Flags().IntP("nodes", "n", 4, "simulated cluster nodes")
+	simulateCmd.Flags().Uint64P("events", "e", 10, "events to send (one reputation epoch per event)")
+	simulateCmd.Flags().Uint64("packets", 100, "packets exchanged with each peer per epoch")
+	simulateCmd.Flags().String("profiles", "", "node packet profiles (loss:latency,...)")
+	simulateCmd.Flags().StringArray("fail", nil, "node failure (node@from[-until], repeatable)")
+	simulateCmd.Flags().Int64("seed", 0, "packet simulator seed (0: random)")
+	simulateCmd.Flags().StringP("format", "f", "csv", "report format (csv or json)")
+	simulateCmd.Flags().StringP("output", "o", "", "report file (default stdout)")
+	eventListCmd.Flags().BoolP("stalled", "s", false, "list the events whose deadline expired (running daemon)")
+	eventListCmd.Flags().Uint64P("last", "l", 20, "latest events to list")
+	reportCmd.Flags().StringP("format", "f", "text", "summary format (text or json)")
+	devnetCmd.PersistentFlags().String("dir", "devnet", "devnet directory")
+	devnetUpCmd.Flags().IntP("nodes", "n", 5, "cluster nodes")
+	devnetUpCmd.Flags().Uint64("chain-id", 12345, "chain id")
+	devnetUpCmd.Flags().Uint64("period", 3, "clique block period (s)")
+	devnetUpCmd.Flags().String("geth", "geth", "geth binary")
+	devnetUpCmd.Flags().String("iface", "lo", "packet monitor interface")
+	devnetUpCmd.Flags().Duration("timeout", 2*time.Minute, "readiness timeout of each step")
+	devnetDownCmd.Flags().Bool("purge", false, "remove the devnet directory")
 	//showCmd.Flags().BoolP("owned", "o", false, "show cluster applications owned by this node")
 }
 
 func Execute() error {
 	return rootCmd.Execute()
 }
+
+// Client of the running node daemon (nil if it is not running)
+func daemonClient() *api.Client {
+
+	cli, err := api.NewClient(&api.Config{
+		Addr:      cfg.API.Addr,
+		Token:     cfg.API.Token,
+		TokenFile: cfg.API.TokenFile,
+	})
+	if err != nil || cli.Ping() != nil {
+		return nil
+	}
+
+	return cli
+}
+
+// Defaults < configuration file < environment (.env included) < flags
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+
+	_ = utils.LoadEnv()
+
+	// Configuration file (optional unless it is explicitly set)
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		path = os.Getenv("HIDRA_CONFIG")
+	}
+	if path == "" {
+		if _, err := os.Stat(config.DefaultFile); err == nil {
+			path = config.DefaultFile
+		}
+	}
+
+	c, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range config.Keys() {
+		if f := cmd.Flags().Lookup(flagName(k.Name)); f != nil && f.Changed {
+			if err = c.Set(k.Name, f.Value.String()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// EPOCH_TIME --> --epoch-time
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
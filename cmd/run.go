@@ -5,6 +5,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/swarleynunez/hidra/core/daemons"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/utils"
 	"os"
 )
 
@@ -17,15 +18,17 @@ var runCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Initialize and configure node
-		managers.InitNode(ctx, false)
+		node, err := managers.InitNode(ctx, cfg, false)
+		utils.Fatal(err)
 
 		// Check if node is registered
-		if !managers.IsNodeRegistered(managers.GetFromAccount()) {
+		if !node.IsNodeRegistered(node.GetFromAccount()) {
 			fmt.Println("--> Node not registered at loaded controller contract")
 			os.Exit(0)
 		}
 
 		// Main loop
-		daemons.Run(ctx, args[0])
+		err = daemons.Run(ctx, node, cfg, args[0])
+		utils.Fatal(err)
 	},
 }
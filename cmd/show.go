@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
 )
 
 const showShortMsg = "Show active cluster applications and containers"
@@ -15,44 +17,82 @@ var showCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 	Args:                  cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Initialize and configure node
-		managers.InitNode(ctx, false)
+		var (
+			apps []types.APIApplication
+			ctrs []types.APIContainer
+			err  error
+		)
+
+		if cli := daemonClient(); cli != nil {
+			// Through the running daemon
+			apps, err = cli.Applications()
+			utils.Fatal(err)
+
+			ctrs, err = cli.Containers()
+			utils.Fatal(err)
+		} else {
+			// Initialize and configure node
+			node, err := managers.InitNode(ctx, cfg, false)
+			utils.Fatal(err)
+
+			apps, ctrs = activeApplications(node)
+		}
 
 		/*// Get flags
 		owned, err := cmd.Flags().GetBool("owned")
-		utils.CheckError(err, utils.FatalMode)
+		utils.Fatal(err)
 
 		// Filter active cluster applications
-		apps := managers.GetActiveApplications()
+		apps := node.GetActiveApplications()
 		if owned {
 			for appid, app := range apps {
-				if app.Owner != managers.GetFromAccount() {
+				if app.Owner != node.GetFromAccount() {
 					delete(apps, appid)
 				}
 			}
 		}*/
 
 		// Print cluster applications
-		apps := managers.GetActiveApplications()
 		if len(apps) == 0 {
 			fmt.Println("--> No cluster applications found")
 			return
 		}
-		for appid, app := range apps {
-			fmt.Println("--> APPID:", appid)
+		for _, app := range apps {
+			fmt.Println("--> APPID:", app.Appid)
 			fmt.Println("    OWNER:", app.Owner)
 			fmt.Println("    REGISTERED:", app.RegisteredAt)
 
 			// Print application's containers
-			ctrs := managers.GetApplicationContainersData(appid)
-			for rcid, ctr := range ctrs {
-				// Get container host
-				insts := managers.GetContainerInstances(rcid)
+			for _, ctr := range ctrs {
+				if ctr.Appid != app.Appid {
+					continue
+				}
 
-				fmt.Println("\t\tRCID:", rcid)
-				fmt.Println("\t\tHOST:", insts[len(insts)-1].Host)
+				fmt.Println("\t\tRCID:", ctr.Rcid)
+				fmt.Println("\t\tHOST:", ctr.Host)
 				fmt.Println("\t\tREGISTERED:", ctr.RegisteredAt)
 			}
 		}
 	},
 }
+
+// Applications and containers read from the DCR (without a running daemon)
+func activeApplications(node *managers.Node) (apps []types.APIApplication, ctrs []types.APIContainer) {
+
+	for appid, app := range node.GetActiveApplications() {
+		apps = append(apps, types.APIApplication{Appid: appid, Owner: app.Owner, RegisteredAt: app.RegisteredAt.Int64()})
+
+		for rcid, ctr := range node.GetApplicationContainersData(appid) {
+			// Get container host
+			insts := node.GetContainerInstances(rcid)
+
+			c := types.APIContainer{Rcid: rcid, Appid: appid, RegisteredAt: ctr.RegisteredAt.Int64()}
+			if len(insts) > 0 {
+				c.Host = insts[len(insts)-1].Host
+			}
+			ctrs = append(ctrs, c)
+		}
+	}
+
+	return
+}
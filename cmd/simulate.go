@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/swarleynunez/hidra/core/daemons"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"os"
+	"strconv"
+)
+
+const simulateShortMsg = "Run an in-process cluster experiment on a simulated chain"
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: simulateShortMsg,
+	Long: title + "\n\n" + "Info:\n  " + simulateShortMsg + "\n\n" +
+		"  Node profiles (--profiles) are loss%:max_latency_ms pairs assigned in a round-robin fashion\n" +
+		"  (default: PKT_LOSS_PROB and PKT_MAX_LATENCY). Failures (--fail) crash a node from an event\n" +
+		"  and optionally recover it (node@from[-until], e.g. 2@5-8).",
+	Args:        cobra.ExactArgs(0),
+	Annotations: map[string]string{skipValidation: ""}, // No Ethereum node is needed
+	Run: func(cmd *cobra.Command, args []string) {
+		nodes, _ := cmd.Flags().GetInt("nodes")
+		events, _ := cmd.Flags().GetUint64("events")
+		packets, _ := cmd.Flags().GetUint64("packets")
+		seed, _ := cmd.Flags().GetInt64("seed")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		sc := &daemons.SimulationConfig{
+			Nodes:       nodes,
+			Events:      events,
+			Packets:     packets,
+			LossProbTh:  cfg.Network.LossProbThreshold,
+			LatTh:       cfg.Network.LatencyThreshold,
+			Aggregation: cfg.Network.ScoreAggregation,
+			Trim:        cfg.Network.ScoreTrim,
+			Seed:        seed,
+		}
+
+		// Node profiles and failures
+		profiles, _ := cmd.Flags().GetString("profiles")
+		if profiles == "" {
+			profiles = strconv.FormatUint(cfg.Network.PktLossProb, 10) + ":" + strconv.FormatUint(cfg.Network.PktMaxLatency, 10)
+		}
+		var err error
+		sc.Profiles, err = daemons.ParseNodeProfiles(profiles)
+		utils.Fatal(err)
+
+		failures, _ := cmd.Flags().GetStringArray("fail")
+		for _, f := range failures {
+			var nf types.NodeFailure
+			nf, err = daemons.ParseNodeFailure(f)
+			utils.Fatal(err)
+			sc.Failures = append(sc.Failures, nf)
+		}
+
+		report, err := daemons.Simulate(ctx, sc)
+		utils.Fatal(err)
+
+		// Report (stdout by default)
+		w := os.Stdout
+		if output != "" {
+			w, err = os.Create(output)
+			utils.Fatal(err)
+			defer w.Close()
+		}
+		err = daemons.WriteSimulationReport(w, report, format)
+		utils.Fatal(err)
+	},
+}
@@ -12,6 +12,7 @@ var versionCmd = &cobra.Command{
 	Short:                 versionShortMsg,
 	Long:                  title + "\n\n" + "Info:\n  " + versionShortMsg,
 	DisableFlagsInUseLine: true,
+	Annotations:           map[string]string{skipValidation: ""},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("--> HIDRA 1.0.0")
 	},
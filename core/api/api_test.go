@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+var (
+	peer1 = common.HexToAddress("0x1")
+	peer2 = common.HexToAddress("0x2")
+)
+
+func testPeers() types.NodeStore {
+
+	return types.NodeStore{
+		peer1: {Reputation: types.ReputationInfo{Values: []uint8{1, 0}, Score: 0.5}},
+		peer2: {
+			CurrentEpoch: types.EpochInfo{OKPackets: 3, TotalPackets: 4},
+			Reputation:   types.ReputationInfo{Values: []uint8{1, 1}, Score: 1},
+		},
+	}
+}
+
+func testSources() *Sources {
+
+	return &Sources{Peers: testPeers, Timeline: NewTimeline(2)}
+}
+
+func TestAuthorization(t *testing.T) {
+
+	h := Handler(context.Background(), testToken, testSources())
+
+	cases := []struct {
+		path, auth string
+		status     int
+	}{
+		{"/v1/health", "", http.StatusOK},
+		{"/v1/peers", "", http.StatusUnauthorized},
+		{"/v1/peers", "Bearer wrong", http.StatusUnauthorized},
+		{"/v1/peers", "Bearer " + testToken, http.StatusOK},
+		{"/v1/unknown", "Bearer " + testToken, http.StatusNotFound},
+		{"/v1/apps/x", "Bearer " + testToken, http.StatusNotFound},
+		{"/v1/timeline?since=x", "Bearer " + testToken, http.StatusBadRequest},
+		{"/", "", http.StatusFound},
+		{"/dashboard/", "", http.StatusOK},
+		{"/dashboard/app.js", "", http.StatusOK},
+		{"/unknown", "", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.auth != "" {
+			req.Header.Set("Authorization", c.auth)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Fatal("ERROR:", t.Name(), c.path, rec.Code)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+
+	h := Handler(context.Background(), testToken, testSources())
+
+	req := httptest.NewRequest("PUT", "/v1/reputation", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+
+	params, ok := matchPath("containers/{rcid}/migrate", "containers/7/migrate")
+	if !ok || len(params) != 1 || params[0] != 7 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if _, ok = matchPath("containers/{rcid}/migrate", "containers/7"); ok {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if _, ok = matchPath("events/{eid}", "events/-1"); ok {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestListenLoopbackOnly(t *testing.T) {
+
+	if _, err := listen("0.0.0.0:0"); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	l, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	l.Close()
+}
+
+func TestClientOverUnixSocket(t *testing.T) {
+
+	dir := t.TempDir()
+	cfg := &Config{
+		Addr:      "unix:" + filepath.Join(dir, "hidra-api.sock"),
+		TokenFile: filepath.Join(dir, "hidra-api.token"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Serve(ctx, cfg, testSources())
+
+	// Wait for the generated token and the socket
+	var (
+		cli *Client
+		err error
+	)
+	for i := 0; i < 50; i++ {
+		if cli, err = NewClient(cfg); err == nil && cli.Ping() == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	peers, err := cli.Peers()
+	if err != nil || len(peers) != 2 || peers[0].Node != peer1 || peers[1].TotalPackets != 4 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Most reputable peers first
+	reps, err := cli.Reputation()
+	if err != nil || len(reps) != 2 || reps[0].Node != peer2 || reps[0].Epochs != 2 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Not supported by the controller contract
+	if cli.RemoveApplication(1) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Wrong token
+	cli.token = "wrong"
+	if _, err = cli.Peers(); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestTimeline(t *testing.T) {
+
+	tl := NewTimeline(2)
+	tl.Add(types.APITimelineEntry{Eid: 1, Phase: "NewEvent"})
+	tl.Add(types.APITimelineEntry{Eid: 1, Phase: "RequiredReplies"})
+	tl.Add(types.APITimelineEntry{Eid: 1, Phase: "RequiredVotes"})
+
+	// Bounded (oldest entries are dropped)
+	all := tl.Since(0)
+	if len(all) != 2 || all[0].Seq != 2 || all[1].Phase != "RequiredVotes" || all[1].At == 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if r := tl.Since(3); len(r) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
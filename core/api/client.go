@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/swarleynunez/hidra/core/types"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const clientTimeout = 60 * time.Second // Deploy requests wait for their transactions
+
+var errUnsuccessfulReq = errors.New("unsuccessful api request")
+
+// Thin client of a running node daemon
+type Client struct {
+	baseURL string
+	token   string
+	hc      *http.Client
+}
+
+func NewClient(cfg *Config) (*Client, error) {
+
+	token := cfg.Token
+	if token == "" {
+		b, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token == "" {
+		return nil, errEmptyToken
+	}
+
+	cli := &Client{
+		baseURL: "http://" + cfg.Addr,
+		token:   token,
+		hc:      &http.Client{Timeout: clientTimeout},
+	}
+
+	// Unix socket transport (the URL host is ignored)
+	if path, found := strings.CutPrefix(cfg.Addr, unixPrefix); found {
+		cli.baseURL = "http://hidra"
+		cli.hc.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	}
+
+	return cli, nil
+}
+
+// Is the daemon running?
+func (cli *Client) Ping() error {
+
+	return cli.do("GET", "/v1/health", nil, nil)
+}
+
+func (cli *Client) Applications() (apps []types.APIApplication, err error) {
+
+	err = cli.do("GET", "/v1/apps", nil, &apps)
+	return
+}
+
+func (cli *Client) Containers() (ctrs []types.APIContainer, err error) {
+
+	err = cli.do("GET", "/v1/containers", nil, &ctrs)
+	return
+}
+
+func (cli *Client) Artifacts() (arts []types.APIArtifact, err error) {
+
+	err = cli.do("GET", "/v1/artifacts", nil, &arts)
+	return
+}
+
+func (cli *Client) Events(last uint64) (events []types.APIEvent, err error) {
+
+	err = cli.do("GET", "/v1/events?last="+strconv.FormatUint(last, 10), nil, &events)
+	return
+}
+
+func (cli *Client) StalledEvents() (stalled []types.APIStalledEvent, err error) {
+
+	err = cli.do("GET", "/v1/events/stalled", nil, &stalled)
+	return
+}
+
+func (cli *Client) Event(eid uint64) (event *types.APIEvent, err error) {
+
+	err = cli.do("GET", "/v1/events/"+strconv.FormatUint(eid, 10), nil, &event)
+	return
+}
+
+func (cli *Client) Peers() (peers []types.APIPeer, err error) {
+
+	err = cli.do("GET", "/v1/peers", nil, &peers)
+	return
+}
+
+func (cli *Client) Reputation() (reps []types.APIReputation, err error) {
+
+	err = cli.do("GET", "/v1/reputation", nil, &reps)
+	return
+}
+
+func (cli *Client) DeployApplication(req *types.APIDeployRequest) error {
+
+	return cli.do("POST", "/v1/apps", req, nil)
+}
+
+func (cli *Client) RemoveApplication(appid uint64) error {
+
+	return cli.do("DELETE", "/v1/apps/"+strconv.FormatUint(appid, 10), nil, nil)
+}
+
+func (cli *Client) MigrateContainer(rcid uint64, req *types.APIMigrateRequest) error {
+
+	return cli.do("POST", "/v1/containers/"+strconv.FormatUint(rcid, 10)+"/migrate", req, nil)
+}
+
+func (cli *Client) do(method, path string, in, out interface{}) error {
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, cli.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cli.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := cli.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// API errors are returned as JSON statuses
+	if res.StatusCode >= 300 {
+		var st types.APIStatus
+		if json.NewDecoder(res.Body).Decode(&st) == nil && st.Error != "" {
+			return fmt.Errorf("%w (%d): %s", errUnsuccessfulReq, res.StatusCode, st.Error)
+		}
+		return fmt.Errorf("%w (%d)", errUnsuccessfulReq, res.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
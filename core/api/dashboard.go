@@ -0,0 +1,31 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+func dashboardHandler() http.Handler {
+
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // Embedded at build time
+	}
+
+	return http.FileServer(http.FS(files))
+}
+
+// Browsers opening the daemon address are sent to the dashboard
+func rootHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, errRouteNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard/", http.StatusFound)
+}
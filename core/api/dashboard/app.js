@@ -0,0 +1,195 @@
+// HIDRA dashboard (data read from the management API of the local daemon)
+"use strict";
+
+const refreshInterval = 5000; // In ms
+const timelineInterval = 2000; // In ms
+const maxTimelineEntries = 200;
+
+let token = localStorage.getItem("hidra-token") || "";
+let lastSeq = 0;
+
+// Tokens can also be passed as #token=... (not sent to the server)
+if (location.hash.startsWith("#token=")) {
+  token = decodeURIComponent(location.hash.slice(7));
+  localStorage.setItem("hidra-token", token);
+  history.replaceState(null, "", location.pathname);
+}
+
+document.getElementById("token-form").addEventListener("submit", (e) => {
+  e.preventDefault();
+  token = document.getElementById("token").value.trim();
+  localStorage.setItem("hidra-token", token);
+  refresh();
+});
+
+async function api(path) {
+  const res = await fetch("/v1/" + path, {headers: {Authorization: "Bearer " + token}});
+  if (!res.ok) {
+    throw new Error(path + ": " + res.status);
+  }
+  return res.json();
+}
+
+function setStatus(ok, msg) {
+  const el = document.getElementById("status");
+  el.textContent = msg;
+  el.className = ok ? "connected" : "";
+}
+
+function short(addr) {
+  return addr.slice(0, 8) + "…" + addr.slice(-4);
+}
+
+function cell(row, text, cls) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (cls) {
+    td.className = cls;
+  }
+  return td;
+}
+
+function gib(bytes) {
+  return (bytes / (1024 * 1024 * 1024)).toFixed(1) + " GiB";
+}
+
+// Sections //
+function renderNodes(nodes) {
+  const tbody = document.getElementById("nodes");
+  tbody.replaceChildren();
+  for (const n of nodes) {
+    const row = tbody.insertRow();
+    if (n.self) {
+      row.className = "self";
+    }
+    cell(row, short(n.node) + (n.self ? " (this node)" : ""), "addr").title = n.node;
+    cell(row, n.specs.ip + ":" + n.specs.port);
+    cell(row, n.specs.cores);
+    cell(row, gib(n.specs.mem));
+    cell(row, gib(n.specs.disk));
+    cell(row, n.specs.os + "/" + n.specs.arch);
+  }
+}
+
+function renderApps(apps, ctrs) {
+  const tbody = document.getElementById("apps");
+  tbody.replaceChildren();
+  for (const app of apps) {
+    const appCtrs = ctrs.filter((c) => c.appid === app.appid);
+    if (appCtrs.length === 0) {
+      appCtrs.push(null);
+    }
+    for (const c of appCtrs) {
+      const row = tbody.insertRow();
+      cell(row, app.appid);
+      cell(row, app.info.desc);
+      cell(row, app.info.ip + ":" + app.info.port + "/" + app.info.proto);
+      cell(row, c ? c.rcid : "-");
+      cell(row, c ? c.info.itag : "-");
+      cell(row, c ? short(c.host) : "-", "addr").title = c ? c.host : "";
+    }
+  }
+}
+
+// Nodes in a circle, one arrow per given reputation score (red 0 to green 1)
+function renderGraph(edges, nodes) {
+  const svg = document.getElementById("graph");
+  const ns = "http://www.w3.org/2000/svg";
+  svg.replaceChildren();
+
+  const addrs = new Set(nodes.map((n) => n.node));
+  for (const e of edges) {
+    addrs.add(e.from);
+    addrs.add(e.to);
+  }
+  const list = [...addrs].sort();
+  const pos = {};
+  list.forEach((addr, i) => {
+    const a = (2 * Math.PI * i) / list.length - Math.PI / 2;
+    pos[addr] = {x: 200 + 150 * Math.cos(a), y: 200 + 150 * Math.sin(a)};
+  });
+
+  for (const e of edges) {
+    const from = pos[e.from], to = pos[e.to];
+    const line = document.createElementNS(ns, "line");
+    line.setAttribute("x1", from.x);
+    line.setAttribute("y1", from.y);
+    // Stop before the target node to show the direction
+    line.setAttribute("x2", from.x + (to.x - from.x) * 0.9);
+    line.setAttribute("y2", from.y + (to.y - from.y) * 0.9);
+    line.setAttribute("stroke", "hsl(" + Math.round(120 * e.score) + ", 70%, 45%)");
+    line.setAttribute("stroke-width", 2);
+    const title = document.createElementNS(ns, "title");
+    title.textContent = short(e.from) + " → " + short(e.to) + ": " + e.score.toFixed(2);
+    line.appendChild(title);
+    svg.appendChild(line);
+  }
+
+  const self = nodes.find((n) => n.self);
+  for (const addr of list) {
+    const c = document.createElementNS(ns, "circle");
+    c.setAttribute("cx", pos[addr].x);
+    c.setAttribute("cy", pos[addr].y);
+    c.setAttribute("r", 8);
+    c.setAttribute("fill", self && self.node === addr ? "#1f3a5f" : "#8b949e");
+    svg.appendChild(c);
+
+    const t = document.createElementNS(ns, "text");
+    t.setAttribute("x", pos[addr].x + 10);
+    t.setAttribute("y", pos[addr].y - 10);
+    t.textContent = short(addr);
+    svg.appendChild(t);
+  }
+}
+
+function renderTimeline(entries) {
+  const ol = document.getElementById("timeline");
+  for (const e of entries) {
+    lastSeq = Math.max(lastSeq, e.seq);
+
+    let text = new Date(e.at).toLocaleTimeString() + " EID " + e.eid + " " + e.phase;
+    if (e.phase === "NewEvent") {
+      text += " from " + short(e.node) + (e.rcid ? " (RCID " + e.rcid + ")" : "");
+    } else if (e.phase === "RequiredReplies") {
+      text += " (" + e.replies + " replies)";
+    } else if (e.phase === "RequiredVotes" || e.phase === "EventSolved") {
+      text += " by " + short(e.node);
+    }
+
+    const li = document.createElement("li");
+    li.className = e.phase;
+    li.textContent = text;
+    ol.prepend(li);
+  }
+  while (ol.children.length > maxTimelineEntries) {
+    ol.lastChild.remove();
+  }
+}
+
+// Refresh loops //
+async function refresh() {
+  try {
+    const [nodes, apps, ctrs, graph] = await Promise.all([
+      api("nodes"), api("apps"), api("containers"), api("reputation/graph"),
+    ]);
+    renderNodes(nodes);
+    renderApps(apps, ctrs);
+    renderGraph(graph, nodes);
+    setStatus(true, "connected");
+  } catch (err) {
+    setStatus(false, err.message);
+  }
+}
+
+async function refreshTimeline() {
+  try {
+    renderTimeline(await api("timeline?since=" + lastSeq));
+  } catch (err) {
+    setStatus(false, err.message);
+  }
+}
+
+refresh();
+refreshTimeline();
+setInterval(refresh, refreshInterval);
+setInterval(refreshTimeline, timelineInterval);
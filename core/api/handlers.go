@@ -0,0 +1,444 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultLastEvents = 50
+	graphEvents       = 10      // Events whose replies are used to build the reputation graph
+	maxBodySize       = 1 << 20 // In bytes
+)
+
+var (
+	errRouteNotFound     = errors.New("api route not found")
+	errMethodNotAllowed  = errors.New("method not allowed")
+	errBadParam          = errors.New("malformed path parameter")
+	errBadBody           = errors.New("malformed request body")
+	errNoContainers      = errors.New("an application needs at least one container")
+	errRemoveUnsupported = errors.New("application removal not supported by the controller contract")
+)
+
+type server struct {
+	ctx context.Context
+	src *Sources
+}
+
+type handler func(s *server, w http.ResponseWriter, r *http.Request, params []uint64)
+
+// API route (path templates like those of the ONOS routes)
+type route struct {
+	Method  string
+	Path    string
+	Handler handler
+}
+
+var routes = []route{
+	{"GET", "apps", listApps},
+	{"GET", "artifacts", listArtifacts},
+	{"POST", "apps", deployApp},
+	{"DELETE", "apps/{appid}", removeApp},
+	{"GET", "containers", listContainers},
+	{"POST", "containers/{rcid}/migrate", migrateContainer},
+	{"GET", "events", listEvents},
+	{"GET", "events/stalled", listStalledEvents},
+	{"GET", "events/{eid}", getEvent},
+	{"GET", "peers", listPeers},
+	{"GET", "reputation", listReputation},
+	{"GET", "reputation/graph", reputationGraph},
+	{"GET", "nodes", listNodes},
+	{"GET", "timeline", listTimeline},
+}
+
+func (s *server) route(w http.ResponseWriter, r *http.Request) {
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+
+	var pathFound bool
+	for i := range routes {
+		params, ok := matchPath(routes[i].Path, path)
+		if !ok {
+			continue
+		}
+		pathFound = true
+
+		if routes[i].Method == r.Method {
+			routes[i].Handler(s, w, r, params)
+			return
+		}
+	}
+
+	if pathFound {
+		writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+	} else {
+		writeError(w, http.StatusNotFound, errRouteNotFound)
+	}
+}
+
+// Applications //
+func listApps(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	apps := []types.APIApplication{}
+	for appid, app := range s.src.Node.GetActiveApplications() {
+		var ainfo types.ApplicationInfo
+		utils.UnmarshalJSON(app.Info, &ainfo)
+
+		apps = append(apps, types.APIApplication{
+			Appid:        appid,
+			Owner:        app.Owner,
+			Info:         ainfo,
+			Containers:   s.src.Node.GetApplicationContainers(appid),
+			RegisteredAt: app.RegisteredAt.Int64(),
+		})
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Appid < apps[j].Appid })
+
+	writeJSON(w, http.StatusOK, apps)
+}
+
+func deployApp(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	var req types.APIDeployRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Containers) == 0 {
+		writeError(w, http.StatusBadRequest, errNoContainers)
+		return
+	}
+
+	err := s.src.Node.RegisterApplication(s.ctx, &req.App, req.Containers, req.Autodeploy)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.Info("Application deployment submitted", "source", "api")
+
+	writeJSON(w, http.StatusAccepted, &types.APIStatus{Status: "submitted"})
+}
+
+func removeApp(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	// TODO. The controller contract has no unregisterApplication function yet
+	writeError(w, http.StatusNotImplemented, fmt.Errorf("%w (appid %d)", errRemoveUnsupported, params[0]))
+}
+
+// Containers //
+func listContainers(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	ctrs := []types.APIContainer{}
+	for rcid, ctr := range s.src.Node.GetActiveContainers() {
+		var cinfo types.ContainerInfo
+		utils.UnmarshalJSON(ctr.Info, &cinfo)
+
+		c := types.APIContainer{
+			Rcid:         rcid,
+			Appid:        ctr.Appid,
+			Info:         cinfo,
+			Autodeployed: ctr.Autodeployed,
+			RegisteredAt: ctr.RegisteredAt.Int64(),
+		}
+		if insts := s.src.Node.GetContainerInstances(rcid); len(insts) > 0 {
+			c.Host = insts[len(insts)-1].Host
+		}
+
+		ctrs = append(ctrs, c)
+	}
+	sort.Slice(ctrs, func(i, j int) bool { return ctrs[i].Rcid < ctrs[j].Rcid })
+
+	writeJSON(w, http.StatusOK, ctrs)
+}
+
+func migrateContainer(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	var req types.APIMigrateRequest
+	if err := readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	etype := types.EventType{RequiredTask: types.MigrateContainerTask, Resource: req.Resource}
+	err := s.src.Node.SendEvent(s.ctx, &etype, params[0])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.Info("Container migration submitted", "rcid", params[0], "source", "api")
+
+	writeJSON(w, http.StatusAccepted, &types.APIStatus{Status: "submitted"})
+}
+
+// Events //
+func listEvents(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	last := uint64(defaultLastEvents)
+	if v := r.URL.Query().Get("last"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: last", errBadParam))
+			return
+		}
+		last = n
+	}
+
+	latest, err := s.src.Node.GetLatestEvents(last)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	events := []types.APIEvent{}
+	for eid, event := range latest {
+		events = append(events, EventView(eid, event))
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Eid > events[j].Eid })
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func listArtifacts(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	arts := []types.APIArtifact{}
+	if s.src.Artifacts != nil {
+		arts = s.src.Artifacts()
+	}
+
+	writeJSON(w, http.StatusOK, arts)
+}
+
+func listStalledEvents(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	stalled := []types.APIStalledEvent{}
+	if s.src.Stalled != nil {
+		stalled = s.src.Stalled()
+	}
+
+	writeJSON(w, http.StatusOK, stalled)
+}
+
+func getEvent(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	event := s.src.Node.GetEvent(params[0])
+	if event.SentAt == nil || event.SentAt.Sign() == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("event %d not found", params[0]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, EventView(params[0], event))
+}
+
+// Peers and reputation //
+func listPeers(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	peers := []types.APIPeer{}
+	for addr, info := range s.src.Peers() {
+		p := types.APIPeer{
+			Node:         addr,
+			OKPackets:    info.CurrentEpoch.OKPackets,
+			TotalPackets: info.CurrentEpoch.TotalPackets,
+			Score:        info.Reputation.Score,
+			Values:       []int{},
+		}
+		for _, v := range info.Reputation.Values {
+			p.Values = append(p.Values, int(v))
+		}
+		if s.src.Node != nil {
+			p.Status = s.src.Node.GetNodeStatus(addr).String()
+		}
+
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Node.Hex() < peers[j].Node.Hex() })
+
+	writeJSON(w, http.StatusOK, peers)
+}
+
+func listReputation(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	reps := []types.APIReputation{}
+	for addr, info := range s.src.Peers() {
+		reps = append(reps, types.APIReputation{
+			Node:   addr,
+			Score:  info.Reputation.Score,
+			Epochs: len(info.Reputation.Values),
+		})
+	}
+
+	// Most reputable peers first
+	sort.Slice(reps, func(i, j int) bool {
+		if reps[i].Score != reps[j].Score {
+			return reps[i].Score > reps[j].Score
+		}
+		return reps[i].Node.Hex() < reps[j].Node.Hex()
+	})
+
+	writeJSON(w, http.StatusOK, reps)
+}
+
+// Reputation scores given by every node (own peers and the replies of the last events)
+func reputationGraph(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	from := s.src.Node.GetFromAccount()
+	edges := map[[2]common.Address]float64{}
+	for addr, info := range s.src.Peers() {
+		edges[[2]common.Address{from, addr}] = info.Reputation.Score
+	}
+
+	// Older replies are overwritten by newer ones
+	events, err := s.src.Node.GetLatestEvents(graphEvents)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	eids := make([]uint64, 0, len(events))
+	for eid := range events {
+		eids = append(eids, eid)
+	}
+	sort.Slice(eids, func(i, j int) bool { return eids[i] < eids[j] })
+
+	for _, eid := range eids {
+		for _, reply := range s.src.Node.GetEventReplies(eid) {
+			if reply.Replier == from {
+				continue
+			}
+			for _, rs := range reply.RepScores {
+				if claim, err := managers.DecodeReputationClaim(rs.Score); err == nil {
+					edges[[2]common.Address{reply.Replier, rs.Node}] = claim.Score
+				}
+			}
+		}
+	}
+
+	graph := []types.APIReputationEdge{}
+	for k, score := range edges {
+		graph = append(graph, types.APIReputationEdge{From: k[0], To: k[1], Score: score})
+	}
+	sort.Slice(graph, func(i, j int) bool {
+		if graph[i].From != graph[j].From {
+			return graph[i].From.Hex() < graph[j].From.Hex()
+		}
+		return graph[i].To.Hex() < graph[j].To.Hex()
+	})
+
+	writeJSON(w, http.StatusOK, graph)
+}
+
+// Topology //
+func listNodes(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	from := s.src.Node.GetFromAccount()
+
+	nodes := []types.APINode{}
+	for addr, specs := range s.src.Node.GetAllNodeSpecs() {
+		n := types.APINode{Node: addr, Self: addr == from}
+		utils.UnmarshalJSON(specs, &n.Specs)
+
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node.Hex() < nodes[j].Node.Hex() })
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+// DEL event phases newer than the since sequence number
+func listTimeline(s *server, w http.ResponseWriter, r *http.Request, params []uint64) {
+
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: since", errBadParam))
+			return
+		}
+		since = n
+	}
+
+	writeJSON(w, http.StatusOK, s.src.Timeline.Since(since))
+}
+
+// Helpers //
+// Match a path against a route template returning its parameters
+func matchPath(tmpl, path string) ([]uint64, bool) {
+
+	tparts, pparts := strings.Split(tmpl, "/"), strings.Split(path, "/")
+	if len(tparts) != len(pparts) {
+		return nil, false
+	}
+
+	var params []uint64
+	for i := range tparts {
+		if strings.HasPrefix(tparts[i], "{") {
+			n, err := strconv.ParseUint(pparts[i], 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			params = append(params, n)
+		} else if tparts[i] != pparts[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// API view of a DEL event (also used by the CLI without a running daemon)
+func EventView(eid uint64, event *types.Event) types.APIEvent {
+
+	var etype types.EventType
+	utils.UnmarshalJSON(event.EType, &etype)
+
+	e := types.APIEvent{
+		Eid:                eid,
+		Type:               etype,
+		Sender:             event.Sender,
+		Solver:             event.Solver,
+		Rcid:               event.Rcid,
+		HasRequiredReplies: event.HasRequiredReplies,
+		HasRequiredVotes:   event.HasRequiredVotes,
+	}
+	if event.SentAt != nil {
+		e.SentAt = event.SentAt.Int64()
+	}
+	if event.SolvedAt != nil {
+		e.SolvedAt = event.SolvedAt.Int64()
+	}
+
+	return e
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+
+	err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %v", errBadBody, err)
+	}
+
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	utils.LogWarning(err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+
+	writeJSON(w, status, &types.APIStatus{Error: err.Error()})
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	unixPrefix = "unix:"
+	tokenSize  = 32 // In bytes
+)
+
+var (
+	errNotLoopback  = errors.New("api address must be a unix socket or a loopback address")
+	errUnauthorized = errors.New("missing or wrong api token")
+	errEmptyToken   = errors.New("empty api token")
+)
+
+// Management API settings
+type Config struct {
+	Addr          string // unix:PATH or a loopback HOST:PORT
+	DashboardAddr string // Loopback HOST:PORT for browsers (disabled if empty)
+	Token         string // Bearer token (generated and saved into TokenFile if empty)
+	TokenFile     string
+}
+
+// Live daemon state not stored in the DCR
+type PeersFunc func() types.NodeStore
+type StalledFunc func() []types.APIStalledEvent
+type ArtifactsFunc func() []types.APIArtifact
+
+type Sources struct {
+	Node      *managers.Node
+	Peers     PeersFunc
+	Timeline  *Timeline
+	Stalled   StalledFunc   // Nil if deadlines are not tracked
+	Artifacts ArtifactsFunc // Nil if artifact replicas are not counted
+}
+
+// Serve the management API (and the dashboard) until the context is done
+func Serve(ctx context.Context, cfg *Config, src *Sources) error {
+
+	token := cfg.Token
+	if token == "" {
+		b := make([]byte, tokenSize)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(b)
+
+		// Local clients read the token from this file
+		if err := os.WriteFile(cfg.TokenFile, []byte(token+"\n"), 0600); err != nil {
+			return err
+		}
+	}
+
+	addrs := []string{cfg.Addr}
+	if cfg.DashboardAddr != "" {
+		addrs = append(addrs, cfg.DashboardAddr)
+	}
+
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		l, err := listen(addr)
+		if err != nil {
+			for i := range listeners {
+				listeners[i].Close()
+			}
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+
+	srv := &http.Server{
+		Handler:           Handler(ctx, token, src),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	// The first listener error stops the server
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- srv.Serve(l)
+		}(l)
+	}
+
+	err := <-errs
+	_ = srv.Close()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func Handler(ctx context.Context, token string, src *Sources) http.Handler {
+
+	s := &server{ctx: ctx, src: src}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, &types.APIStatus{Status: "ok"})
+	})
+	mux.Handle("/v1/", authorize(token, http.HandlerFunc(s.route)))
+
+	// Web dashboard (static files, data is read through the API)
+	mux.Handle("/dashboard/", http.StripPrefix("/dashboard/", dashboardHandler()))
+	mux.HandleFunc("/", rootHandler)
+
+	return mux
+}
+
+// Helpers //
+func listen(addr string) (net.Listener, error) {
+
+	// Unix socket (only reachable by the node owner)
+	if path, found := strings.CutPrefix(addr, unixPrefix); found {
+		// Remove a socket left by a previous run
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+
+		return l, os.Chmod(path, 0600)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("%w: %q", errNotLoopback, addr)
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+func authorize(token string, next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"github.com/swarleynunez/hidra/core/types"
+	"sync"
+	"time"
+)
+
+const DefaultTimelineSize = 500
+
+// Last DEL event phases seen by the watchers (bounded, oldest first)
+type Timeline struct {
+	mutex   sync.RWMutex
+	seq     uint64
+	size    int
+	entries []types.APITimelineEntry
+}
+
+func NewTimeline(size int) *Timeline {
+
+	return &Timeline{size: size}
+}
+
+// Append an entry setting its sequence number and time (if not set)
+func (tl *Timeline) Add(e types.APITimelineEntry) {
+
+	tl.mutex.Lock()
+	defer tl.mutex.Unlock()
+
+	tl.seq++
+	e.Seq = tl.seq
+	if e.At == 0 {
+		e.At = time.Now().UnixMilli()
+	}
+
+	tl.entries = append(tl.entries, e)
+	if len(tl.entries) > tl.size {
+		tl.entries = tl.entries[len(tl.entries)-tl.size:]
+	}
+}
+
+// Entries newer than a sequence number (0 for all)
+func (tl *Timeline) Since(seq uint64) []types.APITimelineEntry {
+
+	tl.mutex.RLock()
+	defer tl.mutex.RUnlock()
+
+	r := []types.APITimelineEntry{}
+	for i := range tl.entries {
+		if tl.entries[i].Seq > seq {
+			r = append(r, tl.entries[i])
+		}
+	}
+
+	return r
+}
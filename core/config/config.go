@@ -0,0 +1,328 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultFile = "hidra.yaml"
+	maskedValue = "******"
+)
+
+var (
+	errUnknownKey  = errors.New("unknown configuration key")
+	errWrongValue  = errors.New("wrong configuration value")
+	errUnknownKind = errors.New("unsupported configuration field kind")
+)
+
+// Node settings (defaults < YAML file < environment < CLI flags)
+type Config struct {
+	Eth        EthConfig        `yaml:"eth"`
+	Node       NodeConfig       `yaml:"node"`
+	Docker     DockerConfig     `yaml:"docker"`
+	Monitor    MonitorConfig    `yaml:"monitor"` // MonitorV1
+	Network    NetworkConfig    `yaml:"network"` // MonitorV2
+	Events     EventsConfig     `yaml:"events"`  // DEL deadlines
+	Storage    StorageConfig    `yaml:"storage"` // Blobs of the CRUD tasks
+	ONOS       ONOSConfig       `yaml:"onos"`
+	API        APIConfig        `yaml:"api"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Experiment ExperimentConfig `yaml:"experiment"`
+	Log        LogConfig        `yaml:"log"`
+}
+
+type EthConfig struct {
+	ChainID    uint64 `yaml:"chain_id" env:"CHAIN_ID" desc:"ethereum chain id (transaction replay protection)"`
+	NodeDir    string `yaml:"node_dir" env:"ETH_NODE_DIR" desc:"ethereum node directory (geth.ipc and keystore)"`
+	NodePass   string `yaml:"node_pass" env:"ETH_NODE_PASS" secret:"true" desc:"keystore account passphrase"`
+	Controller string `yaml:"controller" env:"CONTROLLER_ADDR" desc:"controller smart contract address"`
+}
+
+type NodeConfig struct {
+	AdvertiseAddrs string `yaml:"advertise_addrs" env:"ADVERTISE_ADDRS" desc:"comma-separated advertised IPs or interface names (auto-detected if empty)"`
+	Location       string `yaml:"location" env:"NODE_LOCATION" desc:"advertised node location (LAT,LONG in degrees, not advertised if empty)"`
+}
+
+type DockerConfig struct {
+	Enabled bool `yaml:"enabled" env:"DOCKER_ENABLED" desc:"connect to the local Docker daemon (hosted containers, container rules and artifacts)"`
+}
+
+type MonitorConfig struct {
+	Interval        uint64 `yaml:"interval" env:"MONITOR_INTERVAL" desc:"state sampling interval (ms)"`
+	CycleTime       uint64 `yaml:"cycle_time" env:"CYCLE_TIME" desc:"default rule pending time (ms)"`
+	RulesFile       string `yaml:"rules_file" env:"RULES_FILE" desc:"rules file (.yaml or .json, compiled-in rules if empty)"`
+	RulesLogFile    string `yaml:"rules_log_file" env:"RULES_LOG_FILE" desc:"rule actions log file"`
+	RulesLogMaxSize int64  `yaml:"rules_log_max_size" env:"RULES_LOG_MAX_SIZE" desc:"rule actions log maximum size (MB)"`
+	RulesLogBackups int    `yaml:"rules_log_backups" env:"RULES_LOG_BACKUPS" desc:"rotated rule actions logs to keep"`
+}
+
+type NetworkConfig struct {
+	MaxMonitoredPkts  uint64 `yaml:"max_monitored_pkts" env:"MAX_MONITORED_PKTS" desc:"packets to monitor in experiments"`
+	PktLossProb       uint64 `yaml:"pkt_loss_prob" env:"PKT_LOSS_PROB" desc:"simulated packet loss probability (%)"`
+	PktMaxLatency     uint64 `yaml:"pkt_max_latency" env:"PKT_MAX_LATENCY" desc:"simulated packet maximum latency (ms)"`
+	EpochTime         uint64 `yaml:"epoch_time" env:"EPOCH_TIME" desc:"reputation epoch duration (s)"`
+	LossProbThreshold uint64 `yaml:"loss_prob_threshold" env:"LOSS_PROB_THRESHOLD" desc:"maximum peer packet loss per epoch (%)"`
+	LatencyThreshold  uint64 `yaml:"latency_threshold" env:"LATENCY_THRESHOLD" desc:"maximum peer mean latency per epoch (ms)"`
+	ScoreAggregation  string `yaml:"score_aggregation" env:"SCORE_AGGREGATION" desc:"reply scores aggregation per node (topk, median or trimmed, the same on every node)"`
+	ScoreTrim         uint64 `yaml:"score_trim" env:"SCORE_TRIM" desc:"reply scores trimmed at each end by the trimmed mean (%, the same on every node)"`
+}
+
+type EventsConfig struct {
+	ReplyTimeout uint64 `yaml:"reply_timeout" env:"EVENT_REPLY_TIMEOUT" desc:"time to get the required replies of an event (s)"`
+	VoteTimeout  uint64 `yaml:"vote_timeout" env:"EVENT_VOTE_TIMEOUT" desc:"time to get the required votes of an event (s)"`
+	SolveTimeout uint64 `yaml:"solve_timeout" env:"EVENT_SOLVE_TIMEOUT" desc:"time for the voted solver to solve an event (s)"`
+	MaxRetries   uint64 `yaml:"max_retries" env:"EVENT_MAX_RETRIES" desc:"times a stalled event is re-issued by its sender"`
+}
+
+type StorageConfig struct {
+	Dir          string `yaml:"dir" env:"STORAGE_DIR" desc:"content-addressed blob store directory"`
+	Addr         string `yaml:"addr" env:"STORAGE_ADDR" desc:"blob server address (its port is registered in the node specs)"`
+	FetchTimeout uint64 `yaml:"fetch_timeout" env:"STORAGE_FETCH_TIMEOUT" desc:"blob fetch timeout per peer (s)"`
+	MinReplicas  uint64 `yaml:"min_replicas" env:"STORAGE_MIN_REPLICAS" desc:"artifact replicas under which a warning is logged"`
+	CheckTime    uint64 `yaml:"check_time" env:"STORAGE_CHECK_TIME" desc:"artifact replicas counting interval (s)"`
+}
+
+type ONOSConfig struct {
+	Enabled        bool   `yaml:"enabled" env:"ONOS_ENABLED" desc:"enable the ONOS SDN module"`
+	ControllerIP   string `yaml:"controller_ip" env:"ONOS_CONTROLLER_IP" desc:"ONOS controller ip"`
+	ControllerPort uint16 `yaml:"controller_port" env:"ONOS_CONTROLLER_PORT" desc:"ONOS controller port"`
+	APIPath        string `yaml:"api_path" env:"ONOS_API_PATH" desc:"ONOS virtual service API base path"`
+	APIUser        string `yaml:"api_user" env:"ONOS_API_USER" desc:"ONOS API username"`
+	APIPass        string `yaml:"api_pass" env:"ONOS_API_PASS" secret:"true" desc:"ONOS API password"`
+	TLSEnabled     bool   `yaml:"tls_enabled" env:"ONOS_TLS_ENABLED" desc:"use https to reach the ONOS controller"`
+	TLSCAFile      string `yaml:"tls_ca_file" env:"ONOS_TLS_CA_FILE" desc:"custom CA bundle (PEM) of the ONOS controller"`
+	RequestTimeout uint64 `yaml:"request_timeout" env:"ONOS_REQUEST_TIMEOUT" desc:"ONOS request timeout (s)"`
+	MaxRetries     int    `yaml:"max_retries" env:"ONOS_MAX_RETRIES" desc:"retries of idempotent ONOS requests"`
+	RetryBackoff   uint64 `yaml:"retry_backoff" env:"ONOS_RETRY_BACKOFF" desc:"initial delay between ONOS retries (ms)"`
+	QueueSize      uint64 `yaml:"queue_size" env:"ONOS_QUEUE_SIZE" desc:"VS operations queued while the ONOS controller is unreachable"`
+}
+
+type APIConfig struct {
+	Addr          string `yaml:"addr" env:"API_ADDR" desc:"management API address (unix:PATH or loopback HOST:PORT)"`
+	DashboardAddr string `yaml:"dashboard_addr" env:"DASHBOARD_ADDR" desc:"web dashboard loopback address (disabled if empty)"`
+	Token         string `yaml:"token" env:"API_TOKEN" secret:"true" desc:"management API token (generated if empty)"`
+	TokenFile     string `yaml:"token_file" env:"API_TOKEN_FILE" desc:"file where the generated API token is saved"`
+}
+
+type MetricsConfig struct {
+	Addr string `yaml:"addr" env:"METRICS_ADDR" desc:"prometheus metrics address"`
+}
+
+type ExperimentConfig struct {
+	File           string `yaml:"file" env:"EXPERIMENT_FILE" desc:"experiment records file (.csv or .json, disabled if empty)"`
+	SampleInterval uint64 `yaml:"sample_interval" env:"EXPERIMENT_SAMPLE_INTERVAL" desc:"process CPU/memory sampling interval (ms)"`
+}
+
+type LogConfig struct {
+	Level   string `yaml:"level" env:"LOG_LEVEL" desc:"log level (debug, info, warn or error)"`
+	Format  string `yaml:"format" env:"LOG_FORMAT" desc:"log format (logfmt or json)"`
+	Output  string `yaml:"output" env:"LOG_OUTPUT" desc:"comma-separated log outputs (stderr, stdout or file paths)"`
+	MaxSize int64  `yaml:"max_size" env:"LOG_MAX_SIZE" desc:"log files maximum size (MB)"`
+	Backups int    `yaml:"backups" env:"LOG_BACKUPS" desc:"rotated log files to keep"`
+}
+
+// Configuration key (environment variable name)
+type Key struct {
+	Name   string
+	Desc   string
+	Secret bool
+}
+
+func Default() *Config {
+
+	return &Config{
+		Docker: DockerConfig{Enabled: true},
+		Monitor: MonitorConfig{
+			Interval:        1000,
+			CycleTime:       1000,
+			RulesLogFile:    "hidra-rules.log",
+			RulesLogMaxSize: 10,
+			RulesLogBackups: 3,
+		},
+		Network: NetworkConfig{
+			MaxMonitoredPkts:  1000,
+			PktLossProb:       50,
+			PktMaxLatency:     50,
+			EpochTime:         3,
+			LossProbThreshold: 50,
+			LatencyThreshold:  50,
+			ScoreAggregation:  "topk",
+			ScoreTrim:         20,
+		},
+		Events: EventsConfig{
+			ReplyTimeout: 60,
+			VoteTimeout:  60,
+			SolveTimeout: 120,
+			MaxRetries:   2,
+		},
+		Storage: StorageConfig{
+			Dir:          "hidra-blobs",
+			Addr:         "0.0.0.0:9400",
+			FetchTimeout: 30,
+			MinReplicas:  2,
+			CheckTime:    60,
+		},
+		ONOS: ONOSConfig{
+			ControllerPort: 8181,
+			APIPath:        "/onos/vs",
+			RequestTimeout: 30,
+			MaxRetries:     3,
+			RetryBackoff:   500,
+			QueueSize:      256,
+		},
+		API: APIConfig{
+			Addr:          "unix:hidra-api.sock",
+			DashboardAddr: "localhost:9102",
+			TokenFile:     "hidra-api.token",
+		},
+		Metrics:    MetricsConfig{Addr: "localhost:9101"},
+		Experiment: ExperimentConfig{SampleInterval: 100},
+		Log: LogConfig{
+			Level:   "info",
+			Format:  "logfmt",
+			Output:  "stderr",
+			MaxSize: 10,
+			Backups: 3,
+		},
+	}
+}
+
+// Defaults overridden by a YAML file (if any) and the environment
+func Load(path string) (*Config, error) {
+
+	cfg := Default()
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// Environment variables (empty values are ignored)
+	for _, k := range Keys() {
+		if v := os.Getenv(k.Name); v != "" {
+			if err := cfg.Set(k.Name, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// All configuration keys sorted by name
+func Keys() (keys []Key) {
+
+	walk(reflect.ValueOf(&Config{}).Elem(), func(f reflect.StructField, _ reflect.Value) {
+		keys = append(keys, Key{Name: f.Tag.Get("env"), Desc: f.Tag.Get("desc"), Secret: f.Tag.Get("secret") == "true"})
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+
+	return
+}
+
+// Set a setting by key from its text value
+func (c *Config) Set(key, value string) error {
+
+	var (
+		found bool
+		err   error
+	)
+	walk(reflect.ValueOf(c).Elem(), func(f reflect.StructField, v reflect.Value) {
+		if f.Tag.Get("env") == key {
+			found = true
+			err = setValue(v, value)
+		}
+	})
+
+	if !found {
+		return fmt.Errorf("%w: %q", errUnknownKey, key)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s=%q: %v", errWrongValue, key, value, err)
+	}
+
+	return nil
+}
+
+// Reply scores aggregation advertised in the node specs ("topk", "median" or "trimmed:TRIM")
+func (c *NetworkConfig) AggregationSpec() string {
+
+	switch method := strings.ToLower(c.ScoreAggregation); method {
+	case "", "topk":
+		return "topk"
+	case "trimmed":
+		return method + ":" + strconv.FormatUint(c.ScoreTrim, 10)
+	default:
+		return method
+	}
+}
+
+// Copy without secrets (to be shown)
+func (c *Config) Masked() *Config {
+
+	mc := *c
+	walk(reflect.ValueOf(&mc).Elem(), func(f reflect.StructField, v reflect.Value) {
+		if f.Tag.Get("secret") == "true" && v.String() != "" {
+			v.SetString(maskedValue)
+		}
+	})
+
+	return &mc
+}
+
+// Helpers //
+// Call fn for every configuration key field
+func walk(v reflect.Value, fn func(f reflect.StructField, v reflect.Value)) {
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Struct {
+			walk(v.Field(i), fn)
+		} else if t.Field(i).Tag.Get("env") != "" {
+			fn(t.Field(i), v.Field(i))
+		}
+	}
+}
+
+func setValue(v reflect.Value, s string) error {
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint16, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return errUnknownKind
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testFile(t *testing.T, content string) string {
+
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	return path
+}
+
+func TestPrecedence(t *testing.T) {
+
+	path := testFile(t, "network:\n  epoch_time: 5\n  latency_threshold: 80\n")
+	t.Setenv("LATENCY_THRESHOLD", "90")
+	t.Setenv("PKT_LOSS_PROB", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Default < file < environment (empty values ignored)
+	if cfg.Network.EpochTime != 5 ||
+		cfg.Network.LatencyThreshold != 90 ||
+		cfg.Network.PktLossProb != Default().Network.PktLossProb {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Flags
+	if cfg.Set("EPOCH_TIME", "7") != nil || cfg.Network.EpochTime != 7 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestSet(t *testing.T) {
+
+	cfg := Default()
+
+	if cfg.Set("ONOS_ENABLED", "true") != nil || !cfg.ONOS.Enabled {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if err := cfg.Set("ONOS_CONTROLLER_PORT", "70000"); !errors.Is(err, errWrongValue) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if err := cfg.Set("UNKNOWN", "1"); !errors.Is(err, errUnknownKey) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestMalformedFile(t *testing.T) {
+
+	if _, err := Load(testFile(t, "network: [")); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	cfg := Default()
+	cfg.Eth = EthConfig{ChainID: 12345, NodeDir: "N1", NodePass: "pass"}
+	if cfg.Validate() != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Every problem is reported
+	cfg.Network.LossProbThreshold = 101
+	cfg.Network.EpochTime = 0
+	cfg.Eth.NodePass = ""
+	err := cfg.Validate()
+	if !errors.Is(err, errOutOfRange) || !errors.Is(err, errRequired) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// ONOS settings only if enabled
+	cfg = Default()
+	cfg.Eth = EthConfig{ChainID: 12345, NodeDir: "N1", NodePass: "pass"}
+	cfg.ONOS.ControllerIP = "x"
+	if cfg.Validate() != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cfg.ONOS.Enabled = true
+	if !errors.Is(cfg.Validate(), errMalformed) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Experiment files by extension
+	cfg = Default()
+	cfg.Eth = EthConfig{ChainID: 12345, NodeDir: "N1", NodePass: "pass"}
+	cfg.Experiment.File = "run1.csv"
+	if cfg.Validate() != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cfg.Experiment.File = "run1.txt"
+	if !errors.Is(cfg.Validate(), errMalformed) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// DEL deadlines
+	cfg = Default()
+	cfg.Eth = EthConfig{ChainID: 12345, NodeDir: "N1", NodePass: "pass"}
+	cfg.Events.SolveTimeout = 0
+	if !errors.Is(cfg.Validate(), errOutOfRange) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Advertised addresses and location
+	cfg = Default()
+	cfg.Eth = EthConfig{ChainID: 12345, NodeDir: "N1", NodePass: "pass"}
+	cfg.Node.AdvertiseAddrs = "eth0, 10.0.0.5"
+	if cfg.Validate() != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cfg.Node.AdvertiseAddrs = "eth0,,10.0.0.5"
+	if !errors.Is(cfg.Validate(), errMalformed) {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cfg.Node.AdvertiseAddrs = ""
+	cfg.Node.Location = "40.4168,-3.7038"
+	if cfg.Validate() != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cfg.Node.Location = "91,0"
+	if !errors.Is(cfg.Validate(), errMalformed) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestMasked(t *testing.T) {
+
+	cfg := Default()
+	cfg.Eth.NodePass = "pass"
+
+	if cfg.Masked().Eth.NodePass != maskedValue || cfg.Eth.NodePass != "pass" || cfg.Masked().API.Token != "" {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errRequired   = errors.New("required setting not set")
+	errOutOfRange = errors.New("setting out of range")
+	errMalformed  = errors.New("malformed setting")
+)
+
+// Check every setting at once (all problems are reported)
+func (c *Config) Validate() error {
+
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Ethereum
+	check(required("CHAIN_ID", c.Eth.ChainID != 0))
+	check(required("ETH_NODE_DIR", c.Eth.NodeDir != ""))
+	check(required("ETH_NODE_PASS", c.Eth.NodePass != ""))
+	if c.Eth.Controller != "" && !common.IsHexAddress(c.Eth.Controller) {
+		check(fmt.Errorf("%w: CONTROLLER_ADDR=%q", errMalformed, c.Eth.Controller))
+	}
+
+	// Advertised addresses (interfaces are resolved by the node) and location
+	if c.Node.AdvertiseAddrs != "" {
+		for _, a := range strings.Split(c.Node.AdvertiseAddrs, ",") {
+			if strings.TrimSpace(a) == "" {
+				check(fmt.Errorf("%w: ADVERTISE_ADDRS=%q", errMalformed, c.Node.AdvertiseAddrs))
+				break
+			}
+		}
+	}
+
+	if c.Node.Location != "" {
+		var loc types.NodeLocation
+		if loc.UnmarshalText([]byte(c.Node.Location)) != nil {
+			check(fmt.Errorf("%w: NODE_LOCATION=%q", errMalformed, c.Node.Location))
+		}
+	}
+
+	// MonitorV1
+	check(inRange("MONITOR_INTERVAL", c.Monitor.Interval, 1, 3600*1000))
+	check(inRange("CYCLE_TIME", c.Monitor.CycleTime, 1, 3600*1000))
+	check(inRange("RULES_LOG_MAX_SIZE", uint64(c.Monitor.RulesLogMaxSize), 1, 1024))
+	check(inRange("RULES_LOG_BACKUPS", uint64(c.Monitor.RulesLogBackups), 0, 100))
+
+	// MonitorV2
+	check(inRange("PKT_LOSS_PROB", c.Network.PktLossProb, 0, 100))
+	check(inRange("PKT_MAX_LATENCY", c.Network.PktMaxLatency, 1, 60*1000))
+	check(inRange("EPOCH_TIME", c.Network.EpochTime, 1, 24*3600))
+	check(inRange("LOSS_PROB_THRESHOLD", c.Network.LossProbThreshold, 0, 100))
+	check(inRange("LATENCY_THRESHOLD", c.Network.LatencyThreshold, 1, 60*1000))
+	switch strings.ToLower(c.Network.ScoreAggregation) {
+	case "topk", "median", "trimmed":
+	default:
+		check(fmt.Errorf("%w: SCORE_AGGREGATION=%q", errMalformed, c.Network.ScoreAggregation))
+	}
+	check(inRange("SCORE_TRIM", c.Network.ScoreTrim, 0, 49))
+
+	// DEL deadlines
+	check(inRange("EVENT_REPLY_TIMEOUT", c.Events.ReplyTimeout, 1, 24*3600))
+	check(inRange("EVENT_VOTE_TIMEOUT", c.Events.VoteTimeout, 1, 24*3600))
+	check(inRange("EVENT_SOLVE_TIMEOUT", c.Events.SolveTimeout, 1, 24*3600))
+	check(inRange("EVENT_MAX_RETRIES", c.Events.MaxRetries, 0, 10))
+
+	// Blob store
+	check(required("STORAGE_DIR", c.Storage.Dir != ""))
+	check(address("STORAGE_ADDR", c.Storage.Addr, false))
+	check(inRange("STORAGE_FETCH_TIMEOUT", c.Storage.FetchTimeout, 1, 3600))
+	check(inRange("STORAGE_MIN_REPLICAS", c.Storage.MinReplicas, 1, 100))
+	check(inRange("STORAGE_CHECK_TIME", c.Storage.CheckTime, 1, 24*3600))
+
+	// ONOS (only if enabled)
+	if c.ONOS.Enabled {
+		if net.ParseIP(c.ONOS.ControllerIP) == nil {
+			check(fmt.Errorf("%w: ONOS_CONTROLLER_IP=%q", errMalformed, c.ONOS.ControllerIP))
+		}
+		check(inRange("ONOS_CONTROLLER_PORT", uint64(c.ONOS.ControllerPort), 1, 65535))
+		check(required("ONOS_API_USER", c.ONOS.APIUser != ""))
+		check(required("ONOS_API_PASS", c.ONOS.APIPass != ""))
+		check(inRange("ONOS_REQUEST_TIMEOUT", c.ONOS.RequestTimeout, 1, 3600))
+		check(inRange("ONOS_MAX_RETRIES", uint64(c.ONOS.MaxRetries), 0, 10))
+		check(inRange("ONOS_RETRY_BACKOFF", c.ONOS.RetryBackoff, 1, 60000))
+		check(inRange("ONOS_QUEUE_SIZE", c.ONOS.QueueSize, 1, 65536))
+	}
+
+	// Management API and metrics
+	check(address("API_ADDR", c.API.Addr, true))
+	if c.API.DashboardAddr != "" {
+		check(address("DASHBOARD_ADDR", c.API.DashboardAddr, false))
+	}
+	check(required("API_TOKEN_FILE", c.API.TokenFile != "" || c.API.Token != ""))
+	check(address("METRICS_ADDR", c.Metrics.Addr, false))
+
+	// Experiments
+	if c.Experiment.File != "" {
+		switch strings.ToLower(filepath.Ext(c.Experiment.File)) {
+		case ".csv", ".json":
+		default:
+			check(fmt.Errorf("%w: EXPERIMENT_FILE=%q (.csv or .json)", errMalformed, c.Experiment.File))
+		}
+	}
+	check(inRange("EXPERIMENT_SAMPLE_INTERVAL", c.Experiment.SampleInterval, 10, 60*1000))
+
+	// Logging
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(fmt.Errorf("%w: LOG_LEVEL=%q", errMalformed, c.Log.Level))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "logfmt", "json":
+	default:
+		check(fmt.Errorf("%w: LOG_FORMAT=%q", errMalformed, c.Log.Format))
+	}
+	check(inRange("LOG_MAX_SIZE", uint64(c.Log.MaxSize), 1, 1024))
+	check(inRange("LOG_BACKUPS", uint64(c.Log.Backups), 0, 100))
+
+	return errors.Join(errs...)
+}
+
+// Helpers //
+func required(key string, set bool) error {
+
+	if !set {
+		return fmt.Errorf("%w: %s", errRequired, key)
+	}
+
+	return nil
+}
+
+func inRange(key string, v, min, max uint64) error {
+
+	if v < min || v > max {
+		return fmt.Errorf("%w: %s=%d (%d-%d)", errOutOfRange, key, v, min, max)
+	}
+
+	return nil
+}
+
+// HOST:PORT addresses (or unix:PATH if allowed)
+func address(key, addr string, unix bool) error {
+
+	if unix && strings.HasPrefix(addr, "unix:") && len(addr) > len("unix:") {
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("%w: %s=%q", errMalformed, key, addr)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package daemons
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replicas of the artifacts of the active containers (last count)
+type artifactReplicas struct {
+	mutex sync.Mutex
+	arts  map[string]types.APIArtifact // By CID
+}
+
+func newArtifactReplicas() *artifactReplicas {
+
+	return &artifactReplicas{arts: make(map[string]types.APIArtifact)}
+}
+
+// Management API
+func (ar *artifactReplicas) artifacts() []types.APIArtifact {
+
+	ar.mutex.Lock()
+	defer ar.mutex.Unlock()
+
+	arts := []types.APIArtifact{}
+	for _, art := range ar.arts {
+		arts = append(arts, art)
+	}
+	sort.Slice(arts, func(i, j int) bool { return arts[i].CID < arts[j].CID })
+
+	return arts
+}
+
+func monitorArtifacts(ctx context.Context, node *managers.Node, ar *artifactReplicas, minReplicas, ctime uint64) {
+
+	ticker := time.NewTicker(time.Duration(ctime) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		countArtifactReplicas(ctx, node, ar, minReplicas)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func countArtifactReplicas(ctx context.Context, node *managers.Node, ar *artifactReplicas, minReplicas uint64) {
+
+	arts := make(map[string]types.APIArtifact)
+	for rcid, ctr := range node.GetActiveContainers() {
+		// Decode container info
+		var cinfo types.ContainerInfo
+		utils.UnmarshalJSON(ctr.Info, &cinfo)
+
+		for _, a := range cinfo.Artifacts {
+			art, found := arts[a.CID]
+			if !found {
+				art = types.APIArtifact{CID: a.CID, Replicas: node.CountBlobReplicas(ctx, a.CID), CheckedAt: time.Now().UnixMilli()}
+				if uint64(art.Replicas) < minReplicas {
+					utils.Warn("Under-replicated artifact", "cid", a.CID, "replicas", art.Replicas, "min", minReplicas)
+				}
+			}
+			art.Containers = append(art.Containers, rcid)
+			arts[a.CID] = art
+		}
+	}
+
+	replicas := make(map[string]int, len(arts))
+	for cid, art := range arts {
+		sort.Slice(art.Containers, func(i, j int) bool { return art.Containers[i] < art.Containers[j] })
+		replicas[cid] = art.Replicas
+	}
+	metrics.ArtifactReplicas(replicas)
+
+	ar.mutex.Lock()
+	ar.arts = arts
+	ar.mutex.Unlock()
+}
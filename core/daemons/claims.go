@@ -0,0 +1,284 @@
+package daemons
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/metrics"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	topKAggregation    = "topk"
+	medianAggregation  = "median"
+	trimmedAggregation = "trimmed"
+)
+
+const (
+	minJudgedClaims  = 3    // Claims with evidence about a node needed to judge them
+	okRatioTolerance = 0.25 // Maximum OK ratio deviation from the median claim
+	scoreTolerance   = 0.25 // Maximum score deviation from the median claim
+	latencyTolerance = 50   // Latency deviation (ms) always tolerated (or the median latency if greater)
+	unverifiedWeight = 0.5  // Weight of the claims without evidence
+	unobservedTrust  = 0.5  // Trust in the repliers nobody reports on (neutral score)
+)
+
+// Reputation claim of a replier about a node
+type replyClaim struct {
+	replier common.Address
+	claim   *types.ReputationClaim
+}
+
+// Reply scores aggregation per node (the same on every node)
+type scoreAggregation struct {
+	method string
+	trim   float64 // Share of the weight trimmed at each end (0-0.5)
+}
+
+func newScoreAggregation(method string, trim uint64) *scoreAggregation {
+
+	return &scoreAggregation{method: strings.ToLower(method), trim: float64(trim) / 100}
+}
+
+type weightedScore struct {
+	score  float64
+	weight float64
+}
+
+// Aggregated reputation of each node (claims weighted by consistency, evidence and replier trust)
+func reputationTotals(eid uint64, claims map[common.Address][]replyClaim, maxrss uint64, agg *scoreAggregation) map[common.Address]float64 {
+
+	weights := replierWeights(eid, claims)
+
+	// Replier trust: median of the claims about it (robust to a colluding minority)
+	trust := make(map[common.Address]float64)
+	for replier := range weights {
+		trust[replier] = unobservedTrust
+		if ncs := claims[replier]; len(ncs) > 0 {
+			trust[replier] = weightedMedian(weightedScores(ncs, weights, nil))
+		}
+	}
+
+	totals := make(map[common.Address]float64)
+	for naddr, ncs := range claims {
+		totals[naddr] = agg.aggregate(weightedScores(ncs, weights, trust), maxrss)
+	}
+
+	return totals
+}
+
+// Nil trust: claims weighted by consistency only
+func weightedScores(ncs []replyClaim, weights, trust map[common.Address]float64) []weightedScore {
+
+	ws := make([]weightedScore, 0, len(ncs))
+	for _, rc := range ncs {
+		w := claimWeight(rc, weights)
+		if trust != nil {
+			w *= trust[rc.replier]
+		}
+		ws = append(ws, weightedScore{score: rc.claim.Score, weight: w})
+	}
+
+	return ws
+}
+
+// Weight of each replier: share of its judged claims consistent with the claims of the rest
+func replierWeights(eid uint64, claims map[common.Address][]replyClaim) map[common.Address]float64 {
+
+	judged := make(map[common.Address]int)
+	inconsistent := make(map[common.Address]int)
+	for naddr, ncs := range claims {
+		var ratios, latencies, scores []float64
+		for _, rc := range ncs {
+			if rc.claim.Epochs > 0 {
+				ratios = append(ratios, okRatio(rc.claim))
+				scores = append(scores, rc.claim.Score)
+				if rc.claim.OKPackets > 0 {
+					latencies = append(latencies, float64(rc.claim.Latency))
+				}
+			}
+		}
+		if len(ratios) < minJudgedClaims {
+			continue
+		}
+
+		// Medians are robust to a minority of dishonest repliers (scores are checked too, evidence may be honest)
+		okMed, latMed, scoreMed := median(ratios), median(latencies), median(scores)
+		for _, rc := range ncs {
+			if rc.claim.Epochs == 0 {
+				continue
+			}
+
+			judged[rc.replier]++
+			if !consistentClaim(rc.claim, okMed, latMed, scoreMed, len(latencies) > 0) {
+				inconsistent[rc.replier]++
+				utils.Debug("Inconsistent claim", "eid", eid, "replier", rc.replier.String(), "node", naddr.String(), "score", rc.claim.Score, "ok_ratio", okRatio(rc.claim), "latency", rc.claim.Latency)
+			}
+		}
+	}
+
+	weights := make(map[common.Address]float64)
+	for _, ncs := range claims {
+		for _, rc := range ncs {
+			weights[rc.replier] = 1
+		}
+	}
+	for replier, count := range judged {
+		if inconsistent[replier] == 0 {
+			continue
+		}
+
+		weights[replier] = float64(count-inconsistent[replier]) / float64(count)
+		metrics.InconsistentClaims(replier, inconsistent[replier])
+		utils.Warn("Inconsistent replier", "eid", eid, "replier", replier.String(), "claims", count, "inconsistent", inconsistent[replier], "weight", weights[replier])
+	}
+
+	return weights
+}
+
+// Weight of a claim given its replier consistency (and its evidence)
+func claimWeight(rc replyClaim, weights map[common.Address]float64) float64 {
+
+	w := weights[rc.replier]
+	if rc.claim.Epochs == 0 {
+		w *= unverifiedWeight
+	}
+
+	return w
+}
+
+func consistentClaim(c *types.ReputationClaim, okMed, latMed, scoreMed float64, hasLatency bool) bool {
+
+	if math.Abs(c.Score-scoreMed) > scoreTolerance {
+		return false
+	}
+	if math.Abs(okRatio(c)-okMed) > okRatioTolerance {
+		return false
+	}
+	if hasLatency && c.OKPackets > 0 && math.Abs(float64(c.Latency)-latMed) > math.Max(latencyTolerance, latMed) {
+		return false
+	}
+
+	return true
+}
+
+func okRatio(c *types.ReputationClaim) float64 {
+
+	return float64(c.OKPackets) / float64(c.TotalPackets)
+}
+
+func median(values []float64) float64 {
+
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
+
+// Aggregations //
+func (sa *scoreAggregation) aggregate(ws []weightedScore, maxrss uint64) float64 {
+
+	switch sa.method {
+	case medianAggregation:
+		return weightedMedian(ws)
+	case trimmedAggregation:
+		return trimmedMean(ws, sa.trim)
+	}
+
+	return topKSum(ws, maxrss)
+}
+
+// Sum of the highest weighted scores (MaxRepScores per node)
+func topKSum(ws []weightedScore, k uint64) (total float64) {
+
+	values := make([]float64, 0, len(ws))
+	for _, v := range ws {
+		values = append(values, v.score*v.weight)
+	}
+	slices.Sort(values)
+	slices.Reverse(values)
+
+	for i, v := range values {
+		// Limiting the number of reputation scores to be counted
+		if uint64(i) == k {
+			break
+		}
+		total += v
+	}
+
+	return
+}
+
+// Score at the middle of the weight
+func weightedMedian(ws []weightedScore) float64 {
+
+	sorted, total := sortScores(ws)
+	if total == 0 {
+		return 0
+	}
+
+	var acc float64
+	for _, v := range sorted {
+		acc += v.weight
+		if acc >= total/2 {
+			return v.score
+		}
+	}
+
+	return sorted[len(sorted)-1].score
+}
+
+// Weighted mean without the lowest and highest shares of the weight
+func trimmedMean(ws []weightedScore, trim float64) float64 {
+
+	sorted, total := sortScores(ws)
+	if total == 0 {
+		return 0
+	}
+
+	lo, hi := total*trim, total*(1-trim)
+	var acc, sum, mass float64
+	for _, v := range sorted {
+		// Weight of the score inside [lo, hi]
+		if m := math.Min(acc+v.weight, hi) - math.Max(acc, lo); m > 0 {
+			sum += m * v.score
+			mass += m
+		}
+		acc += v.weight
+	}
+	if mass == 0 {
+		return weightedMedian(ws)
+	}
+
+	return sum / mass
+}
+
+// Ascending scores (deterministic order) and their total weight
+func sortScores(ws []weightedScore) ([]weightedScore, float64) {
+
+	sorted := slices.Clone(ws)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].score != sorted[j].score {
+			return sorted[i].score < sorted[j].score
+		}
+		return sorted[i].weight < sorted[j].weight
+	})
+
+	var total float64
+	for _, v := range sorted {
+		total += v.weight
+	}
+
+	return sorted, total
+}
@@ -0,0 +1,163 @@
+package daemons
+
+import (
+	"context"
+	"errors"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"slices"
+	"sort"
+	"time"
+)
+
+const deadlinesInterval = time.Second
+
+var (
+	errMaxRetries = errors.New("stalled event not re-issued (max retries reached)")
+)
+
+// Last reached DEL phase of an event and its deadline (in ms)
+func eventPhase(et *types.EventTimes, c *config.EventsConfig) (phase string, since, timeout int64) {
+
+	switch {
+	case et.Votes > 0:
+		return "RequiredVotes", et.Votes, int64(c.SolveTimeout) * 1000
+	case et.Replies > 0:
+		return "RequiredReplies", et.Replies, int64(c.VoteTimeout) * 1000
+	default:
+		return "NewEvent", et.Start, int64(c.ReplyTimeout) * 1000
+	}
+}
+
+// Events seen by this node and neither solved nor stalled
+func (el *eventLatencies) pending() map[uint64]types.EventTimes {
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	p := make(map[uint64]types.EventTimes)
+	for eid, et := range el.times {
+		if el.stalled[eid].Phase == "" && et.Start > 0 && et.End == 0 {
+			p[eid] = et
+		}
+	}
+
+	return p
+}
+
+func (el *eventLatencies) stall(se *types.APIStalledEvent) {
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if prev, found := el.stalled[se.Eid]; found {
+		se.RetriedBy = prev.RetriedBy
+	}
+	el.stalled[se.Eid] = *se
+}
+
+// A stalled event re-issued by its sender (maybe not yet stalled for this node)
+func (el *eventLatencies) retried(eid, by uint64) {
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	se := el.stalled[eid]
+	se.Eid, se.RetriedBy = eid, by
+	el.stalled[eid] = se
+}
+
+// Stalled events not solved afterwards (management API)
+func (el *eventLatencies) stalledEvents() []types.APIStalledEvent {
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	ses := []types.APIStalledEvent{}
+	for eid, se := range el.stalled {
+		if el.times[eid].End == 0 && se.Phase != "" {
+			ses = append(ses, se)
+		}
+	}
+	sort.Slice(ses, func(i, j int) bool { return ses[i].Eid < ses[j].Eid })
+
+	return ses
+}
+
+// DEL deadlines (stalled events are re-issued by their senders)
+func monitorDeadlines(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline, c *config.EventsConfig) {
+
+	ticker := time.NewTicker(deadlinesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			checkDeadlines(ctx, node, latencies, timeline, c, now)
+		}
+	}
+}
+
+func checkDeadlines(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline, c *config.EventsConfig, now time.Time) {
+
+	for eid, et := range latencies.pending() {
+		phase, since, timeout := eventPhase(&et, c)
+		if now.UnixMilli()-since < timeout {
+			continue
+		}
+
+		// Solved but its log was not received
+		event := node.GetEvent(eid)
+		if event.SolvedAt != nil && event.SolvedAt.Sign() > 0 {
+			latencies.update(eid, func(et *types.EventTimes) { et.End = now.UnixMilli() })
+			continue
+		}
+
+		se := types.APIStalledEvent{Eid: eid, Phase: phase, Since: since, Sender: event.Sender}
+		if event.HasRequiredVotes {
+			se.Solver = event.Solver
+		}
+		latencies.stall(&se)
+		metrics.StalledEvent(phase)
+
+		// Debug
+		utils.Warn("Stalled event", "eid", eid, "phase", phase, "sender", se.Sender.String(), "solver", se.Solver.String())
+		timeline.Add(types.APITimelineEntry{At: now.UnixMilli(), Eid: eid, Phase: "Stalled", Node: se.Solver, Rcid: event.Rcid})
+
+		// Am I the event sender?
+		if event.Sender == node.GetFromAccount() {
+			go func(eid uint64, event *types.Event) {
+				err := retryEvent(ctx, node, eid, event, c.MaxRetries)
+				utils.LogWarning(err)
+			}(eid, event)
+		}
+	}
+}
+
+// Send the event again excluding its unresponsive solver
+func retryEvent(ctx context.Context, node *managers.Node, eid uint64, event *types.Event, maxRetries uint64) error {
+
+	var etype types.EventType
+	utils.UnmarshalJSON(event.EType, &etype)
+
+	if etype.Attempt >= maxRetries {
+		return errMaxRetries
+	}
+	etype.Retry = eid
+	etype.Attempt++
+
+	// Voted solver that did not solve the event
+	if event.HasRequiredVotes && !slices.Contains(etype.Excluded, event.Solver) {
+		etype.Excluded = append(etype.Excluded, event.Solver)
+	}
+
+	utils.Info("Re-issuing stalled event", "eid", eid, "attempt", etype.Attempt, "excluded", len(etype.Excluded))
+
+	return node.SendEvent(ctx, &etype, event.Rcid)
+}
@@ -0,0 +1,108 @@
+package daemons
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"testing"
+	"time"
+)
+
+func TestStalledEventRetry(t *testing.T) {
+
+	ctx := context.Background()
+	c, err := simnet.NewCluster(ctx, 3)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	defer c.Close()
+	sender, solver := c.Nodes[0], c.Nodes[1].GetFromAccount()
+
+	// The most reputed node is voted but never solves the event
+	scores := []bindings.DELReputationScore{{Node: solver, Score: "1"}, {Node: c.Nodes[2].GetFromAccount(), Score: "0.5"}}
+	etype := types.EventType{RequiredTask: types.PingNodeTask, Resource: types.NoResource}
+	if err = sender.SendEvent(ctx, &etype, 0); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if err = sender.SendReply(ctx, 1, scores); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if err = sender.VoteSolver(ctx, 1, selectSolver(sender, 1, newScoreAggregation(topKAggregation, 0))); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if !sender.GetEvent(1).HasRequiredVotes || sender.GetEvent(1).Solver != solver {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Not expired yet
+	ecfg := &config.EventsConfig{ReplyTimeout: 10, VoteTimeout: 10, SolveTimeout: 10, MaxRetries: 1}
+	latencies := newEventLatencies(nil)
+	timeline := api.NewTimeline(api.DefaultTimelineSize)
+	now := time.Now()
+	latencies.update(1, func(et *types.EventTimes) {
+		et.Start, et.Replies, et.Votes = now.UnixMilli(), now.UnixMilli(), now.UnixMilli()
+	})
+	checkDeadlines(ctx, sender, latencies, timeline, ecfg, now.Add(5*time.Second))
+	if len(latencies.stalledEvents()) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Solve deadline expired (re-issued by the sender)
+	checkDeadlines(ctx, sender, latencies, timeline, ecfg, now.Add(11*time.Second))
+	stalled := latencies.stalledEvents()
+	if len(stalled) != 1 || stalled[0].Phase != "RequiredVotes" || stalled[0].Solver != solver {
+		t.Fatal("ERROR:", t.Name(), stalled)
+	}
+	for i := 0; ; i++ {
+		if events, err := sender.GetLatestEvents(2); err == nil && len(events) == 2 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("ERROR:", t.Name())
+		}
+		time.Sleep(10 * time.Millisecond)
+		c.Commit()
+	}
+
+	var retry types.EventType
+	utils.UnmarshalJSON(sender.GetEvent(2).EType, &retry)
+	if retry.Retry != 1 || retry.Attempt != 1 || len(retry.Excluded) != 1 || retry.Excluded[0] != solver {
+		t.Fatal("ERROR:", t.Name(), retry)
+	}
+	latencies.retried(1, 2)
+	if latencies.stalledEvents()[0].RetriedBy != 2 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// The stalled solver wakes up after the retry (it never saw the retry log)
+	late := c.Nodes[1]
+	late.RunEventTask(ctx, late.GetEvent(1), 1)
+	if err = late.SolveEvent(ctx, 1); err == nil || late.GetRetriedBy(1) != 2 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if solved := sender.GetEvent(1).SolvedAt; solved != nil && solved.Sign() > 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// The excluded node is not voted again
+	if err = sender.SendReply(ctx, 2, scores); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if selectSolver(sender, 2, newScoreAggregation(topKAggregation, 0)) != c.Nodes[2].GetFromAccount() {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Max retries reached
+	if err = retryEvent(ctx, sender, 2, sender.GetEvent(2), ecfg.MaxRetries); err != errMaxRetries {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
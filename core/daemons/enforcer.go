@@ -5,17 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/config"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
+	"os/exec"
 	"slices"
-	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	//blueInfoFormat = "\033[1;34m[%d] %s (Limit: %v, Usage: %v)\033[0m\n"
-	blueInfoFormat = "[%d] %s (Limit: %v, Usage: %v)\n"
+	ruleLogFormat = "ts=%d rule=%s action=%s msg=%q limit=%v usage=%v\n"
 )
 
 var (
@@ -24,59 +26,143 @@ var (
 	errUnknownAction       = errors.New("unknown rule action")
 	errNoContainersFound   = errors.New("no containers found")
 	errReputationDraw      = errors.New("reputation draw")
+	errNotContainerHost    = errors.New("the node is not the container host")
+	errDockerNotConnected  = errors.New("docker client not connected")
+	errEventAlreadySent    = errors.New("event already sent for the container")
+
+	// Rule actions log
+	rulesLog *utils.RotatingFile
 )
 
+// Hosted container and its current usage
+type containerSample struct {
+	rcid  uint64
+	info  *types.ContainerInfo
+	state *types.State // Nil if not sampled
+}
+
 // MonitorV1 //
-func runRuleAction(ctx context.Context, rule *types.Rule, ccache map[uint64]bool, usage interface{}) {
+// rcid: container checked by a container-scoped rule (0 for node rules)
+func runRuleAction(ctx context.Context, node *managers.Node, rule *types.Rule, rcid uint64, ccache map[uint64]bool, usage interface{}) {
+
+	name := ruleTarget(rule, rcid)
 
 	switch rule.Action {
 	case types.SendEventAction:
-		rcid, err := selectContainer(ctx, ccache)
+		// Encapsulate event type
+		etype := types.EventType{
+			RequiredTask: types.MigrateContainerTask,
+			Resource:     rule.Condition.MainResource(),
+		}
+
+		var err error
+		if rcid == 0 {
+			rcid, err = selectContainer(ctx, node, ccache, &etype)
+		} else if ccache[rcid] {
+			err = errEventAlreadySent
+		}
 		if err == nil {
 			ccache[rcid] = true
 
-			// Encapsulate event type
-			etype := types.EventType{
-				RequiredTask: types.MigrateContainerTask,
-				Resource:     rule.Resource,
-			}
-
 			// Debug
 			// fmt.Print("[", time.Now().UnixMilli(), "] ", "Sending an event...\n")
 
-			go func() {
-				err = managers.SendEvent(ctx, &etype, rcid)
+			go func(rcid uint64) {
+				err := node.SendEvent(ctx, &etype, rcid)
 				if err != nil {
 					ccache[rcid] = false
-					utils.CheckError(err, utils.WarningMode)
+					utils.LogWarning(err)
 				}
-			}()
+			}(rcid)
+		} else {
+			utils.LogWarning(err)
 		}
 		fallthrough
 	case types.ProceedAction:
 		// Execute specific and local stuff
 		if rule.Action == types.ProceedAction { // Due to the fallthrough
-
+			go func() {
+				err := runProceedTask(ctx, node, rule.Proceed)
+				utils.LogWarning(err)
+			}()
 		}
 		fallthrough
 	case types.LogAction:
-		// Save log into a file, send log to a remote server...
+		// Save log into a file
+		_, err := fmt.Fprintf(rulesLog, ruleLogFormat, time.Now().UnixMilli(), name, rule.Action, rule.Msg, rule.Limit, usage)
+		utils.LogWarning(err)
 		fallthrough
 	case types.WarnAction:
-		fmt.Printf(blueInfoFormat, time.Now().UnixMilli(), rule.Msg, rule.Limit, usage)
+		utils.Warn(rule.Msg, "rule", name, "action", rule.Action.String(), "limit", rule.Limit, "usage", usage)
 	case types.IgnoreAction:
 		// Do nothing
 	default:
-		utils.CheckError(errUnknownAction, utils.WarningMode)
+		utils.LogWarning(errUnknownAction)
 		return
 	}
 }
 
+// Resolved rules are reported like fired ones (warn and log actions only)
+func logRuleResolved(rule *types.Rule, rcid uint64, usage interface{}) {
+
+	name := ruleTarget(rule, rcid)
+
+	switch rule.Action {
+	case types.SendEventAction, types.ProceedAction, types.LogAction:
+		_, err := fmt.Fprintf(rulesLog, ruleLogFormat, time.Now().UnixMilli(), name, types.RuleResolved, rule.Msg, rule.Limit, usage)
+		utils.LogWarning(err)
+		fallthrough
+	case types.WarnAction:
+		utils.Info(rule.Msg, "rule", name, "status", types.RuleResolved.String(), "limit", rule.Limit, "usage", usage)
+	}
+}
+
+func runProceedTask(ctx context.Context, node *managers.Node, pt *types.ProceedTask) error {
+
+	// Local command
+	if len(pt.Command) > 0 {
+		cctx := ctx
+		if pt.Timeout > 0 {
+			var cancel context.CancelFunc
+			cctx, cancel = context.WithTimeout(ctx, time.Duration(pt.Timeout)*time.Second)
+			defer cancel()
+		}
+
+		out, err := exec.CommandContext(cctx, pt.Command[0], pt.Command[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w: %s", pt.Command[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	// Hosted container restart
+	if pt.Rcid > 0 {
+		if !node.IsDockerConnected() {
+			return errDockerNotConnected
+		}
+		if !node.IsContainerHost(pt.Rcid, node.GetFromAccount()) {
+			return errNotContainerHost
+		}
+
+		node.RestartContainer(ctx, managers.GetContainerName(pt.Rcid))
+	}
+
+	return nil
+}
+
+func initRulesLog(c *config.MonitorConfig) {
+
+	rulesLog = &utils.RotatingFile{
+		Path:       c.RulesLogFile,
+		MaxSize:    c.RulesLogMaxSize * 1024 * 1024,
+		MaxBackups: c.RulesLogBackups,
+	}
+}
+
 // Select an event solver according to spec metrics
 /*func selectSolver(eid uint64) (addr common.Address) {
 
-	event := managers.GetEvent(eid)
-	replies := managers.GetEventReplies(eid)
+	event := node.GetEvent(eid)
+	replies := node.GetEventReplies(eid)
 
 	// Decode event type
 	var etype types.EventType
@@ -97,7 +183,7 @@ func runRuleAction(ctx context.Context, rule *types.Rule, ccache map[uint64]bool
 
 		// Get and decode replier specs
 		var specs types.NodeSpecs
-		utils.UnmarshalJSON(managers.GetNodeSpecs(v.Replier), &specs)
+		utils.UnmarshalJSON(node.GetNodeSpecs(v.Replier), &specs)
 
 		var met interface{}
 		var comp types.RuleComparator
@@ -120,7 +206,7 @@ func runRuleAction(ctx context.Context, rule *types.Rule, ccache map[uint64]bool
 			met = state.NetPacketsRecv // Received packets
 			comp = types.LessComp
 		default:
-			utils.CheckError(errUnknownSpec, utils.WarningMode)
+			utils.LogWarning(errUnknownSpec)
 			continue
 		}
 
@@ -133,31 +219,87 @@ func runRuleAction(ctx context.Context, rule *types.Rule, ccache map[uint64]bool
 		if ok, err := utils.CompareValues(met, comp, best); ok {
 			best, addr = met, v.Replier
 		} else {
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 
 	return
 }*/
 
-// Select a container according to its config and spec usage
-func selectContainer(ctx context.Context, ccache map[uint64]bool) (uint64, error) {
-
-	// Get DCR active containers
-	for rcid := range managers.GetActiveContainers() {
-		// Check if am I the host and if a previous event has already been sent for the container
-		if managers.IsContainerHost(rcid, managers.GetFromAccount()) && !ccache[rcid] {
-			/*cname := managers.GetContainerName(rcid)
-			c := managers.SearchDockerContainers(ctx, "name", cname, true)
-			if c != nil {
-				// TODO: implement container selector (next container?)
-				return rcid, nil
-			}*/
-			return rcid, nil
+// Select the hosted container responsible for the pressure (highest usage, lowest impact)
+func selectContainer(ctx context.Context, node *managers.Node, ccache map[uint64]bool, etype *types.EventType) (uint64, error) {
+
+	var best *containerSample
+	samples := sampleContainers(ctx, node)
+	for i := range samples {
+		// Check if a previous event has already been sent for the container
+		if ccache[samples[i].rcid] {
+			continue
+		}
+
+		if best == nil || isMoreResponsible(&samples[i], best, etype) {
+			best = &samples[i]
 		}
 	}
 
-	return 0, errNoContainersFound
+	if best == nil {
+		return 0, errNoContainersFound
+	}
+
+	return best.rcid, nil
+}
+
+// Not sampled containers are only compared by impact
+func isMoreResponsible(cs, than *containerSample, etype *types.EventType) bool {
+
+	if cs.state != nil && than.state != nil {
+		u1, u2 := containerUsage(cs.state, etype), containerUsage(than.state, etype)
+		if u1 != u2 {
+			return u1 > u2
+		}
+	} else if cs.state != nil || than.state != nil {
+		return cs.state != nil
+	}
+
+	return cs.info.Impact < than.info.Impact
+}
+
+func containerUsage(state *types.State, etype *types.EventType) float64 {
+
+	switch etype.Resource {
+	case types.MemResource:
+		return float64(state.MemUsage)
+	case types.DiskResource:
+		return float64(state.DiskUsage)
+	case types.PktSentResource:
+		return float64(state.NetPacketsSent)
+	case types.PktRecvResource:
+		return float64(state.NetPacketsRecv)
+	}
+
+	// CPU and combined resources
+	return state.CpuUsage
+}
+
+// Functions to select the spec metric type depending on the rule limit type
+func selectMetric(c *types.RuleCondition, state *types.State, specs *types.NodeSpecs) (usage interface{}) {
+
+	switch c.Resource {
+	case types.CpuResource:
+		usage = selectCpuMetric(c.MetricType, state)
+	case types.MemResource:
+		usage = selectMemMetric(c.MetricType, state, specs)
+	case types.DiskResource:
+		usage = selectDiskMetric(c.MetricType, state, specs)
+	case types.PktSentResource:
+		usage = selectPktSentMetric(c.MetricType, state)
+	case types.PktRecvResource:
+		usage = selectPktRecvMetric(c.MetricType, state)
+	default:
+		utils.LogWarning(errUnknownSpec)
+	}
+
+	return
 }
 
 // Functions to select the spec metric type depending on the rule limit type
@@ -171,9 +313,7 @@ func selectCpuMetric(mt types.RuleMetricType, state *types.State) (usage interfa
 	return
 }
 
-func selectMemMetric(mt types.RuleMetricType, state *types.State) (usage interface{}) {
-
-	specs := managers.GetSpecs()
+func selectMemMetric(mt types.RuleMetricType, state *types.State, specs *types.NodeSpecs) (usage interface{}) {
 
 	switch mt {
 	case types.UnitsMetric:
@@ -185,9 +325,7 @@ func selectMemMetric(mt types.RuleMetricType, state *types.State) (usage interfa
 	return
 }
 
-func selectDiskMetric(mt types.RuleMetricType, state *types.State) (usage interface{}) {
-
-	specs := managers.GetSpecs()
+func selectDiskMetric(mt types.RuleMetricType, state *types.State, specs *types.NodeSpecs) (usage interface{}) {
 
 	switch mt {
 	case types.UnitsMetric:
@@ -222,6 +360,9 @@ func selectPktRecvMetric(mt types.RuleMetricType, state *types.State) (usage int
 // MonitorV2 //
 func updateNodeReputations(nodeStore types.NodeStore, lossProbTh, latTh uint64) {
 
+	_smutex.Lock()
+	defer _smutex.Unlock()
+
 	// For each peer
 	for nodeAddr, nodeInfo := range nodeStore {
 		if nodeInfo.CurrentEpoch.TotalPackets == 0 {
@@ -241,12 +382,56 @@ func updateNodeReputations(nodeStore types.NodeStore, lossProbTh, latTh uint64)
 		}
 
 		aggregateReputationValue(nodeStore, nodeAddr, repValue)
+		recordEpochEvidence(nodeStore[nodeAddr])
 
 		// Reset current epoch
 		nodeStore[nodeAddr].CurrentEpoch = types.EpochInfo{}
 	}
 }
 
+// Replies of a ping event as availability probes of every registered peer.
+// Reply times are block timestamps (seconds), too coarse to measure latency
+func recordPingReplies(node *managers.Node, eid uint64, nodeStore types.NodeStore) {
+
+	replies := node.GetEventReplies(eid)
+	if len(replies) == 0 {
+		return
+	}
+	replied := make(map[common.Address]bool)
+	for _, r := range replies {
+		replied[r.Replier] = true
+	}
+
+	_smutex.Lock()
+	defer _smutex.Unlock()
+
+	from := node.GetFromAccount()
+	for addr, encoded := range node.GetAllNodeSpecs() {
+		if addr == from {
+			continue
+		}
+
+		// Deregistered nodes are not probed
+		var specs types.NodeSpecs
+		utils.UnmarshalJSON(encoded, &specs)
+		if specs.Status == types.DeregisteredNode {
+			continue
+		}
+		if nodeStore[addr] == nil {
+			nodeStore[addr] = &types.NodeInfo{}
+		}
+
+		// Nodes not replying before the required replies count as lost probes
+		nodeStore[addr].CurrentEpoch.TotalPackets++
+		if replied[addr] {
+			nodeStore[addr].CurrentEpoch.OKPackets++
+		}
+		metrics.Packet(addr, !replied[addr])
+	}
+
+	utils.Debug("Ping replies", "eid", eid, "replies", len(replies))
+}
+
 func checkAvailabilityFilter(currentEpoch types.EpochInfo, lossProbTh uint64) (success bool) {
 
 	if float64(currentEpoch.OKPackets)/float64(currentEpoch.TotalPackets) >= float64(100-lossProbTh)/100 {
@@ -259,17 +444,37 @@ func checkAvailabilityFilter(currentEpoch types.EpochInfo, lossProbTh uint64) (s
 func checkLatencyFilter(currentEpoch types.EpochInfo, latTh uint64) (success bool) {
 
 	count := len(currentEpoch.Latencies)
-	if count > 0 {
+	if count == 0 {
+		return currentEpoch.OKPackets > 0 // Availability probes only (ping replies carry no latency)
+	}
+
+	var total uint64
+	for _, v := range currentEpoch.Latencies {
+		total += v
+	}
+	if float64(total)/float64(count) <= float64(latTh) {
+		success = true
+	}
+
+	return
+}
+
+// Summary of the closed epoch sent as reply evidence
+func recordEpochEvidence(info *types.NodeInfo) {
+
+	es := types.EpochSummary{OKPackets: info.CurrentEpoch.OKPackets, TotalPackets: info.CurrentEpoch.TotalPackets}
+	if count := len(info.CurrentEpoch.Latencies); count > 0 {
 		var total uint64
-		for _, v := range currentEpoch.Latencies {
+		for _, v := range info.CurrentEpoch.Latencies {
 			total += v
 		}
-		if float64(total)/float64(count) <= float64(latTh) {
-			success = true
-		}
+		es.Latency = total / uint64(count)
 	}
 
-	return
+	info.Reputation.Evidence = append(info.Reputation.Evidence, es)
+	if len(info.Reputation.Evidence) > managers.MaxEvidenceEpochs {
+		info.Reputation.Evidence = info.Reputation.Evidence[len(info.Reputation.Evidence)-managers.MaxEvidenceEpochs:]
+	}
 }
 
 func aggregateReputationValue(nodeStore types.NodeStore, nodeAddr common.Address, repValue uint8) {
@@ -284,87 +489,89 @@ func aggregateReputationValue(nodeStore types.NodeStore, nodeAddr common.Address
 		total += uint64(v)
 	}
 	nodeStore[nodeAddr].Reputation.Score = float64(total) / float64(len(rvs))
+	metrics.PeerReputation(nodeAddr, nodeStore[nodeAddr].Reputation.Score)
 }
 
-func selectSolver(eid uint64) common.Address {
+func selectSolver(node *managers.Node, eid uint64, agg *scoreAggregation) common.Address {
 
-	fmt.Println("\nREPLIES:")
-
-	// Get reputation scores per node
-	replies := managers.GetEventReplies(eid)
-	scores := make(map[common.Address][]float64)
+	// Get reputation claims per node
+	replies := node.GetEventReplies(eid)
+	claims := make(map[common.Address][]replyClaim)
 	for _, reply := range replies {
-
-		fmt.Println(reply.Replier, reply.RepScores)
+		utils.Debug("Event reply", "eid", eid, "replier", reply.Replier.String(), "scores", reply.RepScores)
 
 		for _, rs := range reply.RepScores {
-			// Check reputation score
-			score, err := strconv.ParseFloat(rs.Score, 64)
-			if err != nil || score < 0 || score > 1 {
-				utils.CheckError(err, utils.WarningMode)
+			// Check reputation claim
+			claim, err := managers.DecodeReputationClaim(rs.Score)
+			if err != nil {
+				utils.LogWarning(fmt.Errorf("%w from %s", err, reply.Replier))
 				continue
 			}
 
-			// Store reputation score
-			scores[rs.Node] = append(scores[rs.Node], score)
+			// Store reputation claim
+			claims[rs.Node] = append(claims[rs.Node], replyClaim{replier: reply.Replier, claim: claim})
 		}
 	}
 
-	fmt.Println("\nSCORES PER NODE:")
+	// Aggregate reputation claims per node
+	totals := reputationTotals(eid, claims, node.GetClusterConfig().MaxRepScores, agg)
 
-	// Aggregate reputation scores per node prioritizing the best
-	maxrss := managers.GetClusterConfig().MaxRepScores
-	totals := make(map[common.Address]float64)
-	for naddr, nrss := range scores {
-		// Sort node scores in descending order
-		slices.Sort(nrss)
-		slices.Reverse(nrss)
-
-		fmt.Println(naddr, nrss)
-
-		var count uint64
-		for _, score := range nrss {
-			// Limiting the number of reputation scores to be counted
-			if count == maxrss {
-				break
-			}
-			count++
-
-			// Add reputation score to the total
-			totals[naddr] += score
-		}
+	for naddr, total := range totals {
+		utils.Debug("Total reputation score", "eid", eid, "node", naddr.String(), "total", total)
 	}
 
-	fmt.Println("\nTOTAL SCORE PER NODE:")
-	for k, v := range totals {
-		fmt.Println(k, v)
-	}
+	// Nodes excluded by the event sender (unresponsive solvers of stalled events)
+	event := node.GetEvent(eid)
+	var etype types.EventType
+	utils.UnmarshalJSON(event.EType, &etype)
 
-	// Get and decode container info
-	rcid := managers.GetEvent(eid).Rcid
-	var cinfo types.ContainerInfo
+	// Get and decode container and application infos (placement hint)
+	rcid := event.Rcid
+	var (
+		cinfo types.ContainerInfo
+		ainfo types.ApplicationInfo
+	)
 	if rcid > 0 {
-		utils.UnmarshalJSON(managers.GetContainer(rcid).Info, &cinfo)
+		ctr := node.GetContainer(rcid)
+		utils.UnmarshalJSON(ctr.Info, &cinfo)
+		utils.UnmarshalJSON(node.GetApplication(ctr.Appid).Info, &ainfo)
 	}
 
-	// Get the address of the most reputed node
-	var (
-		bestScore float64 = -1
-		bestAddr  common.Address
-	)
+	// Get the best candidate (the most reputed one without a placement hint)
+	var best *solverCandidate
 	for addr, total := range totals {
+		if slices.Contains(etype.Excluded, addr) {
+			continue
+		}
+
+		// Get and decode node specs
+		var specs types.NodeSpecs
+		utils.UnmarshalJSON(node.GetNodeSpecs(addr), &specs)
+
+		// Draining and deregistered nodes are not candidates
+		if specs.Status != types.ActiveNode {
+			continue
+		}
+
 		// FILTER_3: resources
-		if rcid > 0 && !managers.CanExecuteContainer(addr, cinfo.CpuLimit, cinfo.MemLimit) {
+		if rcid > 0 && !node.CanExecuteContainer(addr, cinfo.CpuLimit, cinfo.MemLimit) {
 			continue
 		}
 
-		if total > bestScore {
-			bestScore, bestAddr = total, addr
-		} else if total == bestScore {
+		cand := newSolverCandidate(addr, total, &specs, &ainfo)
+		if best == nil || cand.better(best, ainfo.Radius) {
+			best = cand
+		} else if !best.better(cand, ainfo.Radius) {
 			// TODO: manage draws
-			utils.CheckError(errReputationDraw, utils.WarningMode)
+			utils.LogWarning(errReputationDraw)
 		}
 	}
+	if best == nil {
+		return common.Address{}
+	}
+	if best.distance >= 0 {
+		utils.Debug("Placement", "eid", eid, "solver", best.addr.String(), "distance_km", best.distance, "radius_km", ainfo.Radius)
+	}
 
-	return bestAddr
+	return best.addr
 }
@@ -0,0 +1,239 @@
+package daemons
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestRecordPingReplies(t *testing.T) {
+
+	ctx := context.Background()
+	c, err := simnet.NewCluster(ctx, 3)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	defer c.Close()
+
+	etype := types.EventType{RequiredTask: types.PingNodeTask, Resource: types.NoResource}
+	if err = c.Nodes[0].SendEvent(ctx, &etype, 0); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if err = c.Nodes[1].SendReply(ctx, 1, nil); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+
+	// Only the replier passes the probe (the sender does not probe itself)
+	ns := types.NodeStore{}
+	recordPingReplies(c.Nodes[0], 1, ns)
+	replier, late := ns[c.Nodes[1].GetFromAccount()], ns[c.Nodes[2].GetFromAccount()]
+	if len(ns) != 2 ||
+		replier.CurrentEpoch.OKPackets != 1 || len(replier.CurrentEpoch.Latencies) != 0 ||
+		late.CurrentEpoch.TotalPackets != 1 || late.CurrentEpoch.OKPackets != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Availability probes do not fail the latency filter
+	if !checkLatencyFilter(replier.CurrentEpoch, 50) || checkLatencyFilter(late.CurrentEpoch, 50) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestReplierWeights(t *testing.T) {
+
+	node := common.HexToAddress("0x1")
+	honest := func(i int64) replyClaim {
+		return replyClaim{
+			replier: common.BigToAddress(big.NewInt(10 + i)),
+			claim:   &types.ReputationClaim{Score: 0.9, Epochs: 5, OKPackets: 450, TotalPackets: 500, Latency: 20},
+		}
+	}
+
+	// A replier whose evidence contradicts the rest
+	liar := replyClaim{
+		replier: common.HexToAddress("0x2"),
+		claim:   &types.ReputationClaim{Score: 1, Epochs: 5, OKPackets: 500, TotalPackets: 500, Latency: 400},
+	}
+	claims := map[common.Address][]replyClaim{node: {honest(0), honest(1), liar}}
+
+	weights := replierWeights(1, claims)
+	if weights[honest(0).replier] != 1 || weights[honest(1).replier] != 1 || weights[liar.replier] != 0 ||
+		claimWeight(liar, weights) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Not enough claims to judge them (claims without evidence are down-weighted)
+	bare := replyClaim{replier: common.HexToAddress("0x3"), claim: &types.ReputationClaim{Score: 1}}
+	claims = map[common.Address][]replyClaim{node: {honest(0), liar, bare}}
+	weights = replierWeights(2, claims)
+	if weights[liar.replier] != 1 || claimWeight(bare, weights) != unverifiedWeight {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestUnobservedReplierTrust(t *testing.T) {
+
+	x, y, z := common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")
+	n1, n2 := common.HexToAddress("0x11"), common.HexToAddress("0x12")
+
+	// Nobody reports on x, z reports y as a good node
+	claims := map[common.Address][]replyClaim{
+		n1: {{replier: x, claim: &types.ReputationClaim{Score: 1}}},
+		n2: {{replier: y, claim: &types.ReputationClaim{Score: 1}}},
+		y:  {{replier: z, claim: &types.ReputationClaim{Score: 0.8}}},
+	}
+
+	// An unobserved replier is not trusted more than an observed one
+	totals := reputationTotals(1, claims, 4, newScoreAggregation(topKAggregation, 0))
+	if totals[n1] != unverifiedWeight*unobservedTrust || totals[n1] >= totals[n2] {
+		t.Fatal("ERROR:", t.Name(), totals)
+	}
+}
+
+// 8 nodes, 3 of them colluding: they rate each other 1 and the rest 0 (their evidence is genuine)
+func TestCollusionResistance(t *testing.T) {
+
+	// Reply scores counted per node by a deployed controller
+	c, err := simnet.NewCluster(context.Background(), 1)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	maxrss := c.Nodes[0].GetClusterConfig().MaxRepScores
+	c.Close()
+
+	// Enough colluders to fill the counted scores of each other (honest majority)
+	addrs := make([]common.Address, 2*maxrss+4)
+	for i := range addrs {
+		addrs[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	colluder := func(i int) bool { return uint64(i) >= maxrss+3 }
+
+	// Honest view: node 0 is the best one, colluders have lossy links
+	truth := make([]float64, len(addrs))
+	for i := range truth {
+		switch {
+		case i == 0:
+			truth[i] = 0.9
+		case colluder(i):
+			truth[i] = 0.2
+		default:
+			truth[i] = 0.8
+		}
+	}
+	claims := make(map[common.Address][]replyClaim)
+	for i, replier := range addrs {
+		ns := types.NodeStore{}
+		for j, addr := range addrs {
+			if i == j {
+				continue
+			}
+
+			ok := uint64(90)
+			if colluder(j) {
+				ok = 40
+			}
+			info := &types.NodeInfo{Reputation: types.ReputationInfo{Score: truth[j]}}
+			for e := 0; e < 5; e++ {
+				info.Reputation.Evidence = append(info.Reputation.Evidence, types.EpochSummary{OKPackets: ok, TotalPackets: 100, Latency: 20})
+			}
+			if colluder(i) {
+				info.Reputation.Score = 0
+				if colluder(j) {
+					info.Reputation.Score = 1
+				}
+			}
+			ns[addr] = info
+		}
+
+		// Encoded and decoded as event replies
+		for _, rs := range managers.GetReputationScores(ns) {
+			claim, err := managers.DecodeReputationClaim(rs.Score)
+			if err != nil {
+				t.Fatal("ERROR:", t.Name(), err)
+			}
+			claims[rs.Node] = append(claims[rs.Node], replyClaim{replier: replier, claim: claim})
+		}
+	}
+	best := func(totals map[common.Address]float64) (addr common.Address) {
+		bestScore := -1.0
+		for a, total := range totals {
+			if total > bestScore {
+				bestScore, addr = total, a
+			}
+		}
+		return
+	}
+
+	// Plain top-k sums (every reply counts equally): a colluder wins
+	equal := make(map[common.Address]float64)
+	for _, replier := range addrs {
+		equal[replier] = 1
+	}
+	plain := make(map[common.Address]float64)
+	for naddr, ncs := range claims {
+		plain[naddr] = topKSum(weightedScores(ncs, equal, nil), maxrss)
+	}
+	if !colluder(slices.Index(addrs, best(plain))) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Colluders attach honest evidence, but their scores contradict the rest
+	weights := replierWeights(1, claims)
+	for i, replier := range addrs {
+		if colluder(i) != (weights[replier] == 0) {
+			t.Fatal("ERROR:", t.Name(), i, weights[replier])
+		}
+	}
+
+	// Replies weighted by the replier reputation
+	for _, method := range []string{topKAggregation, medianAggregation, trimmedAggregation} {
+		if best(reputationTotals(1, claims, maxrss, newScoreAggregation(method, 20))) != addrs[0] {
+			t.Fatal("ERROR:", t.Name(), method)
+		}
+	}
+}
+
+func TestPlacement(t *testing.T) {
+
+	// Users in Madrid
+	ainfo := &types.ApplicationInfo{Clients: &types.NodeLocation{Latitude: 40.4168, Longitude: -3.7038}}
+	nodes := []struct {
+		total float64
+		loc   *types.NodeLocation
+	}{
+		{3, nil}, // The most reputed one (location not advertised)
+		{2, &types.NodeLocation{Latitude: 48.8566, Longitude: 2.3522}},  // Paris (~1053 km)
+		{1, &types.NodeLocation{Latitude: 41.3874, Longitude: 2.1686}},  // Barcelona (~505 km)
+		{0, &types.NodeLocation{Latitude: 40.4168, Longitude: -3.7038}}, // Madrid (unreputed)
+	}
+	solver := func(radius float64) int {
+		ainfo.Radius = radius
+		var best *solverCandidate
+		bestIdx := -1
+		for i, n := range nodes {
+			cand := newSolverCandidate(common.BigToAddress(big.NewInt(int64(i))), n.total, &types.NodeSpecs{Location: n.loc}, ainfo)
+			if best == nil || cand.better(best, radius) {
+				best, bestIdx = cand, i
+			}
+		}
+		return bestIdx
+	}
+
+	// Closest reputed node, the most reputed one within the radius or the most reputed one
+	if solver(0) != 2 || solver(600) != 2 || solver(1100) != 1 || solver(100) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Without a placement hint
+	ainfo.Clients = nil
+	if solver(0) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -0,0 +1,253 @@
+package daemons
+
+import (
+	"fmt"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric value taken by the monitor
+type sample struct {
+	at    time.Time
+	value interface{}
+}
+
+// MonitorV1 rule evaluation (metric windows and per-rule state machines)
+type ruleEvaluator struct {
+	samples map[string][]sample         // Per resource and metric type
+	states  map[string]*types.RuleState // Per rule
+}
+
+func newRuleEvaluator() *ruleEvaluator {
+
+	return &ruleEvaluator{
+		samples: map[string][]sample{},
+		states:  map[string]*types.RuleState{},
+	}
+}
+
+// Store the current value of every metric used by the rules (rcid 0 for the node)
+func (re *ruleEvaluator) observe(now time.Time, rcid uint64, rules []types.Rule, state *types.State, specs *types.NodeSpecs) {
+
+	// Largest window per metric
+	leaves := map[string]*types.RuleCondition{}
+	windows := map[string]time.Duration{}
+	for i := range rules {
+		for _, leaf := range rules[i].Condition.Leaves() {
+			key := metricKey(rcid, leaf)
+			w := time.Duration(leaf.Window) * time.Millisecond
+			if _, found := leaves[key]; !found || w > windows[key] {
+				leaves[key], windows[key] = leaf, w
+			}
+		}
+	}
+
+	for key, leaf := range leaves {
+		value := selectMetric(leaf, state, specs)
+		if value == nil {
+			utils.LogWarning(errBoundNotImplemented)
+			continue
+		}
+
+		// Drop samples out of the window (keeping the last one)
+		s := append(re.samples[key], sample{at: now, value: value})
+		var cut int
+		for cut < len(s)-1 && now.Sub(s[cut].at) > windows[key] {
+			cut++
+		}
+		re.samples[key] = s[cut:]
+	}
+}
+
+// Advance the rule state machine returning the previous and the current status
+func (re *ruleEvaluator) step(now time.Time, rcid uint64, rule *types.Rule, pending time.Duration) (prev, cur types.RuleStatus, usage interface{}) {
+
+	// Container-scoped rules have a state per container
+	skey := ruleTarget(rule, rcid)
+	st := re.states[skey]
+	if st == nil {
+		st = &types.RuleState{Status: types.RuleInactive, Since: now}
+		re.states[skey] = st
+	}
+	prev = st.Status
+
+	// Firing rules are checked against the clear thresholds (hysteresis)
+	var usages []string
+	ok, err := re.eval(now, rcid, rule.Condition, st.Status == types.RuleFiring, &usages)
+	if err != nil {
+		utils.LogWarning(fmt.Errorf("rule %q: %w", skey, err))
+		return prev, prev, st.Value
+	}
+
+	// Single resource rules report the metric value
+	if rule.Condition.IsLeaf() {
+		usage = st.Value
+		if v := re.aggregated(now, rcid, rule.Condition); v != nil {
+			usage = v
+		}
+	} else {
+		usage = strings.Join(usages, " ")
+	}
+	st.Value = usage
+
+	if rule.For > 0 {
+		pending = time.Duration(rule.For) * time.Millisecond
+	}
+
+	switch st.Status {
+	case types.RuleInactive, types.RuleResolved:
+		if ok {
+			st.Status, st.Since = types.RulePending, now
+		}
+	case types.RulePending:
+		if !ok {
+			st.Status, st.Since = types.RuleInactive, now
+		}
+	case types.RuleFiring:
+		if !ok {
+			st.Status, st.Since = types.RuleResolved, now
+		}
+	}
+
+	// Pending rules fire once the condition has held for the pending time
+	if st.Status == types.RulePending && now.Sub(st.Since) >= pending {
+		st.Status, st.Since = types.RuleFiring, now
+	}
+
+	return prev, st.Status, usage
+}
+
+func (re *ruleEvaluator) eval(now time.Time, rcid uint64, c *types.RuleCondition, firing bool, usages *[]string) (bool, error) {
+
+	// AND
+	if len(c.All) > 0 {
+		r := true
+		for i := range c.All {
+			ok, err := re.eval(now, rcid, &c.All[i], firing, usages)
+			if err != nil {
+				return false, err
+			}
+			r = r && ok
+		}
+		return r, nil
+	}
+
+	// OR
+	if len(c.Any) > 0 {
+		var r bool
+		for i := range c.Any {
+			ok, err := re.eval(now, rcid, &c.Any[i], firing, usages)
+			if err != nil {
+				return false, err
+			}
+			r = r || ok
+		}
+		return r, nil
+	}
+
+	// Resource condition
+	value := re.aggregated(now, rcid, c)
+	if value == nil {
+		return false, nil
+	}
+	*usages = append(*usages, fmt.Sprintf("%s=%v", c.Resource, value))
+
+	limit := c.Limit
+	if firing {
+		limit = c.Clear
+	}
+
+	return compareMetric(value, c.Comparator, limit)
+}
+
+// Aggregate the window samples of a resource condition (nil if there are no samples)
+func (re *ruleEvaluator) aggregated(now time.Time, rcid uint64, c *types.RuleCondition) interface{} {
+
+	s := re.samples[metricKey(rcid, c)]
+	if len(s) == 0 {
+		return nil
+	}
+	if c.Aggregation == types.LastAggregation || c.Window == 0 {
+		return s[len(s)-1].value
+	}
+
+	window := time.Duration(c.Window) * time.Millisecond
+	var values []float64
+	for i := range s {
+		if now.Sub(s[i].at) <= window {
+			if v, ok := types.ToFloat64(s[i].value); ok {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return aggregate(c, values)
+}
+
+// Helpers //
+func metricKey(rcid uint64, c *types.RuleCondition) string {
+
+	return strconv.FormatUint(rcid, 10) + "/" + c.Resource.String() + "/" + c.MetricType.String()
+}
+
+// Rule name and its target container (if any)
+func ruleTarget(rule *types.Rule, rcid uint64) string {
+
+	if rcid > 0 {
+		return rule.NameID + "@" + strconv.FormatUint(rcid, 10)
+	}
+
+	return rule.NameID
+}
+
+func aggregate(c *types.RuleCondition, values []float64) interface{} {
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch c.Aggregation {
+	case types.AvgAggregation:
+		var total float64
+		for _, v := range values {
+			total += v
+		}
+		return total / float64(len(values))
+	case types.MinAggregation:
+		r := values[0]
+		for _, v := range values[1:] {
+			r = math.Min(r, v)
+		}
+		return r
+	case types.MaxAggregation:
+		r := values[0]
+		for _, v := range values[1:] {
+			r = math.Max(r, v)
+		}
+		return r
+	case types.P95Aggregation:
+		// Nearest-rank percentile
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		return sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+	}
+
+	return values[len(values)-1]
+}
+
+// Numeric values are compared as float64 (aggregations change uint64 metrics into float64)
+func compareMetric(value interface{}, comp types.RuleComparator, limit interface{}) (bool, error) {
+
+	v, vok := types.ToFloat64(value)
+	l, lok := types.ToFloat64(limit)
+	if vok && lok {
+		return utils.CompareValues(v, comp, l)
+	}
+
+	return utils.CompareValues(value, comp, limit)
+}
@@ -0,0 +1,219 @@
+package daemons
+
+import (
+	"github.com/swarleynunez/hidra/core/types"
+	"testing"
+	"time"
+)
+
+func newTestRule(t *testing.T, rule types.Rule) *types.Rule {
+
+	if err := rule.Validate(); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	return &rule
+}
+
+func TestRuleHysteresis(t *testing.T) {
+
+	rule := newTestRule(t, types.Rule{
+		NameID: "r1",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.CpuResource,
+			MetricType: types.PercentMetric,
+			Comparator: types.GreaterComp,
+			Limit:      float64(80),
+			Clear:      float64(60),
+		},
+		Action: types.WarnAction,
+	})
+	specs := &types.NodeSpecs{}
+	re := newRuleEvaluator()
+	now := time.Now()
+	pending := 2 * time.Second
+
+	// CPU usage per second and expected rule status
+	steps := []struct {
+		cpu    float64
+		status types.RuleStatus
+	}{
+		{50, types.RuleInactive},
+		{90, types.RulePending},
+		{70, types.RuleInactive}, // Not held during the pending time
+		{90, types.RulePending},
+		{85, types.RulePending},
+		{95, types.RuleFiring},
+		{70, types.RuleFiring}, // Above the clear threshold
+		{55, types.RuleResolved},
+		{70, types.RuleResolved},
+		{85, types.RulePending},
+	}
+
+	for i, s := range steps {
+		now = now.Add(time.Second)
+		re.observe(now, 0, []types.Rule{*rule}, &types.State{CpuUsage: s.cpu}, specs)
+		if _, cur, _ := re.step(now, 0, rule, pending); cur != s.status {
+			t.Fatal("ERROR:", t.Name(), i, cur)
+		}
+	}
+}
+
+func TestRuleWindows(t *testing.T) {
+
+	specs := &types.NodeSpecs{MemTotal: 1000}
+	cond := types.RuleCondition{
+		Resource:   types.MemResource,
+		MetricType: types.UnitsMetric,
+		Comparator: types.GreaterComp,
+		Limit:      uint64(500),
+		Window:     5000,
+	}
+
+	cases := map[string]struct {
+		aggr     string
+		expected float64
+	}{
+		"avg": {"avg", 600},
+		"min": {"min", 200},
+		"max": {"max", 1000},
+		"p95": {"p95", 1000},
+	}
+
+	for name, c := range cases {
+		cond := cond
+		if err := cond.Aggregation.UnmarshalText([]byte(c.aggr)); err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+		rule := newTestRule(t, types.Rule{NameID: name, RuleCondition: cond, Action: types.WarnAction})
+
+		// The first two samples are out of the window
+		re := newRuleEvaluator()
+		now := time.Now()
+		for _, mem := range []uint64{5000, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000} {
+			now = now.Add(600 * time.Millisecond)
+			re.observe(now, 0, []types.Rule{*rule}, &types.State{MemUsage: mem}, specs)
+		}
+
+		if _, _, usage := re.step(now, 0, rule, 0); usage != c.expected {
+			t.Fatal("ERROR:", t.Name(), name, usage)
+		}
+	}
+}
+
+func TestRuleComposite(t *testing.T) {
+
+	leaf := func(res string, limit interface{}) types.RuleCondition {
+
+		var c types.RuleCondition
+		_ = c.Resource.UnmarshalText([]byte(res))
+		c.MetricType = types.UnitsMetric
+		c.Comparator = types.GreaterComp
+		c.Limit = limit
+		return c
+	}
+
+	// sent > 10 AND (recv > 10 OR mem > 10)
+	rule := newTestRule(t, types.Rule{
+		NameID: "r1",
+		Condition: &types.RuleCondition{All: []types.RuleCondition{
+			leaf("pkt_sent", uint64(10)),
+			{Any: []types.RuleCondition{leaf("pkt_recv", uint64(10)), leaf("mem", uint64(10))}},
+		}},
+		Action: types.WarnAction,
+	})
+	if rule.Condition.MainResource() != types.AllResources {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	cases := []struct {
+		state    types.State
+		expected bool
+	}{
+		{types.State{NetPacketsSent: 20, NetPacketsRecv: 20}, true},
+		{types.State{NetPacketsSent: 20, MemUsage: 20}, true},
+		{types.State{NetPacketsSent: 20}, false},
+		{types.State{NetPacketsRecv: 20, MemUsage: 20}, false},
+	}
+
+	for i, c := range cases {
+		re := newRuleEvaluator()
+		now := time.Now()
+		re.observe(now, 0, []types.Rule{*rule}, &c.state, &types.NodeSpecs{})
+		if _, cur, _ := re.step(now, 0, rule, 0); (cur == types.RuleFiring) != c.expected {
+			t.Fatal("ERROR:", t.Name(), i, cur)
+		}
+	}
+}
+
+func TestContainerRules(t *testing.T) {
+
+	service := types.WebServerServ
+	rule := newTestRule(t, types.Rule{
+		NameID: "r1",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.CpuResource,
+			MetricType: types.PercentMetric,
+			Comparator: types.GreaterComp,
+			Limit:      float64(80),
+		},
+		Action: types.WarnAction,
+		Scope:  &types.RuleScope{Service: &service},
+	})
+	specs := &types.NodeSpecs{Cores: 4, MemTotal: 1000}
+
+	// 170% of a 2 cores limit (85%) and of all node cores (42.5%)
+	ctype := types.ContainerType{ServiceType: types.WebServerServ}
+	samples := []containerSample{
+		{rcid: 1, info: &types.ContainerInfo{ContainerType: ctype, ContainerConfig: types.ContainerConfig{CpuLimit: 2 * 1e9, MemLimit: 100}}, state: &types.State{CpuUsage: 170}},
+		{rcid: 2, info: &types.ContainerInfo{ContainerType: ctype}, state: &types.State{CpuUsage: 170}},
+	}
+
+	// Every container has its own rule state
+	re := newRuleEvaluator()
+	now := time.Now()
+	for _, cs := range samples {
+		if !rule.Matches(cs.rcid, &cs.info.ContainerType) {
+			t.Fatal("ERROR:", t.Name(), cs.rcid)
+		}
+
+		state, cspecs := containerView(&cs, specs)
+		re.observe(now, cs.rcid, []types.Rule{*rule}, state, cspecs)
+		if _, cur, usage := re.step(now, cs.rcid, rule, 0); (cur == types.RuleFiring) != (cs.rcid == 1) {
+			t.Fatal("ERROR:", t.Name(), cs.rcid, usage)
+		}
+	}
+
+	// Memory percent is relative to the container limit
+	if _, cspecs := containerView(&samples[0], specs); cspecs.MemTotal != 100 {
+		t.Fatal("ERROR:", t.Name())
+	}
+	if rule.Matches(3, &types.ContainerType{ServiceType: types.DatabaseServ}) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestSelectResponsibleContainer(t *testing.T) {
+
+	etype := &types.EventType{Resource: types.MemResource}
+	info := func(impact uint8) *types.ContainerInfo {
+		return &types.ContainerInfo{ContainerType: types.ContainerType{Impact: impact}}
+	}
+
+	cases := []struct {
+		cs, than containerSample
+		expected bool
+	}{
+		{containerSample{info: info(5), state: &types.State{MemUsage: 20}}, containerSample{info: info(1), state: &types.State{MemUsage: 10}}, true},
+		{containerSample{info: info(1), state: &types.State{MemUsage: 10}}, containerSample{info: info(5), state: &types.State{MemUsage: 20}}, false},
+		{containerSample{info: info(1), state: &types.State{MemUsage: 10}}, containerSample{info: info(5), state: &types.State{MemUsage: 10}}, true},
+		{containerSample{info: info(5), state: &types.State{}}, containerSample{info: info(1)}, true},
+		{containerSample{info: info(1)}, containerSample{info: info(5)}, true},
+	}
+
+	for i, c := range cases {
+		if isMoreResponsible(&c.cs, &c.than, etype) != c.expected {
+			t.Fatal("ERROR:", t.Name(), i)
+		}
+	}
+}
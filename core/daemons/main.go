@@ -2,119 +2,194 @@ package daemons
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/experiments"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
+	"github.com/swarleynunez/hidra/core/storage"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
-	"strconv"
+	"github.com/swarleynunez/hidra/inputs"
+	"sync"
 	"time"
 )
 
-func Run(ctx context.Context, iface string) {
+// NodeStore access (packet monitor, epochs, replies and management API)
+var _smutex sync.RWMutex
 
-	// MonitorV1 //
-	/*// Rule cycle counter (rcc) per rule
-	rccs := map[string]types.CycleCounter{}
+var (
+	errAggregationMismatch = errors.New("score aggregation differs from the cluster (SCORE_AGGREGATION and SCORE_TRIM)")
+	errDockerArtifacts     = errors.New("hosted container with artifacts without Docker (DOCKER_ENABLED)")
+)
 
-	// Cache to avoid duplicated events per container
-	ctrCache := map[uint64]bool{}
+// The configuration must be already validated
+func Run(ctx context.Context, node *managers.Node, cfg *config.Config, iface string) error {
+
+	// MonitorV1 //
+	minter, ctime := cfg.Monitor.Interval, cfg.Monitor.CycleTime
 
-	minter, err := strconv.ParseUint(utils.GetEnv("MONITOR_INTERVAL"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+	// Rules file (compiled-in rules if not set)
+	rules := newRuleSet(cfg.Monitor.RulesFile, inputs.Rules[:], node.IsDockerConnected())
+	_, err := rules.Reload()
+	if err != nil {
+		return err
+	}
 
-	ctime, err := strconv.ParseUint(utils.GetEnv("CYCLE_TIME"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)*/
+	// Rule actions log
+	initRulesLog(&cfg.Monitor)
 
 	// MonitorV2 //
-	mmp, err := strconv.ParseUint(utils.GetEnv("MAX_MONITORED_PKTS"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
-
-	lossProb, err := strconv.ParseUint(utils.GetEnv("PKT_LOSS_PROB"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+	mmp := cfg.Network.MaxMonitoredPkts
+	lossProb, maxLatency := cfg.Network.PktLossProb, cfg.Network.PktMaxLatency
+	epTime := cfg.Network.EpochTime
+	lossProbTh, latTh := cfg.Network.LossProbThreshold, cfg.Network.LatencyThreshold
+	agg := newScoreAggregation(cfg.Network.ScoreAggregation, cfg.Network.ScoreTrim)
 
-	maxLatency, err := strconv.ParseUint(utils.GetEnv("PKT_MAX_LATENCY"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+	// Data structures
+	nodeStore := types.NodeStore{}
 
-	epTime, err := strconv.ParseUint(utils.GetEnv("EPOCH_TIME"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+	// Experiments (event records and process usage)
+	var rec *experiments.Recorder
+	if cfg.Experiment.File != "" {
+		rec, err = experiments.NewRecorder(cfg.Experiment.File)
+		if err != nil {
+			return err
+		}
+		defer rec.Close()
 
-	lossProbTh, err := strconv.ParseUint(utils.GetEnv("LOSS_PROB_THRESHOLD"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+		go func() {
+			err := rec.Sample(ctx, time.Duration(cfg.Experiment.SampleInterval)*time.Millisecond)
+			utils.LogWarning(err)
+		}()
+		utils.Info("Experiment records", "file", cfg.Experiment.File)
+	}
+	latencies := newEventLatencies(rec)
+	timeline := api.NewTimeline(api.DefaultTimelineSize)
+	pktCounter := types.PacketCounter{Max: mmp}
 
-	latTh, err := strconv.ParseUint(utils.GetEnv("LATENCY_THRESHOLD"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+	// Hosted artifacts are materialised into Docker volumes
+	if !node.IsDockerConnected() {
+		for rcid, cinfo := range node.GetHostedContainers() {
+			if len(cinfo.Artifacts) > 0 {
+				return fmt.Errorf("%w: %d", errDockerArtifacts, rcid)
+			}
+		}
+	}
 
-	// Data structures
-	nodeStore := types.NodeStore{}
+	// Registry cache (kept current by the watchers)
+	node.EnableRegistry()
 
-	// Experiments
-	latencies := make(map[uint64]types.EventTimes)
-	pktCounter := types.PacketCounter{Max: mmp}
+	// Nodes aggregating reply scores differently would split their votes (checked before advertising ours)
+	if peers := node.AggregationMismatches(); len(peers) > 0 {
+		return fmt.Errorf("%w: %s (%d peers differ, e.g. %s)", errAggregationMismatch, cfg.Network.AggregationSpec(), len(peers), peers[0])
+	}
+	if _, err = node.RefreshSpecs(ctx); err != nil {
+		return err
+	}
 
 	// Watchers to receive blockchain events
-	go WatchNewEvent(ctx, latencies, nodeStore)
-	go WatchRequiredReplies(ctx)
-	go WatchRequiredVotes(ctx)
-	go WatchEventSolved(ctx, latencies)
-	go WatchApplicationRegistered()
-	go WatchContainerRegistered(ctx)
-	//go WatchContainerUpdated(ctx)
-	//go WatchContainerUnregistered(ctx)
+	go WatchNewEvent(ctx, node, latencies, timeline, nodeStore)
+	go WatchRequiredReplies(ctx, node, latencies, timeline, agg)
+	go WatchRequiredVotes(ctx, node, latencies, timeline)
+	go WatchEventSolved(ctx, node, latencies, timeline, nodeStore)
+	go monitorDeadlines(ctx, node, latencies, timeline, &cfg.Events)
+	go WatchApplicationRegistered(node)
+	go WatchContainerRegistered(ctx, node)
+	go watchRegistry(ctx, node)
+	//go WatchContainerUpdated(ctx, node)
+	//go WatchContainerUnregistered(ctx, node)
 
 	// TODO: check node/Docker running ports (also check registered ports in DCR)
 	// Recover node state from DCR
-	//managers.InitNodeState(ctx)
+	//node.InitNodeState(ctx)
 
 	// Get node network info
-	nodeIP, nodePort := managers.GetNodeIPFromAddress(managers.GetFromAccount())
-
-	// Debug
-	fmt.Print("--> Node network info: ", nodeIP+":"+nodePort, "\n")
-	fmt.Print("--> Packet simulator config:\n")
-	fmt.Print("		Packet loss probability: ", lossProb, "%\n")
-	fmt.Print("		Loss probability threshold: ", lossProbTh, "%\n")
-	fmt.Print("		Packet maximum latency: ", maxLatency, "ms\n")
-	fmt.Print("		Latency threshold: ", latTh, "ms\n\n")
+	nodeIP, nodePort := node.GetNodeIPFromAddress(node.GetFromAccount())
+
+	// Node and packet simulator config
+	utils.Info("Node network info", "address", nodeIP+":"+nodePort)
+	utils.Info("Packet simulator config",
+		"loss_prob", lossProb,
+		"loss_prob_threshold", lossProbTh,
+		"max_latency_ms", maxLatency,
+		"latency_threshold_ms", latTh,
+	)
+
+	// Prometheus metrics
+	maddr := cfg.Metrics.Addr
+	go func() {
+		err := metrics.Serve(maddr)
+		utils.LogWarning(err)
+	}()
+	utils.Info("Metrics endpoint", "url", "http://"+maddr+"/metrics")
+
+	// Blob server (CRUD tasks)
+	go func() {
+		err := storage.Serve(ctx, cfg.Storage.Addr, node.GetBlobStore())
+		utils.LogWarning(err)
+	}()
+	utils.Info("Blob server", "addr", cfg.Storage.Addr, "dir", cfg.Storage.Dir)
+	artifacts := newArtifactReplicas()
+	go monitorArtifacts(ctx, node, artifacts, cfg.Storage.MinReplicas, cfg.Storage.CheckTime)
+	go monitorSpecs(ctx, node)
+
+	// Management API and web dashboard
+	acfg := &api.Config{
+		Addr:          cfg.API.Addr,
+		DashboardAddr: cfg.API.DashboardAddr,
+		Token:         cfg.API.Token,
+		TokenFile:     cfg.API.TokenFile,
+	}
+	src := &api.Sources{
+		Node:      node,
+		Peers:     func() types.NodeStore { return copyNodeStore(nodeStore) },
+		Timeline:  timeline,
+		Stalled:   latencies.stalledEvents,
+		Artifacts: artifacts.artifacts,
+	}
+	go func() {
+		err := api.Serve(ctx, acfg, src)
+		utils.LogWarning(err)
+	}()
+	utils.Info("Management API", "addr", acfg.Addr)
+	if acfg.DashboardAddr != "" {
+		utils.Info("Web dashboard", "url", "http://"+acfg.DashboardAddr+"/dashboard/", "token_file", acfg.TokenFile)
+	}
 
 	// Main loop V1
-	/*go printEventLatencies(args)
-	for {
-		time.Sleep(time.Duration(minter) * time.Millisecond)
-
-		// Check all state rules
-		checkStateRules(ctx, rccs, minter, ctime, ctrCache)
-	}*/
+	if len(rules.Rules()) > 0 || cfg.Monitor.RulesFile != "" {
+		go monitorRules(ctx, node, rules, minter, ctime)
+	}
 
 	// Main loop V2
-	go monitorNetwork(iface, nodePort, lossProb, maxLatency, nodeStore, &pktCounter)
+	go monitorNetwork(node, iface, nodePort, lossProb, maxLatency, nodeStore, &pktCounter)
 	for {
 		time.Sleep(time.Duration(epTime) * time.Second)
 
 		// In each epoch
 		go updateNodeReputations(nodeStore, lossProbTh, latTh)
+		go func() {
+			metrics.ContainersHosted(len(node.GetHostedContainers()))
+		}()
 	}
 }
 
-/*func printEventLatencies(args []string) {
+// Snapshot of the peers for readers outside the daemons
+func copyNodeStore(nodeStore types.NodeStore) types.NodeStore {
 
-	count, err := strconv.Atoi(args[1])
-	utils.CheckError(err, utils.FatalMode)
-
-	for {
-		b, err := os.ReadFile(args[0])
-		utils.CheckError(err, utils.FatalMode)
-
-		if strings.Count(string(b), "EventSolved") == count {
-			var total float64
-			for _, v := range latencies {
-				latency := float64(v.end-v.start) / 1e9
-				fmt.Println(latency)
-				total += latency
-			}
-			fmt.Printf("LATENCY %.2f", total/float64(len(latencies)))
-			return
-		}
+	_smutex.RLock()
+	defer _smutex.RUnlock()
 
-		time.Sleep(1000 * time.Millisecond)
+	ns := make(types.NodeStore, len(nodeStore))
+	for addr, info := range nodeStore {
+		ni := *info
+		ni.CurrentEpoch.Latencies = append([]uint64(nil), info.CurrentEpoch.Latencies...)
+		ni.Reputation.Values = append([]uint8(nil), info.Reputation.Values...)
+		ns[addr] = &ni
 	}
-}*/
+
+	return ns
+}
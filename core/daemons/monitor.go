@@ -6,89 +6,172 @@ import (
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/pcap"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
-	"github.com/swarleynunez/hidra/inputs"
+	"math"
 	"math/rand"
+	"sort"
+	"time"
 )
 
 // MonitorV1 //
-func checkStateRules(ctx context.Context, rccs map[string]types.CycleCounter, minter, ctime uint64, ccache map[uint64]bool) {
-
-	state := managers.GetState()
-
-	for _, rule := range inputs.Rules {
-		// Current spec value (variable for different value types)
-		var usage interface{}
-
-		switch rule.Resource {
-		case types.CpuResource:
-			usage = selectCpuMetric(rule.MetricType, state)
-		case types.MemResource:
-			usage = selectMemMetric(rule.MetricType, state)
-		case types.DiskResource:
-			usage = selectDiskMetric(rule.MetricType, state)
-		case types.PktSentResource:
-			usage = selectPktSentMetric(rule.MetricType, state)
-		case types.PktRecvResource:
-			usage = selectPktRecvMetric(rule.MetricType, state)
-		default:
-			utils.CheckError(errUnknownSpec, utils.WarningMode)
-			continue // Drop rule check
-		}
+func monitorRules(ctx context.Context, node *managers.Node, rs *ruleSet, minter, ctime uint64) {
+
+	// Metric windows and rule states
+	re := newRuleEvaluator()
+
+	// Cache to avoid duplicated events per container
+	ccache := map[uint64]bool{}
 
-		if usage == nil {
-			utils.CheckError(errBoundNotImplemented, utils.WarningMode)
-			continue // Drop rule check
+	for {
+		time.Sleep(time.Duration(minter) * time.Millisecond)
+
+		// Hot reload of the rules file
+		changed, err := rs.Reload()
+		if err != nil {
+			utils.LogWarning(err)
+		} else if changed {
+			re = newRuleEvaluator()
+
+			// Debug
+			utils.Info("Rules loaded", "file", rs.path, "rules", len(rs.Rules()))
 		}
 
-		// Get the rcc
-		rcc := rccs[rule.NameID]
+		// Check all state rules
+		checkStateRules(ctx, node, re, rs.Rules(), ctime, ccache)
+	}
+}
+
+func checkStateRules(ctx context.Context, node *managers.Node, re *ruleEvaluator, rules []types.Rule, ctime uint64, ccache map[uint64]bool) {
+
+	now := time.Now()
+	pending := time.Duration(ctime) * time.Millisecond // Rules without a pending time must hold during a whole cycle
+
+	// Node rules
+	var nrules, crules []types.Rule
+	for i := range rules {
+		if rules[i].Scope == nil {
+			nrules = append(nrules, rules[i])
+		} else {
+			crules = append(crules, rules[i])
+		}
+	}
 
-		// Count a measure if the rcc has already started
-		if rcc.Measures > 0 {
-			rcc.Measures++
+	specs := managers.GetSpecs()
+	if len(nrules) > 0 {
+		re.observe(now, 0, nrules, managers.GetState(), specs)
+		for i := range nrules {
+			checkRule(ctx, node, re, now, 0, &nrules[i], pending, ccache)
 		}
+	}
 
-		// Rule checking
-		if ok, err := utils.CompareValues(usage, rule.Comparator, rule.Limit); ok {
+	// Container-scoped rules (checked for each hosted container)
+	if len(crules) == 0 || !node.IsDockerConnected() {
+		return
+	}
+	for _, cs := range sampleContainers(ctx, node) {
+		if cs.state == nil {
+			continue
+		}
 
-			// Start the rcc
-			if rcc.Measures == 0 {
-				rcc.Measures++
+		var matched []types.Rule
+		for i := range crules {
+			if crules[i].Matches(cs.rcid, &cs.info.ContainerType) {
+				matched = append(matched, crules[i])
 			}
+		}
 
-			// Count a trigger
-			rcc.Triggers++
+		cstate, cspecs := containerView(&cs, specs)
+		re.observe(now, cs.rcid, matched, cstate, cspecs)
+		for i := range matched {
+			checkRule(ctx, node, re, now, cs.rcid, &matched[i], pending, ccache)
+		}
+	}
+}
+
+func checkRule(ctx context.Context, node *managers.Node, re *ruleEvaluator, now time.Time, rcid uint64, rule *types.Rule, pending time.Duration, ccache map[uint64]bool) {
+
+	prev, cur, usage := re.step(now, rcid, rule, pending)
+	if prev == cur {
+		return
+	}
 
-			if rcc.Measures == ctime/minter && rcc.Measures == rcc.Triggers {
-				runRuleAction(ctx, &rule, ccache, usage)
+	switch cur {
+	case types.RuleFiring:
+		runRuleAction(ctx, node, rule, rcid, ccache, usage)
+	case types.RuleResolved:
+		logRuleResolved(rule, rcid, usage)
+	}
+}
+
+// Current usage of the hosted containers (nil states if they cannot be sampled)
+func sampleContainers(ctx context.Context, node *managers.Node) (samples []containerSample) {
+
+	for rcid, cinfo := range node.GetHostedContainers() {
+		cs := containerSample{rcid: rcid, info: cinfo}
+
+		if node.IsDockerConnected() {
+			state, err := node.GetContainerState(ctx, rcid)
+			if err != nil {
+				utils.LogWarning(err)
+			} else {
+				cs.state = state
 			}
-		} else {
-			utils.CheckError(err, utils.WarningMode)
 		}
 
-		// Reset the rcc
-		if rcc.Measures == ctime/minter {
-			//rcc = cycle{}
-		}
+		samples = append(samples, cs)
+	}
+
+	// Deterministic order
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].rcid < samples[j].rcid
+	})
+
+	return
+}
 
-		// Update the rcc for the next state checking
-		rccs[rule.NameID] = rcc
+// Container usage relative to its limits (percent metrics)
+func containerView(cs *containerSample, specs *types.NodeSpecs) (*types.State, *types.NodeSpecs) {
+
+	state, cspecs := *cs.state, *specs
+
+	// Docker reports 100% per core (the CPU limit or all node cores)
+	cores := float64(specs.Cores)
+	if cs.info.CpuLimit > 0 {
+		cores = float64(cs.info.CpuLimit) / 1e9
+	}
+	if cores > 0 {
+		state.CpuUsage = math.Min(state.CpuUsage/cores, 100.0)
 	}
+
+	if cs.info.MemLimit > 0 {
+		cspecs.MemTotal = cs.info.MemLimit
+	}
+
+	return &state, &cspecs
 }
 
 // MonitorV2 //
-func monitorNetwork(iface, nodePort string, lossProb, maxLatency uint64, nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
+func monitorNetwork(node *managers.Node, iface, nodePort string, lossProb, maxLatency uint64, nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
 
 	// Open interface
 	handle, err := pcap.OpenLive(iface, 65536, false, pcap.BlockForever)
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		utils.LogError(err, "iface", iface)
+		return
+	}
 	defer handle.Close()
 
 	// TODO. Filtering by UDP and ports (due to the emulation of fog nodes)
 	err = handle.SetBPFFilter("udp and port " + nodePort + " and !port 30301")
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		utils.LogError(err, "iface", iface)
+		return
+	}
+
+	// Packet performance simulator (only used by this goroutine)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	// Use the handle as a packet source to process all packets
 	pktSrc := gopacket.NewPacketSource(handle, handle.LinkType())
@@ -96,17 +179,17 @@ func monitorNetwork(iface, nodePort string, lossProb, maxLatency uint64, nodeSto
 		// Counting all packets
 		pktCounter.Total++
 
-		processPacket(pkt, nodePort, lossProb, maxLatency, nodeStore, pktCounter)
+		processPacket(node, rng, pkt, nodePort, lossProb, maxLatency, nodeStore, pktCounter)
 
 		// Bounding the experiment
 		/*if pktCounter.Total == pktCounter.Max {
-			managers.PrintFinalStatistics(nodeStore, pktCounter)
+			node.PrintFinalStatistics(nodeStore, pktCounter)
 			os.Exit(0)
 		}*/
 	}
 }
 
-func processPacket(pkt gopacket.Packet, nodePort string, lossProb, maxLatency uint64, nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
+func processPacket(node *managers.Node, rng *rand.Rand, pkt gopacket.Packet, nodePort string, lossProb, maxLatency uint64, nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
 
 	// Get packet network/transport info
 	srcIP := pkt.NetworkLayer().NetworkFlow().Src().String()
@@ -115,7 +198,7 @@ func processPacket(pkt gopacket.Packet, nodePort string, lossProb, maxLatency ui
 	dstPort := pkt.TransportLayer().TransportFlow().Dst().String()
 
 	// Packet performance simulation
-	lost, latency := simulatePacketPerformance(lossProb, maxLatency)
+	lost, latency := simulatePacketPerformance(rng, lossProb, maxLatency)
 
 	// Packet sender/receiver?
 	var nodeAddr common.Address
@@ -132,7 +215,7 @@ func processPacket(pkt gopacket.Packet, nodePort string, lossProb, maxLatency ui
 		}
 
 		// Get the other fog node of the edge
-		nodeAddr = managers.GetNodeAddressFromIP(dstIP, dstPort)
+		nodeAddr = node.GetNodeAddressFromIP(dstIP, dstPort)
 	} else { // Incoming packets
 		if !lost {
 			pktCounter.Recv++
@@ -146,10 +229,15 @@ func processPacket(pkt gopacket.Packet, nodePort string, lossProb, maxLatency ui
 		}
 
 		// Get the other fog node of the edge
-		nodeAddr = managers.GetNodeAddressFromIP(srcIP, srcPort)
+		nodeAddr = node.GetNodeAddressFromIP(srcIP, srcPort)
 	}
 
 	if !utils.EmptyEthAddress(nodeAddr.String()) {
+		metrics.Packet(nodeAddr, lost)
+
+		_smutex.Lock()
+		defer _smutex.Unlock()
+
 		// New fog nodes/peers
 		if nodeStore[nodeAddr] == nil {
 			nodeStore[nodeAddr] = &types.NodeInfo{}
@@ -166,12 +254,12 @@ func processPacket(pkt gopacket.Packet, nodePort string, lossProb, maxLatency ui
 	return
 }
 
-func simulatePacketPerformance(lossProb, maxLatency uint64) (lost bool, latency uint64) {
+func simulatePacketPerformance(rng *rand.Rand, lossProb, maxLatency uint64) (lost bool, latency uint64) {
 
-	if uint64(rand.Intn(100+1)) < lossProb {
+	if uint64(rng.Intn(100+1)) < lossProb {
 		lost = true
 	} else {
-		latency = uint64(rand.Intn(int(maxLatency)) + 1)
+		latency = uint64(rng.Intn(int(maxLatency)) + 1)
 	}
 
 	return
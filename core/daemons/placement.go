@@ -0,0 +1,53 @@
+package daemons
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+)
+
+// Solver candidate of an event
+type solverCandidate struct {
+	addr     common.Address
+	total    float64 // Aggregated reputation
+	distance float64 // To the application users (km, -1 if unknown)
+}
+
+func newSolverCandidate(addr common.Address, total float64, specs *types.NodeSpecs, ainfo *types.ApplicationInfo) *solverCandidate {
+
+	c := &solverCandidate{addr: addr, total: total, distance: -1}
+	if ainfo.Clients != nil && specs.Location != nil {
+		c.distance = ainfo.Clients.Distance(specs.Location)
+	}
+
+	return c
+}
+
+// Candidates within the radius first or, without radius, the closest ones.
+// Unreputed nodes are never preferred for their location and reputation breaks the rest of ties
+func (c *solverCandidate) better(o *solverCandidate, radius float64) bool {
+
+	if c.total > 0 && o.total > 0 {
+		if radius > 0 {
+			if in, oin := c.within(radius), o.within(radius); in != oin {
+				return in
+			}
+		} else if c.distance >= 0 || o.distance >= 0 {
+			if o.distance < 0 {
+				return true
+			}
+			if c.distance < 0 {
+				return false
+			}
+			if c.distance != o.distance {
+				return c.distance < o.distance
+			}
+		}
+	}
+
+	return c.total > o.total
+}
+
+func (c *solverCandidate) within(radius float64) bool {
+
+	return c.distance >= 0 && c.distance <= radius
+}
@@ -0,0 +1,53 @@
+package daemons
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/utils"
+	"time"
+)
+
+// Full registry reload (node registrations and spec updates emit no contract events,
+// so peer lookups fall back to the chain and the watchers refresh the nodes of each event)
+const registrySyncInterval = time.Minute
+
+// Container changes not handled by the DCR watchers
+func watchRegistry(ctx context.Context, node *managers.Node) {
+
+	// Controller smart contract instance
+	cinst := node.GetControllerInst()
+
+	updated := make(chan *bindings.ControllerContainerUpdated)
+	unregistered := make(chan *bindings.ControllerContainerUnregistered)
+
+	// Subscriptions to the events
+	usub, err := cinst.WatchContainerUpdated(nil, updated)
+	utils.LogWarning(err)
+	rsub, err := cinst.WatchContainerUnregistered(nil, unregistered)
+	utils.LogWarning(err)
+
+	ticker := time.NewTicker(registrySyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case log := <-updated:
+			if !log.Raw.Removed {
+				node.RefreshContainer(log.Rcid)
+			}
+		case log := <-unregistered:
+			if !log.Raw.Removed {
+				node.RefreshContainer(log.Rcid)
+			}
+		case <-ticker.C:
+			node.SyncRegistry()
+		case err = <-usub.Err():
+			utils.LogWarning(err)
+		case err = <-rsub.Err():
+			utils.LogWarning(err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package daemons
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errDuplicatedRule  = errors.New("duplicated rule name")
+	errUnknownRulesExt = errors.New("unknown rules file extension (.json, .yaml or .yml)")
+	errContainerRule   = errors.New("container-scoped rule without Docker (DOCKER_ENABLED)")
+)
+
+// MonitorV1 rules (loaded from a rules file and reloaded when it changes)
+type ruleSet struct {
+	path       string
+	modTime    time.Time
+	containers bool // Are container-scoped rules allowed?
+
+	mutex sync.RWMutex
+	rules []types.Rule
+}
+
+// Rules file layout
+type rulesFile struct {
+	Rules []types.Rule `json:"rules" yaml:"rules"`
+}
+
+// Without a rules file, the compiled-in rules are used (invalid ones are dropped)
+func newRuleSet(path string, defaults []types.Rule, containers bool) *ruleSet {
+
+	rs := &ruleSet{path: path, containers: containers}
+	for _, rule := range defaults {
+		if err := rule.Validate(); err != nil {
+			utils.LogWarning(err)
+			continue
+		}
+		if rule.Scope != nil && !containers {
+			utils.LogWarning(fmt.Errorf("%w: %q", errContainerRule, rule.NameID))
+			continue
+		}
+		rs.rules = append(rs.rules, rule)
+	}
+
+	return rs
+}
+
+func (rs *ruleSet) Rules() []types.Rule {
+
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	return rs.rules
+}
+
+// Load the rules file if it was modified (invalid files keep the current rules)
+func (rs *ruleSet) Reload() (changed bool, err error) {
+
+	if rs.path == "" {
+		return
+	}
+
+	info, err := os.Stat(rs.path)
+	if err != nil || info.ModTime().Equal(rs.modTime) {
+		return
+	}
+	rs.modTime = info.ModTime()
+
+	rules, err := loadRules(rs.path)
+	if err != nil {
+		return
+	}
+	if !rs.containers {
+		for i := range rules {
+			if rules[i].Scope != nil {
+				return false, fmt.Errorf("%s: %w: %q", rs.path, errContainerRule, rules[i].NameID)
+			}
+		}
+	}
+
+	rs.mutex.Lock()
+	rs.rules = rules
+	rs.mutex.Unlock()
+
+	return true, nil
+}
+
+func loadRules(path string) ([]types.Rule, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode rules depending on the file extension
+	var rf rulesFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, &rf)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &rf)
+	default:
+		err = errUnknownRulesExt
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Check rules before using them (limit types are checked against metric types)
+	names := map[string]bool{}
+	for i := range rf.Rules {
+		if err = rf.Rules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if names[rf.Rules[i].NameID] {
+			return nil, fmt.Errorf("%s: %w: %q", path, errDuplicatedRule, rf.Rules[i].NameID)
+		}
+		names[rf.Rules[i].NameID] = true
+	}
+
+	return rf.Rules, nil
+}
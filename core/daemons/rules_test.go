@@ -0,0 +1,115 @@
+package daemons
+
+import (
+	"errors"
+	"github.com/swarleynunez/hidra/core/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadRules(t *testing.T) {
+
+	rules, err := loadRules(filepath.Join("..", "..", "inputs", "rules.example.yaml"))
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	if len(rules) != 6 ||
+		rules[0].Resource != types.CpuResource ||
+		rules[0].Comparator != types.GreaterComp ||
+		rules[0].Action != types.SendEventAction ||
+		rules[0].Limit != float64(90) ||
+		rules[2].Limit != uint64(107374182400) ||
+		rules[2].Proceed == nil ||
+		len(rules[2].Proceed.Command) != 4 ||
+		len(rules[4].Condition.All) != 2 ||
+		len(rules[4].Condition.Leaves()) != 3 ||
+		rules[4].Condition.All[0].Clear != float64(60) ||
+		rules[5].Scope == nil ||
+		!rules[5].Matches(1, &types.ContainerType{ServiceType: types.WebServerServ}) ||
+		rules[5].Matches(1, &types.ContainerType{ServiceType: types.DatabaseServ}) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestLoadRulesValidation(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"mismatch.json":  `{"rules": [{"name": "r1", "resource": "mem", "metric": "units", "comparator": ">", "limit": "1GB", "action": "warn"}]}`,
+		"fraction.json":  `{"rules": [{"name": "r1", "resource": "pkt_sent", "metric": "units", "comparator": ">", "limit": 1.5, "action": "warn"}]}`,
+		"percent.yaml":   "rules:\n  - {name: r1, resource: cpu, metric: percent, comparator: '>', limit: 120, action: warn}\n",
+		"metric.yaml":    "rules:\n  - {name: r1, resource: cpu, metric: units, comparator: '>', limit: 1, action: warn}\n",
+		"duplicate.yaml": "rules:\n  - {name: r1, resource: cpu, metric: percent, comparator: '>', limit: 1, action: warn}\n  - {name: r1, resource: cpu, metric: percent, comparator: '<', limit: 1, action: warn}\n",
+		"proceed.yaml":   "rules:\n  - {name: r1, resource: cpu, metric: percent, comparator: '>', limit: 1, action: proceed}\n",
+		"unknown.yaml":   "rules:\n  - {name: r1, resource: gpu, metric: percent, comparator: '>', limit: 1, action: warn}\n",
+		"clear.yaml":     "rules:\n  - {name: r1, resource: cpu, metric: percent, comparator: '>', limit: 80, clear: 90, action: warn}\n",
+		"mixed.yaml":     "rules:\n  - {name: r1, resource: cpu, condition: {any: [{resource: cpu, metric: percent, comparator: '>', limit: 1}]}, action: warn}\n",
+		"empty.yaml":     "rules:\n  - {name: r1, condition: {all: []}, action: warn}\n",
+		"aggr.yaml":      "rules:\n  - {name: r1, resource: cpu, metric: percent, comparator: '>', limit: 1, aggregation: median, action: warn}\n",
+		"scope.yaml":     "rules:\n  - {name: r1, scope: {}, resource: cpu, metric: percent, comparator: '>', limit: 1, action: warn}\n",
+		"service.yaml":   "rules:\n  - {name: r1, scope: {service: gpu}, resource: cpu, metric: percent, comparator: '>', limit: 1, action: warn}\n",
+		"rules.txt":      "",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+
+		if _, err := loadRules(path); err == nil {
+			t.Fatal("ERROR:", t.Name(), name)
+		}
+	}
+}
+
+func TestReloadRules(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rule := `{"rules": [{"name": "r1", "resource": "cpu", "metric": "percent", "comparator": ">", "limit": 50, "action": "warn"}]}`
+	if err := os.WriteFile(path, []byte(rule), 0644); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	rs := newRuleSet(path, nil, true)
+	if changed, err := rs.Reload(); !changed || err != nil || len(rs.Rules()) != 1 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Unchanged file
+	if changed, _ := rs.Reload(); changed {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// An invalid update keeps the current rules
+	if err := os.WriteFile(path, []byte(`{"rules": [{"name": ""}]}`), 0644); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	if changed, err := rs.Reload(); changed || err == nil || len(rs.Rules()) != 1 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestContainerRulesWithoutDocker(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rule := `{"rules": [{"name": "r1", "resource": "cpu", "metric": "percent", "comparator": ">", "limit": 50, "action": "warn", "scope": {"rcid": 1}}]}`
+	if err := os.WriteFile(path, []byte(rule), 0644); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Rejected if Docker is not connected
+	rs := newRuleSet(path, nil, false)
+	if _, err := rs.Reload(); !errors.Is(err, errContainerRule) || len(rs.Rules()) != 0 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	rs = newRuleSet(path, nil, true)
+	if _, err := rs.Reload(); err != nil || len(rs.Rules()) != 1 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
@@ -0,0 +1,441 @@
+package daemons
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errTooFewNodes        = errors.New("at least 2 nodes are required")
+	errMalformedProfile   = errors.New("malformed node profile (loss:latency)")
+	errMalformedFailure   = errors.New("malformed node failure (node@from[-until])")
+	errUnknownNode        = errors.New("unknown simulated node")
+	errUnknownFormat      = errors.New("unknown report format")
+	errNoProfiles         = errors.New("no node profiles")
+	errUnreachableProfile = errors.New("100% packet loss profile")
+	errUnknownAggregation = errors.New("unknown score aggregation (topk, median or trimmed)")
+	errTrimOutOfRange     = errors.New("score trim out of range (0-49%)")
+)
+
+// In-process cluster experiment (hidra simulate)
+type SimulationConfig struct {
+	Nodes       int
+	Events      uint64
+	Packets     uint64              // Per peer and reputation epoch (one epoch per event)
+	Profiles    []types.NodeProfile // Assigned to the nodes in a round-robin fashion
+	Failures    []types.NodeFailure
+	LossProbTh  uint64
+	LatTh       uint64
+	Aggregation string // Reply scores aggregation (topk by default)
+	Trim        uint64 // %
+	Seed        int64  // 0: random
+}
+
+// Simulated node state
+type simNode struct {
+	node      *managers.Node
+	profile   types.NodeProfile
+	nodeStore types.NodeStore
+}
+
+// Message sent by a node and simulated arrival time
+type simArrival struct {
+	node int
+	at   uint64
+}
+
+// Run every event through the DEL phases on a simulated chain
+func Simulate(ctx context.Context, sc *SimulationConfig) (*types.SimulationReport, error) {
+
+	if sc.Nodes < 2 {
+		return nil, errTooFewNodes
+	}
+	if len(sc.Profiles) == 0 {
+		return nil, errNoProfiles
+	}
+	for _, p := range sc.Profiles {
+		if p.LossProb >= 100 {
+			return nil, errUnreachableProfile
+		}
+	}
+	for _, f := range sc.Failures {
+		if f.Node < 0 || f.Node >= sc.Nodes {
+			return nil, fmt.Errorf("%w: %d", errUnknownNode, f.Node)
+		}
+	}
+	switch strings.ToLower(sc.Aggregation) {
+	case "", topKAggregation, medianAggregation, trimmedAggregation:
+		if sc.Trim >= 50 {
+			return nil, errTrimOutOfRange
+		}
+	default:
+		return nil, errUnknownAggregation
+	}
+
+	seed := sc.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	agg := newScoreAggregation(sc.Aggregation, sc.Trim)
+
+	c, err := simnet.NewCluster(ctx, sc.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	report := &types.SimulationReport{Seed: seed}
+	nodes := make([]*simNode, sc.Nodes)
+	for i, n := range c.Nodes {
+		nodes[i] = &simNode{node: n, profile: sc.Profiles[i%len(sc.Profiles)], nodeStore: types.NodeStore{}}
+		report.Nodes = append(report.Nodes, types.SimulatedNode{Index: i, Address: n.GetFromAccount(), Profile: nodes[i].profile})
+	}
+
+	for epoch := uint64(1); epoch <= sc.Events; epoch++ {
+		alive := aliveNodes(sc, epoch)
+
+		// Reputation epoch before each event
+		simulateEpoch(rng, sc, nodes, alive)
+
+		se := types.SimulatedEvent{Sender: -1, Solver: -1, Failed: failedNodes(sc, epoch)}
+		if len(alive) > 0 {
+			se.Sender = alive[int(epoch-1)%len(alive)]
+			err = simulateEvent(ctx, rng, c, nodes, alive, agg, &se)
+			if err != nil {
+				return nil, err
+			}
+		}
+		se.Reputations = meanReputations(nodes, alive)
+		report.Events = append(report.Events, se)
+
+		// Debug
+		utils.Info("Simulated event", "epoch", epoch, "eid", se.Eid, "phase", se.Phase, "solver", se.Solver, "latency_ms", se.Latency)
+	}
+
+	return report, nil
+}
+
+func simulateEvent(ctx context.Context, rng *rand.Rand, c *simnet.Cluster, nodes []*simNode, alive []int, agg *scoreAggregation, se *types.SimulatedEvent) error {
+
+	sender := nodes[se.Sender]
+	etype := types.EventType{RequiredTask: types.PingNodeTask, Resource: types.NoResource}
+	err := sender.node.SendEvent(ctx, &etype, 0)
+	if err != nil {
+		return err
+	}
+	c.Commit()
+	sentAt := txDelay(rng, sender.profile)
+	state, err := sender.node.GetClusterState()
+	if err != nil {
+		return err
+	}
+	se.Eid, se.Phase = state.NextEventId-1, "NewEvent"
+
+	// Replies in arrival order until the required ones are mined
+	for _, a := range arrivals(rng, nodes, alive, sentAt) {
+		_smutex.RLock()
+		repScores := managers.GetReputationScores(nodes[a.node].nodeStore)
+		_smutex.RUnlock()
+
+		err = nodes[a.node].node.SendReply(ctx, se.Eid, repScores)
+		if err != nil {
+			utils.LogWarning(err)
+			continue
+		}
+		c.Commit()
+
+		if sender.node.GetEvent(se.Eid).HasRequiredReplies {
+			se.Phase, se.RepliesTime = "RequiredReplies", a.at
+			break
+		}
+	}
+	if se.RepliesTime == 0 {
+		return nil
+	}
+
+	// Votes (solver selected by each node from the replies)
+	for _, a := range arrivals(rng, nodes, alive, se.RepliesTime) {
+		solver := selectSolver(nodes[a.node].node, se.Eid, agg)
+		if utils.EmptyEthAddress(solver.String()) {
+			utils.LogWarning(errNoSolverFound)
+			continue
+		}
+
+		err = nodes[a.node].node.VoteSolver(ctx, se.Eid, solver)
+		if err != nil {
+			utils.LogWarning(err)
+			continue
+		}
+		c.Commit()
+
+		if event := sender.node.GetEvent(se.Eid); event.HasRequiredVotes {
+			se.Phase, se.VotesTime = "RequiredVotes", a.at
+			se.Solver = nodeIndex(nodes, event.Solver)
+			break
+		}
+	}
+	if se.VotesTime == 0 || !containsNode(alive, se.Solver) {
+		return nil
+	}
+
+	// The solver is alive
+	solver := nodes[se.Solver]
+	err = solver.node.SolveEvent(ctx, se.Eid)
+	if err != nil {
+		return err
+	}
+	c.Commit()
+	se.Phase, se.Latency = "EventSolved", se.VotesTime+txDelay(rng, solver.profile)
+
+	return nil
+}
+
+// Packets exchanged by every alive node with each peer (failed peers lose all packets)
+func simulateEpoch(rng *rand.Rand, sc *SimulationConfig, nodes []*simNode, alive []int) {
+
+	for _, i := range alive {
+		for j, peer := range nodes {
+			if i == j {
+				continue
+			}
+
+			addr := peer.node.GetFromAccount()
+			if nodes[i].nodeStore[addr] == nil {
+				nodes[i].nodeStore[addr] = &types.NodeInfo{}
+			}
+			epoch := &nodes[i].nodeStore[addr].CurrentEpoch
+
+			for p := uint64(0); p < sc.Packets; p++ {
+				lost, latency := simulatePacketPerformance(rng, peer.profile.LossProb, peer.profile.MaxLatency)
+				if !containsNode(alive, j) {
+					lost = true
+				}
+
+				epoch.TotalPackets++
+				if !lost {
+					epoch.OKPackets++
+					epoch.Latencies = append(epoch.Latencies, latency)
+				}
+			}
+		}
+
+		updateNodeReputations(nodes[i].nodeStore, sc.LossProbTh, sc.LatTh)
+	}
+}
+
+// Lost transactions are sent again after the maximum latency
+func txDelay(rng *rand.Rand, p types.NodeProfile) (delay uint64) {
+
+	for {
+		lost, latency := simulatePacketPerformance(rng, p.LossProb, p.MaxLatency)
+		if !lost {
+			return delay + latency
+		}
+		delay += p.MaxLatency
+	}
+}
+
+func arrivals(rng *rand.Rand, nodes []*simNode, alive []int, from uint64) (r []simArrival) {
+
+	for _, i := range alive {
+		r = append(r, simArrival{node: i, at: from + txDelay(rng, nodes[i].profile)})
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].at < r[j].at
+	})
+
+	return
+}
+
+// Mean reputation score of each node given by its alive peers
+func meanReputations(nodes []*simNode, alive []int) []float64 {
+
+	reps := make([]float64, len(nodes))
+	for j, peer := range nodes {
+		var total float64
+		var count int
+		for _, i := range alive {
+			if ni := nodes[i].nodeStore[peer.node.GetFromAccount()]; i != j && ni != nil {
+				total += ni.Reputation.Score
+				count++
+			}
+		}
+		if count > 0 {
+			reps[j] = total / float64(count)
+		}
+	}
+
+	return reps
+}
+
+func aliveNodes(sc *SimulationConfig, epoch uint64) (alive []int) {
+
+	failed := failedNodes(sc, epoch)
+	for i := 0; i < sc.Nodes; i++ {
+		if !containsNode(failed, i) {
+			alive = append(alive, i)
+		}
+	}
+
+	return
+}
+
+func failedNodes(sc *SimulationConfig, epoch uint64) (failed []int) {
+
+	for _, f := range sc.Failures {
+		if epoch >= f.From && (f.Until == 0 || epoch < f.Until) && !containsNode(failed, f.Node) {
+			failed = append(failed, f.Node)
+		}
+	}
+	sort.Ints(failed)
+
+	return
+}
+
+// Is the node in the list? (alive or failed nodes)
+func containsNode(nodes []int, node int) bool {
+
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+func nodeIndex(nodes []*simNode, addr common.Address) int {
+
+	for i := range nodes {
+		if nodes[i].node.GetFromAccount() == addr {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Parsing //
+// "10:50,0:20" --> loss probability (%) and maximum latency (ms) per node
+func ParseNodeProfiles(s string) (profiles []types.NodeProfile, err error) {
+
+	for _, p := range strings.Split(s, ",") {
+		loss, lat, found := strings.Cut(strings.TrimSpace(p), ":")
+		if !found {
+			return nil, fmt.Errorf("%w: %q", errMalformedProfile, p)
+		}
+
+		var np types.NodeProfile
+		np.LossProb, err = strconv.ParseUint(loss, 10, 64)
+		if err != nil || np.LossProb >= 100 {
+			return nil, fmt.Errorf("%w: %q", errMalformedProfile, p)
+		}
+		np.MaxLatency, err = strconv.ParseUint(lat, 10, 64)
+		if err != nil || np.MaxLatency == 0 {
+			return nil, fmt.Errorf("%w: %q", errMalformedProfile, p)
+		}
+		profiles = append(profiles, np)
+	}
+
+	return profiles, nil
+}
+
+// "2@5" (node 2 fails from event 5) or "2@5-8" (and recovers at event 8)
+func ParseNodeFailure(s string) (f types.NodeFailure, err error) {
+
+	node, events, found := strings.Cut(strings.TrimSpace(s), "@")
+	if !found {
+		return f, fmt.Errorf("%w: %q", errMalformedFailure, s)
+	}
+	from, until, ranged := strings.Cut(events, "-")
+
+	f.Node, err = strconv.Atoi(node)
+	if err != nil {
+		return f, fmt.Errorf("%w: %q", errMalformedFailure, s)
+	}
+	f.From, err = strconv.ParseUint(from, 10, 64)
+	if err != nil || f.From == 0 {
+		return f, fmt.Errorf("%w: %q", errMalformedFailure, s)
+	}
+	if ranged {
+		f.Until, err = strconv.ParseUint(until, 10, 64)
+		if err != nil || f.Until <= f.From {
+			return f, fmt.Errorf("%w: %q", errMalformedFailure, s)
+		}
+	}
+
+	return f, nil
+}
+
+// Reports //
+func WriteSimulationReport(w io.Writer, r *types.SimulationReport, format string) error {
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "csv":
+		return writeSimulationCSV(w, r)
+	}
+
+	return fmt.Errorf("%w: %q", errUnknownFormat, format)
+}
+
+// One row per event (reputation columns per node)
+func writeSimulationCSV(w io.Writer, r *types.SimulationReport) error {
+
+	header := []string{"eid", "sender", "solver", "phase", "replies_ms", "votes_ms", "latency_ms", "failed"}
+	for _, n := range r.Nodes {
+		header = append(header, "rep_"+strconv.Itoa(n.Index))
+	}
+
+	cw := csv.NewWriter(w)
+	err := cw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range r.Events {
+		failed := make([]string, len(e.Failed))
+		for i, n := range e.Failed {
+			failed[i] = strconv.Itoa(n)
+		}
+
+		row := []string{
+			strconv.FormatUint(e.Eid, 10),
+			strconv.Itoa(e.Sender),
+			strconv.Itoa(e.Solver),
+			e.Phase,
+			strconv.FormatUint(e.RepliesTime, 10),
+			strconv.FormatUint(e.VotesTime, 10),
+			strconv.FormatUint(e.Latency, 10),
+			strings.Join(failed, " "),
+		}
+		for _, rep := range e.Reputations {
+			row = append(row, strconv.FormatFloat(rep, 'f', 4, 64))
+		}
+
+		if err = cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,141 @@
+package daemons
+
+import (
+	"bytes"
+	"context"
+	"github.com/swarleynunez/hidra/core/types"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+
+	// The last node is too slow for its peers
+	profiles, err := ParseNodeProfiles("0:10,0:10,0:10,0:500")
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	sc := &SimulationConfig{Nodes: 4, Events: 3, Packets: 20, Profiles: profiles, LossProbTh: 50, LatTh: 50, Seed: 1}
+
+	r, err := Simulate(context.Background(), sc)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	if r.Seed != 1 || len(r.Nodes) != 4 || len(r.Events) != 3 || r.Nodes[3].Profile.MaxLatency != 500 {
+		t.Fatal("ERROR:", t.Name())
+	}
+	for i, e := range r.Events {
+		if e.Eid != uint64(i+1) ||
+			e.Sender != i ||
+			e.Phase != "EventSolved" ||
+			e.Solver < 0 || e.Solver == 3 ||
+			e.RepliesTime == 0 || e.VotesTime < e.RepliesTime || e.Latency < e.VotesTime ||
+			e.Reputations[0] != 1 || e.Reputations[3] != 0 {
+			t.Fatal("ERROR:", t.Name(), e)
+		}
+	}
+}
+
+func TestSimulateFailures(t *testing.T) {
+
+	sc := &SimulationConfig{
+		Nodes:      4,
+		Events:     3,
+		Packets:    20,
+		Profiles:   []types.NodeProfile{{LossProb: 0, MaxLatency: 10}},
+		Failures:   []types.NodeFailure{{Node: 1, From: 2}},
+		LossProbTh: 50,
+		LatTh:      50,
+		Seed:       1,
+	}
+
+	r, err := Simulate(context.Background(), sc)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Failed nodes stop sending events and lose their reputation
+	e1, e2, e3 := r.Events[0], r.Events[1], r.Events[2]
+	if len(e1.Failed) != 0 ||
+		len(e2.Failed) != 1 || e2.Failed[0] != 1 ||
+		e2.Sender == 1 || e3.Sender == 1 ||
+		e1.Reputations[1] != 1 ||
+		e2.Reputations[1] >= e1.Reputations[1] ||
+		e3.Reputations[1] >= e2.Reputations[1] {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// No event is sent while every node is down (on-chain IDs are not skipped)
+	sc.Failures = []types.NodeFailure{{Node: 0, From: 2, Until: 3}, {Node: 1, From: 2, Until: 3}, {Node: 2, From: 2, Until: 3}, {Node: 3, From: 2, Until: 3}}
+	if r, err = Simulate(context.Background(), sc); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if r.Events[0].Eid != 1 || r.Events[1].Eid != 0 || r.Events[1].Sender != -1 ||
+		r.Events[2].Eid != 2 || r.Events[2].Phase != "EventSolved" {
+		t.Fatal("ERROR:", t.Name(), r.Events)
+	}
+
+	// Unknown node
+	sc.Failures = []types.NodeFailure{{Node: 4, From: 1}}
+	if _, err = Simulate(context.Background(), sc); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestParseSimulationFlags(t *testing.T) {
+
+	profiles, err := ParseNodeProfiles("10:50, 0:20")
+	if err != nil || len(profiles) != 2 || profiles[0].LossProb != 10 || profiles[1].MaxLatency != 20 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	for _, s := range []string{"", "10", "100:50", "10:0", "a:b"} {
+		if _, err = ParseNodeProfiles(s); err == nil {
+			t.Fatal("ERROR:", t.Name(), s)
+		}
+	}
+
+	f, err := ParseNodeFailure("2@5-8")
+	if err != nil || f.Node != 2 || f.From != 5 || f.Until != 8 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if f, err = ParseNodeFailure("0@1"); err != nil || f.Until != 0 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	for _, s := range []string{"2", "2@0", "2@5-5", "x@1", "2@a"} {
+		if _, err = ParseNodeFailure(s); err == nil {
+			t.Fatal("ERROR:", t.Name(), s)
+		}
+	}
+}
+
+func TestWriteSimulationReport(t *testing.T) {
+
+	r := &types.SimulationReport{
+		Nodes: []types.SimulatedNode{{Index: 0}, {Index: 1}},
+		Events: []types.SimulatedEvent{
+			{Eid: 1, Sender: 0, Solver: 1, Phase: "EventSolved", RepliesTime: 10, VotesTime: 20, Latency: 30, Reputations: []float64{1, 0.5}},
+			{Eid: 2, Sender: 1, Solver: -1, Phase: "NewEvent", Failed: []int{0}, Reputations: []float64{0, 0.5}},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := WriteSimulationReport(&b, r, "csv"); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 ||
+		lines[0] != "eid,sender,solver,phase,replies_ms,votes_ms,latency_ms,failed,rep_0,rep_1" ||
+		lines[1] != "1,0,1,EventSolved,10,20,30,,1.0000,0.5000" ||
+		lines[2] != "2,1,-1,NewEvent,0,0,0,0,0.0000,0.5000" {
+		t.Fatal("ERROR:", t.Name(), lines)
+	}
+
+	b.Reset()
+	if err := WriteSimulationReport(&b, r, "json"); err != nil || !strings.Contains(b.String(), `"latency_ms": 30`) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if WriteSimulationReport(&b, r, "xml") == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
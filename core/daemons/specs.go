@@ -0,0 +1,32 @@
+package daemons
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/utils"
+	"time"
+)
+
+// Hardware and IP changes check
+const specsCheckInterval = time.Minute
+
+func monitorSpecs(ctx context.Context, node *managers.Node) {
+
+	ticker := time.NewTicker(specsCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		updated, err := node.RefreshSpecs(ctx)
+		if err != nil {
+			utils.LogWarning(err)
+		} else if updated {
+			utils.Info("Node specs updated")
+		}
+	}
+}
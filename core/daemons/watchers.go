@@ -3,11 +3,14 @@ package daemons
 import (
 	"context"
 	"errors"
-	"fmt"
+	"github.com/swarleynunez/hidra/core/api"
 	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/experiments"
 	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/metrics"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
+	"sync"
 	"time"
 )
 
@@ -15,18 +18,70 @@ var (
 	errNoSolverFound = errors.New("no solver found")
 )
 
+// DEL phase times per event (shared by the watchers)
+type eventLatencies struct {
+	mutex   sync.Mutex
+	times   map[uint64]types.EventTimes
+	stalled map[uint64]types.APIStalledEvent // Expired phase deadlines
+	rec     *experiments.Recorder            // Nil if experiments are not recorded
+}
+
+func newEventLatencies(rec *experiments.Recorder) *eventLatencies {
+
+	return &eventLatencies{
+		times:   make(map[uint64]types.EventTimes),
+		stalled: make(map[uint64]types.APIStalledEvent),
+		rec:     rec,
+	}
+}
+
+// Set a phase time of an event and return all its times
+func (el *eventLatencies) update(eid uint64, set func(et *types.EventTimes)) types.EventTimes {
+
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	et := el.times[eid]
+	set(&et)
+	el.times[eid] = et
+
+	return et
+}
+
+// Save a solved event into the experiment file
+func (el *eventLatencies) record(node *managers.Node, eid uint64, event *types.Event, et *types.EventTimes) {
+
+	if el.rec == nil {
+		return
+	}
+
+	err := el.rec.Record(&types.ExperimentEvent{
+		Eid:       eid,
+		Node:      node.GetFromAccount(),
+		Sender:    event.Sender,
+		Solver:    event.Solver,
+		Rcid:      event.Rcid,
+		Replies:   node.GetEventReplyCount(eid),
+		Start:     et.Start,
+		RepliesAt: et.Replies,
+		VotesAt:   et.Votes,
+		End:       et.End,
+	})
+	utils.LogWarning(err)
+}
+
 // DEL (debug: all cluster nodes)
-func WatchNewEvent(ctx context.Context, latencies map[uint64]types.EventTimes, nodeStore types.NodeStore) {
+func WatchNewEvent(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline, nodeStore types.NodeStore) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	// Log channel
 	logs := make(chan *bindings.ControllerNewEvent)
 
 	// Subscription to the event
 	sub, err := cinst.WatchNewEvent(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -41,39 +96,54 @@ func WatchNewEvent(ctx context.Context, latencies map[uint64]types.EventTimes, n
 
 				// Experiments
 				start := time.Now().UnixMilli()
-				latencies[log.Eid] = types.EventTimes{Start: start}
+				latencies.update(log.Eid, func(et *types.EventTimes) { et.Start = start })
 
 				// Debug
-				event := managers.GetEvent(log.Eid)
+				event := node.GetEvent(log.Eid)
+				node.RefreshNode(event.Sender) // Status and addresses of the sender
 				if event.Rcid > 0 {
-					fmt.Print("[", start, "] ", "NewEvent (EID=", log.Eid, ", Sender=", event.Sender.String(), ", RCID=", event.Rcid, ")\n")
+					utils.Info("NewEvent", "eid", log.Eid, "sender", event.Sender.String(), "rcid", event.Rcid)
 				} else {
-					fmt.Print("[", start, "] ", "NewEvent (EID=", log.Eid, ", Sender=", event.Sender.String(), ")\n")
+					utils.Info("NewEvent", "eid", log.Eid, "sender", event.Sender.String())
+				}
+				timeline.Add(types.APITimelineEntry{At: start, Eid: log.Eid, Phase: "NewEvent", Node: event.Sender, Rcid: event.Rcid})
+
+				// Re-issued stalled event?
+				var etype types.EventType
+				utils.UnmarshalJSON(event.EType, &etype)
+				if etype.Retry > 0 {
+					node.SetRetriedBy(etype.Retry, log.Eid)
+					latencies.retried(etype.Retry, log.Eid)
+					utils.Info("Stalled event re-issued", "eid", etype.Retry, "by", log.Eid, "attempt", etype.Attempt)
 				}
 
 				// Send reply containing the current reputation scores
 				go func() {
-					err = managers.SendReply(ctx, log.Eid, managers.GetReputationScores(nodeStore))
-					utils.CheckError(err, utils.WarningMode)
+					_smutex.RLock()
+					repScores := managers.GetReputationScores(nodeStore)
+					_smutex.RUnlock()
+
+					err = node.SendReply(ctx, log.Eid, repScores)
+					utils.LogWarning(err)
 				}()
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DEL (debug: all cluster nodes)
-func WatchRequiredReplies(ctx context.Context) {
+func WatchRequiredReplies(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline, agg *scoreAggregation) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerRequiredReplies)
 
 	// Subscription to the event
 	sub, err := cinst.WatchRequiredReplies(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -86,37 +156,47 @@ func WatchRequiredReplies(ctx context.Context) {
 			if !log.Raw.Removed && !lcache[log.Eid] {
 				lcache[log.Eid] = true
 
+				// Experiments
+				now := time.Now().UnixMilli()
+				latencies.update(log.Eid, func(et *types.EventTimes) { et.Replies = now })
+
 				// Debug
-				fmt.Print("[", time.Now().UnixMilli(), "] ", "RequiredReplies (EID=", log.Eid, ")\n")
+				utils.Info("RequiredReplies", "eid", log.Eid)
+				timeline.Add(types.APITimelineEntry{At: now, Eid: log.Eid, Phase: "RequiredReplies", Replies: node.GetEventReplyCount(log.Eid)})
+
+				// Current specs of the solver candidates
+				for _, reply := range node.GetEventReplies(log.Eid) {
+					node.RefreshNode(reply.Replier)
+				}
 
 				// Select and vote an event solver
-				solver := selectSolver(log.Eid)
+				solver := selectSolver(node, log.Eid, agg)
 				if !utils.EmptyEthAddress(solver.String()) {
 					go func() {
-						err = managers.VoteSolver(ctx, log.Eid, solver)
-						utils.CheckError(err, utils.WarningMode)
+						err = node.VoteSolver(ctx, log.Eid, solver)
+						utils.LogWarning(err)
 					}()
 				} else {
-					utils.CheckError(errNoSolverFound, utils.WarningMode)
+					utils.LogWarning(errNoSolverFound)
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DEL (debug: all cluster nodes)
-func WatchRequiredVotes(ctx context.Context) {
+func WatchRequiredVotes(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerRequiredVotes)
 
 	// Subscription to the event
 	sub, err := cinst.WatchRequiredVotes(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -129,40 +209,46 @@ func WatchRequiredVotes(ctx context.Context) {
 			if !log.Raw.Removed && !lcache[log.Eid] {
 				lcache[log.Eid] = true
 
+				// Experiments
+				now := time.Now().UnixMilli()
+				latencies.update(log.Eid, func(et *types.EventTimes) { et.Votes = now })
+
 				// Debug
-				event := managers.GetEvent(log.Eid)
-				fmt.Print("[", time.Now().UnixMilli(), "] ", "RequiredVotes (EID=", log.Eid, ", Solver=", event.Solver.String(), ")\n")
+				event := node.GetEvent(log.Eid)
+				node.RefreshNode(event.Solver) // Addresses of the voted solver
+				utils.Info("RequiredVotes", "eid", log.Eid, "solver", event.Solver.String())
+				timeline.Add(types.APITimelineEntry{At: now, Eid: log.Eid, Phase: "RequiredVotes", Node: event.Solver, Rcid: event.Rcid})
 
 				// Am I the voted solver?
-				from := managers.GetFromAccount()
+				from := node.GetFromAccount()
 				if event.Solver == from {
 					// Am I the event sender?
 					if event.Sender != from {
 						// Execute required event task (depends on the event type)
-						go managers.RunEventTask(ctx, event, log.Eid)
+						go node.RunEventTask(ctx, event, log.Eid)
 					} else {
 						// Execute required local task (depends on the event type)
-						go managers.RunTask(ctx, event, log.Eid)
+						go node.RunTask(ctx, event, log.Eid)
 					}
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DEL (debug: all cluster nodes)
-func WatchEventSolved(ctx context.Context, latencies map[uint64]types.EventTimes) {
+func WatchEventSolved(ctx context.Context, node *managers.Node, latencies *eventLatencies, timeline *api.Timeline, nodeStore types.NodeStore) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerEventSolved)
 
 	// Subscription to the event
 	sub, err := cinst.WatchEventSolved(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -177,39 +263,58 @@ func WatchEventSolved(ctx context.Context, latencies map[uint64]types.EventTimes
 
 				// Experiments
 				end := time.Now().UnixMilli()
-				latencies[log.Eid] = types.EventTimes{Start: latencies[log.Eid].Start, End: end}
+				et := latencies.update(log.Eid, func(et *types.EventTimes) { et.End = end })
+				metrics.ObserveEventTimes(&et)
 
 				// Debug
-				fmt.Print("[", end, "] ", "EventSolved (EID=", log.Eid, ")\n")
+				utils.Info("EventSolved", "eid", log.Eid, "latency_ms", et.End-et.Start)
 				//fmt.Print("\n--------------------------------------------------------------------------------\n\n")
 
+				event := node.GetEvent(log.Eid)
+				if event.Rcid > 0 {
+					node.RefreshContainer(event.Rcid) // New instance
+				}
+				latencies.record(node, log.Eid, event, &et)
+				timeline.Add(types.APITimelineEntry{At: end, Eid: log.Eid, Phase: "EventSolved", Node: event.Solver, Rcid: event.Rcid})
+
+				// Ping replies feed the reputation of the repliers
+				var etype types.EventType
+				utils.UnmarshalJSON(event.EType, &etype)
+				if etype.RequiredTask == types.PingNodeTask {
+					go recordPingReplies(node, log.Eid, nodeStore)
+				}
+
+				// Deleted or replaced blobs are dropped by every replica holder
+				if err = node.DropBlobReplica(&etype); err != nil {
+					utils.LogWarning(err)
+				}
+
 				// Am I the event sender and not the event solver?
-				event := managers.GetEvent(log.Eid)
-				from := managers.GetFromAccount()
+				from := node.GetFromAccount()
 				if event.Sender == from {
 					if event.Solver != from {
 						// Execute required ending task (depends on the event type)
-						go managers.RunEventEndingTask(ctx, event)
+						go node.RunEventEndingTask(ctx, event)
 					}
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DCR (debug: only owner nodes)
-func WatchApplicationRegistered() {
+func WatchApplicationRegistered(node *managers.Node) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerApplicationRegistered)
 
 	// Subscription to the event
 	sub, err := cinst.WatchApplicationRegistered(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -223,36 +328,36 @@ func WatchApplicationRegistered() {
 				lcache[log.Appid] = true
 
 				// Am I the application owner?
-				app := managers.GetApplication(log.Appid)
-				if app.Owner == managers.GetFromAccount() {
+				app := node.GetApplication(log.Appid)
+				if app.Owner == node.GetFromAccount() {
 					// Debug
-					fmt.Print("[", time.Now().UnixMilli(), "] ", "ApplicationRegistered (APPID=", log.Appid, ")\n")
+					utils.Info("ApplicationRegistered", "appid", log.Appid)
 
 					// Decode application info
 					var ainfo types.ApplicationInfo
 					utils.UnmarshalJSON(app.Info, &ainfo)
 
 					// ONOS SDN plugin
-					managers.ONOSAddVirtualService(log.Appid, ainfo.Description, ainfo.IP, ainfo.Protocol, ainfo.Port)
+					node.ONOSAddVirtualService(log.Appid, ainfo.Description, ainfo.IP, ainfo.Protocol, ainfo.Port)
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DCR (debug: only owner nodes)
-func WatchContainerRegistered(ctx context.Context) {
+func WatchContainerRegistered(ctx context.Context, node *managers.Node) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerContainerRegistered)
 
 	// Subscription to the event
 	sub, err := cinst.WatchContainerRegistered(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -264,25 +369,26 @@ func WatchContainerRegistered(ctx context.Context) {
 			// Check if a log has already been received
 			if !log.Raw.Removed && !lcache[log.Rcid] {
 				lcache[log.Rcid] = true
+				node.RefreshContainer(log.Rcid)
 
 				// Am I the container owner?
-				ctr := managers.GetContainer(log.Rcid)
-				from := managers.GetFromAccount()
-				if managers.GetApplication(ctr.Appid).Owner == from {
+				ctr := node.GetContainer(log.Rcid)
+				from := node.GetFromAccount()
+				if node.GetApplication(ctr.Appid).Owner == from {
 					// Debug
-					fmt.Print("[", time.Now().UnixMilli(), "] ", "ContainerRegistered (RCID=", log.Rcid, ", APPID=", ctr.Appid, ")\n")
+					utils.Info("ContainerRegistered", "rcid", log.Rcid, "appid", ctr.Appid)
 
 					// Am I the container host?
-					if managers.IsContainerHost(log.Rcid, from) {
+					if node.IsContainerHost(log.Rcid, from) {
 						/*// Decode container info
 						var cinfo types.ContainerInfo
 						utils.UnmarshalJSON(ctr.Info, &cinfo)
 
 						// Autodeploy mode (anonymous function)
 						go func() {
-							managers.NewContainer(ctx, &cinfo, ctr.Appid, log.Rcid, true)
-							err = managers.ActivateContainer(ctx, log.Rcid)
-							utils.CheckError(err, utils.WarningMode)
+							node.NewContainer(ctx, &cinfo, ctr.Appid, log.Rcid, true)
+							err = node.ActivateContainer(ctx, log.Rcid)
+							utils.LogWarning(err)
 						}()*/
 					} else {
 						// Encapsulate event type
@@ -292,29 +398,29 @@ func WatchContainerRegistered(ctx context.Context) {
 						}
 
 						go func() {
-							err = managers.SendEvent(ctx, &etype, log.Rcid)
-							utils.CheckError(err, utils.WarningMode)
+							err = node.SendEvent(ctx, &etype, log.Rcid)
+							utils.LogWarning(err)
 						}()
 					}
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DCR (debug: only host nodes)
-/*func WatchContainerUpdated(ctx context.Context) {
+/*func WatchContainerUpdated(ctx context.Context, node *managers.Node) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerContainerUpdated)
 
 	// Subscription to the event
 	sub, err := cinst.WatchContainerUpdated(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Infinite loop
 	for {
@@ -323,10 +429,10 @@ func WatchContainerRegistered(ctx context.Context) {
 			// TODO: Check if an event log has already been received (repeated rcids)
 			if !log.Raw.Removed {
 				// Am I the container host?
-				ctr := managers.GetContainer(log.Rcid)
-				if managers.IsContainerHost(log.Rcid, managers.GetFromAccount()) {
+				ctr := node.GetContainer(log.Rcid)
+				if node.IsContainerHost(log.Rcid, node.GetFromAccount()) {
 					// Debug
-					fmt.Print("[", time.Now().UnixMilli(), "] ", "ContainerUpdated (RCID=", log.Rcid, ")\n")
+					utils.Info("ContainerUpdated", "rcid", log.Rcid)
 
 					// Decode container info
 					var cinfo types.ContainerInfo
@@ -335,31 +441,31 @@ func WatchContainerRegistered(ctx context.Context) {
 					// TODO: think about which containers fields could be updated by the owner and how
 					go func() { // Anonymous function
 						// TODO: manage container ports by cluster node
-						// managers.RemoveContainer(ctx, ctr.Appid, log.Rcid, true)
-						// managers.NewContainer(ctx, &cinfo, ctr.Appid, log.Rcid, true)
+						// node.RemoveContainer(ctx, ctr.Appid, log.Rcid, true)
+						// node.NewContainer(ctx, &cinfo, ctr.Appid, log.Rcid, true)
 					}()
 				} else {
 					// Clean container old instances (if exists)
-					// go managers.RemoveContainer(ctx, ctr.Appid, log.Rcid, false)
+					// go node.RemoveContainer(ctx, ctr.Appid, log.Rcid, false)
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }
 
 // DCR (debug: only host nodes)
-func WatchContainerUnregistered(ctx context.Context) {
+func WatchContainerUnregistered(ctx context.Context, node *managers.Node) {
 
 	// Controller smart contract instance
-	cinst := managers.GetControllerInst()
+	cinst := node.GetControllerInst()
 
 	logs := make(chan *bindings.ControllerContainerUnregistered)
 
 	// Subscription to the event
 	sub, err := cinst.WatchContainerUnregistered(nil, logs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Cache to avoid duplicated logs
 	lcache := map[uint64]bool{}
@@ -373,20 +479,20 @@ func WatchContainerUnregistered(ctx context.Context) {
 				lcache[log.Rcid] = true
 
 				// Debug
-				fmt.Print("[", time.Now().UnixMilli(), "] ", "ContainerUnregistered (RCID=", log.Rcid, ")\n")
+				utils.Info("ContainerUnregistered", "rcid", log.Rcid)
 
 				// Am I the container host?
-				_ = managers.GetContainer(log.Rcid)
-				if managers.IsContainerHost(log.Rcid, managers.GetFromAccount()) {
+				_ = node.GetContainer(log.Rcid)
+				if node.IsContainerHost(log.Rcid, node.GetFromAccount()) {
 					// Check if it is unregistering an application and remove container
-					// go managers.RemoveContainer(ctx, ctr.Appid, log.Rcid, !managers.IsApplicationUnregistered(ctr.Appid))
+					// go node.RemoveContainer(ctx, ctr.Appid, log.Rcid, !node.IsApplicationUnregistered(ctr.Appid))
 				} else {
 					// Clean container old instances (if exists)
-					// go managers.RemoveContainer(ctx, ctr.Appid, log.Rcid, false)
+					// go node.RemoveContainer(ctx, ctr.Appid, log.Rcid, false)
 				}
 			}
 		case err = <-sub.Err():
-			utils.CheckError(err, utils.WarningMode)
+			utils.LogWarning(err)
 		}
 	}
 }*/
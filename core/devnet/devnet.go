@@ -0,0 +1,575 @@
+package devnet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/swarleynunez/hidra/core/api"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/eth"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StateFile         = "devnet.json"
+	GethBasePort      = 30303 // Also the HIDRA node port (sniffed by the packet monitor)
+	AuthRPCBasePort   = 8551
+	MetricsBasePort   = 9200
+	DashboardBasePort = 9300
+	BlobBasePort      = 9400
+	pollInterval      = 250 * time.Millisecond
+	stopTimeout       = 10 * time.Second
+)
+
+var (
+	errAlreadyRunning = errors.New("devnet already running (hidra devnet down)")
+	errNotFound       = errors.New("devnet not found")
+	errTooFewNodes    = errors.New("at least 1 node is required")
+	errNotReady       = errors.New("timeout waiting for")
+)
+
+// Local cluster settings
+type Config struct {
+	Dir     string
+	Nodes   int
+	ChainID uint64
+	Period  uint64 // Clique block period (s)
+	Geth    string // Geth binary
+	Hidra   string // HIDRA binary (hidra run)
+	Iface   string // Packet monitor interface
+	Timeout time.Duration
+	Base    *config.Config // Settings shared by every node
+}
+
+// Saved by up and used by down and status
+type State struct {
+	ChainID    uint64      `json:"chain_id"`
+	Controller string      `json:"controller"`
+	Nodes      []NodeState `json:"nodes"`
+}
+
+type NodeState struct {
+	Name       string         `json:"name"`
+	Dir        string         `json:"dir"`
+	ConfigFile string         `json:"config_file"`
+	Address    common.Address `json:"address"`
+	Port       int            `json:"port"`
+	GethPID    int            `json:"geth_pid,omitempty"`
+	HidraPID   int            `json:"hidra_pid,omitempty"`
+}
+
+// Node status seen by hidra devnet status
+type NodeStatus struct {
+	NodeState
+	GethAlive  bool
+	HidraAlive bool
+	Block      uint64
+	Peers      uint64
+	APIReady   bool
+}
+
+// Create (or reset) and start a local cluster, waiting on every step
+func Up(ctx context.Context, c *Config) (*State, error) {
+
+	if c.Nodes < 1 {
+		return nil, errTooFewNodes
+	}
+	dir, err := filepath.Abs(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if st, err := LoadState(dir); err == nil && st.running() {
+		return nil, errAlreadyRunning
+	}
+
+	st := &State{ChainID: c.ChainID}
+	var accounts []common.Address
+	for i := 0; i < c.Nodes; i++ {
+		ns, err := initNodeDir(dir, i)
+		if err != nil {
+			return nil, err
+		}
+		st.Nodes = append(st.Nodes, *ns)
+		accounts = append(accounts, ns.Address)
+	}
+
+	// Fresh chain for every node
+	gpath := filepath.Join(dir, "genesis.json")
+	if err = writeGenesis(gpath, Genesis(c.ChainID, c.Period, accounts)); err != nil {
+		return nil, err
+	}
+	for i := range st.Nodes {
+		ndir := st.Nodes[i].Dir
+		if err = os.RemoveAll(filepath.Join(ndir, "geth")); err != nil {
+			return nil, err
+		}
+		out, err := exec.CommandContext(ctx, c.Geth, "--datadir", ndir, "init", gpath).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("geth init: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	// Stop everything if any step fails
+	ok := false
+	defer func() {
+		if !ok {
+			_ = saveState(dir, st)
+			_ = Down(dir, false)
+		}
+	}()
+
+	// Ethereum nodes (the first one seals and acts as bootnode)
+	var bootnode string
+	for i := range st.Nodes {
+		if err = startGeth(c, st, i, bootnode); err != nil {
+			return nil, err
+		}
+		if err = waitFor(ctx, c.Timeout, "geth "+st.Nodes[i].Name, func() bool { return gethReady(ctx, st.Nodes[i].Dir, i > 0) }); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			if bootnode, err = enode(ctx, st.Nodes[0].Dir); err != nil {
+				return nil, err
+			}
+		}
+		utils.Info("Ethereum node started", "node", st.Nodes[i].Name, "pid", st.Nodes[i].GethPID)
+	}
+
+	// Controller smart contract
+	cfgs := make([]*config.Config, len(st.Nodes))
+	for i := range st.Nodes {
+		cfgs[i] = nodeConfig(c, &st.Nodes[i], i)
+	}
+	deployer, err := managers.InitNode(ctx, cfgs[0], true)
+	if err != nil {
+		return nil, err
+	}
+	caddr, err := deployer.DeployController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st.Controller = caddr.String()
+	utils.Info("Controller deployed", "address", st.Controller)
+
+	// Node registration (once the contract is seen by every node)
+	for i := range st.Nodes {
+		cfgs[i].Eth.Controller = st.Controller
+		if err = writeNodeConfig(st.Nodes[i].ConfigFile, cfgs[i]); err != nil {
+			return nil, err
+		}
+		if err = waitFor(ctx, c.Timeout, "controller at "+st.Nodes[i].Name, func() bool { return hasCode(ctx, st.Nodes[i].Dir, caddr) }); err != nil {
+			return nil, err
+		}
+
+		node, err := managers.InitNode(ctx, cfgs[i], false)
+		if err != nil {
+			return nil, err
+		}
+		if err = node.RegisterNode(ctx, uint16(st.Nodes[i].Port)); err != nil {
+			return nil, err
+		}
+		if err = waitFor(ctx, c.Timeout, "registration of "+st.Nodes[i].Name, func() bool { return node.IsNodeRegistered(st.Nodes[i].Address) }); err != nil {
+			return nil, err
+		}
+		utils.Info("Node registered", "node", st.Nodes[i].Name, "port", st.Nodes[i].Port)
+	}
+
+	// HIDRA daemons
+	for i := range st.Nodes {
+		if err = startHidra(c, st, i); err != nil {
+			return nil, err
+		}
+		acfg := &cfgs[i].API
+		if err = waitFor(ctx, c.Timeout, "hidra "+st.Nodes[i].Name, func() bool { return apiReady(acfg) }); err != nil {
+			return nil, err
+		}
+		utils.Info("HIDRA daemon started", "node", st.Nodes[i].Name, "pid", st.Nodes[i].HidraPID)
+	}
+
+	ok = true
+	return st, saveState(dir, st)
+}
+
+// Stop the daemons and then the Ethereum nodes (purge: remove the devnet directory)
+func Down(dir string, purge bool) error {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	st, err := LoadState(dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range st.Nodes {
+		errs = append(errs, stopProcess(st.Nodes[i].HidraPID, st.Nodes[i].ConfigFile))
+		st.Nodes[i].HidraPID = 0
+	}
+	for i := range st.Nodes {
+		errs = append(errs, stopProcess(st.Nodes[i].GethPID, st.Nodes[i].Dir))
+		st.Nodes[i].GethPID = 0
+	}
+	if err = errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if purge {
+		return os.RemoveAll(dir)
+	}
+
+	return saveState(dir, st)
+}
+
+func Status(ctx context.Context, dir string) (*State, []NodeStatus, error) {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := LoadState(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nss []NodeStatus
+	for _, n := range st.Nodes {
+		ns := NodeStatus{NodeState: n, GethAlive: isRunning(n.GethPID, n.Dir), HidraAlive: isRunning(n.HidraPID, n.ConfigFile)}
+
+		if ns.GethAlive {
+			if ethc, err := eth.Connect(utils.FormatPath(n.Dir, "geth.ipc")); err == nil {
+				ns.Block, _ = ethc.BlockNumber(ctx)
+				ns.Peers, _ = peerCount(ctx, ethc.Client())
+				ethc.Close()
+			}
+		}
+		if ns.HidraAlive {
+			if cfg, err := config.Load(n.ConfigFile); err == nil {
+				ns.APIReady = apiReady(&cfg.API)
+			}
+		}
+		nss = append(nss, ns)
+	}
+
+	return st, nss, nil
+}
+
+func LoadState(dir string) (*State, error) {
+
+	b, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errNotFound, dir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var st State
+	if err = json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+func saveState(dir string, st *State) error {
+
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, StateFile), b, 0644)
+}
+
+// Any process still alive?
+func (st *State) running() bool {
+
+	for _, n := range st.Nodes {
+		if isRunning(n.GethPID, n.Dir) || isRunning(n.HidraPID, n.ConfigFile) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Nodes //
+// Node directory with its keystore account and passphrase (reused if they exist)
+func initNodeDir(dir string, i int) (*NodeState, error) {
+
+	name := "N" + strconv.Itoa(i+1)
+	ndir := filepath.Join(dir, name)
+	if err := os.MkdirAll(ndir, 0700); err != nil {
+		return nil, err
+	}
+
+	ppath := filepath.Join(ndir, "password.txt")
+	pass, err := os.ReadFile(ppath)
+	if errors.Is(err, os.ErrNotExist) {
+		pass, err = newPassphrase()
+		if err == nil {
+			err = os.WriteFile(ppath, pass, 0600)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ks := eth.LoadKeystore(filepath.Join(ndir, "keystore"))
+	if len(ks.Accounts()) == 0 {
+		if _, err = eth.CreateAccount(ks, string(pass)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &NodeState{
+		Name:       name,
+		Dir:        ndir,
+		ConfigFile: filepath.Join(ndir, config.DefaultFile),
+		Address:    ks.Accounts()[0].Address,
+		Port:       GethBasePort + i,
+	}, nil
+}
+
+func newPassphrase() ([]byte, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// Shared settings with the node paths and ports
+func nodeConfig(c *Config, ns *NodeState, i int) *config.Config {
+
+	cfg := *c.Base
+	pass, _ := os.ReadFile(filepath.Join(ns.Dir, "password.txt"))
+
+	cfg.Eth = config.EthConfig{ChainID: c.ChainID, NodeDir: ns.Dir, NodePass: string(pass)}
+	cfg.Monitor.RulesLogFile = filepath.Join(ns.Dir, "hidra-rules.log")
+	cfg.API.Addr = "unix:" + filepath.Join(ns.Dir, "hidra-api.sock")
+	cfg.API.DashboardAddr = "localhost:" + strconv.Itoa(DashboardBasePort+i)
+	cfg.API.TokenFile = filepath.Join(ns.Dir, "hidra-api.token")
+	cfg.Metrics.Addr = "localhost:" + strconv.Itoa(MetricsBasePort+i)
+	cfg.Storage.Dir = filepath.Join(ns.Dir, "blobs")
+	cfg.Storage.Addr = "127.0.0.1:" + strconv.Itoa(BlobBasePort+i)
+	cfg.Node.AdvertiseAddrs = "127.0.0.1" // Emulated nodes (packets monitored on loopback)
+	cfg.Log.Output = filepath.Join(ns.Dir, "hidra.log")
+	if cfg.Experiment.File != "" {
+		cfg.Experiment.File = filepath.Join(ns.Dir, filepath.Base(cfg.Experiment.File))
+	}
+
+	return &cfg
+}
+
+func writeNodeConfig(path string, cfg *config.Config) error {
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0600) // Passphrase included
+}
+
+// Processes //
+func startGeth(c *Config, st *State, i int, bootnode string) error {
+
+	n := &st.Nodes[i]
+	args := []string{
+		"--datadir", n.Dir,
+		"--networkid", strconv.FormatUint(c.ChainID, 10),
+		"--syncmode", "full",
+		"--port", strconv.Itoa(n.Port),
+		"--authrpc.port", strconv.Itoa(AuthRPCBasePort + i),
+		"--nat", "none",
+	}
+	if bootnode != "" {
+		args = append(args, "--bootnodes", bootnode)
+	} else {
+		sealer := n.Address.String()
+		args = append(args, "--unlock", sealer, "--password", filepath.Join(n.Dir, "password.txt"), "--mine", "--miner.etherbase", sealer)
+	}
+
+	pid, err := startProcess(c.Geth, args, filepath.Join(n.Dir, "geth.log"))
+	n.GethPID = pid
+
+	return err
+}
+
+func startHidra(c *Config, st *State, i int) error {
+
+	n := &st.Nodes[i]
+	pid, err := startProcess(c.Hidra, []string{"run", c.Iface, "--config", n.ConfigFile}, filepath.Join(n.Dir, "hidra.out"))
+	n.HidraPID = pid
+
+	return err
+}
+
+// Detached process writing its output into a file
+func startProcess(bin string, args []string, out string) (int, error) {
+
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout, cmd.Stderr = f, f
+	detach(cmd)
+	if err = cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	// Not waited (children of init once the CLI exits)
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+
+	return pid, nil
+}
+
+// Only processes started for the devnet (their command line includes the mark)
+func isRunning(pid int, mark string) bool {
+
+	if pid == 0 {
+		return false
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false
+	}
+	cmdline, err := p.Cmdline()
+	if err != nil || !strings.Contains(cmdline, mark) {
+		return false
+	}
+
+	// Zombies are not running
+	status, err := p.Status()
+	return err == nil && (len(status) == 0 || status[0] != process.Zombie)
+}
+
+// SIGTERM and SIGKILL after the stop timeout
+func stopProcess(pid int, mark string) error {
+
+	if !isRunning(pid, mark) {
+		return nil
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return err
+	}
+	if err = p.Terminate(); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(stopTimeout)
+	for time.Now().Before(deadline) {
+		if !isRunning(pid, mark) {
+			return nil
+		}
+		time.Sleep(pollInterval)
+	}
+
+	return p.Kill()
+}
+
+// Readiness //
+func waitFor(ctx context.Context, timeout time.Duration, what string, ready func() bool) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for !ready() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w %s", errNotReady, what)
+		case <-time.After(pollInterval):
+		}
+	}
+
+	return nil
+}
+
+// IPC endpoint answering (and connected to a peer if required)
+func gethReady(ctx context.Context, dir string, peers bool) bool {
+
+	ethc, err := ethclient.DialContext(ctx, utils.FormatPath(dir, "geth.ipc"))
+	if err != nil {
+		return false
+	}
+	defer ethc.Close()
+
+	if _, err = ethc.BlockNumber(ctx); err != nil {
+		return false
+	}
+	if peers {
+		count, err := peerCount(ctx, ethc.Client())
+		return err == nil && count > 0
+	}
+
+	return true
+}
+
+func peerCount(ctx context.Context, rc *rpc.Client) (uint64, error) {
+
+	var count string
+	if err := rc.CallContext(ctx, &count, "net_peerCount"); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimPrefix(count, "0x"), 16, 64)
+}
+
+func enode(ctx context.Context, dir string) (string, error) {
+
+	rc, err := rpc.DialContext(ctx, utils.FormatPath(dir, "geth.ipc"))
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var info p2p.NodeInfo
+	if err = rc.CallContext(ctx, &info, "admin_nodeInfo"); err != nil {
+		return "", err
+	}
+
+	return info.Enode, nil
+}
+
+func hasCode(ctx context.Context, dir string, addr common.Address) bool {
+
+	ethc, err := ethclient.DialContext(ctx, utils.FormatPath(dir, "geth.ipc"))
+	if err != nil {
+		return false
+	}
+	defer ethc.Close()
+
+	code, err := ethc.CodeAt(ctx, addr, nil)
+	return err == nil && len(code) > 0
+}
+
+func apiReady(acfg *config.APIConfig) bool {
+
+	cli, err := api.NewClient(&api.Config{Addr: acfg.Addr, Token: acfg.Token, TokenFile: acfg.TokenFile})
+	return err == nil && cli.Ping() == nil
+}
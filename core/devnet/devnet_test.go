@@ -0,0 +1,134 @@
+package devnet
+
+import (
+	"bytes"
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenesis(t *testing.T) {
+
+	accounts := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	g := Genesis(12345, 3, accounts)
+
+	extra := g.ExtraData
+	if len(extra) != 32+20+65 ||
+		!bytes.Equal(extra[32:52], accounts[0].Bytes()) ||
+		g.Config.ChainID.Uint64() != 12345 ||
+		g.Config.Clique.Period != 3 ||
+		len(g.Alloc) != 2 ||
+		g.Alloc[accounts[1]].Balance.Cmp(nodeBalance) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	path := filepath.Join(t.TempDir(), "genesis.json")
+	if err := writeGenesis(path, g); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if b, err := os.ReadFile(path); err != nil || !bytes.Contains(b, []byte(`"period": 3`)) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
+
+func TestNodeConfig(t *testing.T) {
+
+	dir := t.TempDir()
+	ns, err := initNodeDir(dir, 1)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Accounts and passphrases are reused
+	again, err := initNodeDir(dir, 1)
+	if err != nil || again.Address != ns.Address || ns.Name != "N2" || ns.Port != GethBasePort+1 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	base := config.Default()
+	base.Experiment.File = "runs/exp.csv"
+	cfg := nodeConfig(&Config{ChainID: 12345, Base: base}, ns, 1)
+	cfg.Eth.Controller = common.HexToAddress("0x1").String()
+	if err = writeNodeConfig(ns.ConfigFile, cfg); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Valid settings loaded by hidra run (base settings not modified)
+	loaded, err := config.Load(ns.ConfigFile)
+	if err != nil || loaded.Validate() != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if loaded.Eth.NodeDir != ns.Dir ||
+		loaded.Eth.NodePass == "" ||
+		loaded.Metrics.Addr != "localhost:9201" ||
+		loaded.Storage.Addr != "127.0.0.1:9401" ||
+		loaded.API.Addr != "unix:"+filepath.Join(ns.Dir, "hidra-api.sock") ||
+		loaded.Experiment.File != filepath.Join(ns.Dir, "exp.csv") ||
+		base.Metrics.Addr != config.Default().Metrics.Addr {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestProcesses(t *testing.T) {
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "out.log")
+	if err := os.WriteFile(log, nil, 0644); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Long-running process whose command line includes the devnet directory
+	pid, err := startProcess("tail", []string{"-f", log}, filepath.Join(dir, "tail.out"))
+	if err != nil {
+		t.Skip("tail not available:", err)
+	}
+	err = waitFor(context.Background(), time.Second, "tail", func() bool { return isRunning(pid, dir) })
+	if err != nil || isRunning(pid, "other") {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	st := &State{ChainID: 12345, Nodes: []NodeState{{Name: "N1", Dir: dir, GethPID: pid}}}
+	if err = saveState(dir, st); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if loaded, err := LoadState(dir); err != nil || !loaded.running() {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Already running
+	_, err = Up(context.Background(), &Config{Dir: dir, Nodes: 1, Timeout: time.Second})
+	if err != errAlreadyRunning {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	if err = Down(dir, false); err != nil || isRunning(pid, dir) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if loaded, err := LoadState(dir); err != nil || loaded.Nodes[0].GethPID != 0 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	if err = Down(dir, true); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if _, err = os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestUpWithoutGeth(t *testing.T) {
+
+	dir := t.TempDir()
+	c := &Config{Dir: dir, Nodes: 1, ChainID: 12345, Period: 1, Geth: filepath.Join(dir, "missing"), Timeout: time.Second, Base: config.Default()}
+
+	if _, err := Up(context.Background(), c); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "genesis.json")); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
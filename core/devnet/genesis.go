@@ -0,0 +1,63 @@
+package devnet
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"os"
+)
+
+const (
+	cliqueEpoch     = 30000
+	genesisGasLimit = 0xffffffff
+)
+
+// Initial balance of each node account (2^200 wei)
+var nodeBalance = new(big.Int).Lsh(big.NewInt(1), 200)
+
+// Clique chain sealed by the first account (every account is funded)
+func Genesis(chainID, period uint64, accounts []common.Address) *core.Genesis {
+
+	// Vanity (32 bytes) + sealers + signature (65 bytes)
+	extra := make([]byte, 32, 32+common.AddressLength+crypto.SignatureLength)
+	extra = append(extra, accounts[0].Bytes()...)
+	extra = append(extra, make([]byte, crypto.SignatureLength)...)
+
+	alloc := core.GenesisAlloc{}
+	for _, addr := range accounts {
+		alloc[addr] = core.GenesisAccount{Balance: nodeBalance}
+	}
+
+	return &core.Genesis{
+		Config: &params.ChainConfig{
+			ChainID:             new(big.Int).SetUint64(chainID),
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			PetersburgBlock:     big.NewInt(0),
+			IstanbulBlock:       big.NewInt(0),
+			Clique:              &params.CliqueConfig{Period: period, Epoch: cliqueEpoch},
+		},
+		Timestamp:  1,
+		ExtraData:  extra,
+		GasLimit:   genesisGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+}
+
+func writeGenesis(path string, g *core.Genesis) error {
+
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package devnet
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// New session (processes survive the CLI and its terminal)
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
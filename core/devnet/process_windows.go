@@ -0,0 +1,7 @@
+package devnet
+
+import (
+	"os/exec"
+)
+
+func detach(cmd *exec.Cmd) {}
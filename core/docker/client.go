@@ -3,17 +3,21 @@ package docker
 import (
 	"context"
 	"github.com/docker/docker/client"
-	"github.com/swarleynunez/hidra/core/utils"
 )
 
-func Connect(ctx context.Context) (docc *client.Client) {
+func Connect(ctx context.Context) (*client.Client, error) {
 
 	docc, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check connection
 	_, err = docc.Ping(ctx)
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		docc.Close()
+		return nil, err
+	}
 
-	return
+	return docc, nil
 }
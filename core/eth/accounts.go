@@ -4,12 +4,12 @@ import (
 	"errors"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
-	"github.com/swarleynunez/hidra/core/utils"
 )
 
 var (
 	//errNotFoundAddr  = errors.New("ethereum address not found in keystore")
 	ErrMalformedAddr = errors.New("malformed ethereum address")
+	errEmptyKeystore = errors.New("no ethereum accounts found in keystore")
 )
 
 func LoadKeystore(keydir string) (ks *keystore.KeyStore) {
@@ -19,33 +19,37 @@ func LoadKeystore(keydir string) (ks *keystore.KeyStore) {
 	return
 }
 
-func CreateAccount(ks *keystore.KeyStore, passphrase string) (from accounts.Account) {
+func CreateAccount(ks *keystore.KeyStore, passphrase string) (accounts.Account, error) {
 
 	from, err := ks.NewAccount(passphrase)
-	utils.CheckError(err, utils.WarningMode)
-
 	if err == nil {
 		// Save the created address
 		// TODO: test --> utils.SetEnv("NODE_ADDR", from.Address.String())
 	}
 
-	return
+	return from, err
 }
 
-func LoadAccount(ks *keystore.KeyStore, passphrase string) (from accounts.Account) {
+func LoadAccount(ks *keystore.KeyStore, passphrase string) (accounts.Account, error) {
+
+	if len(ks.Accounts()) == 0 {
+		return accounts.Account{}, errEmptyKeystore
+	}
 
 	// Unlock the loaded account
-	from = ks.Accounts()[0]
+	from := ks.Accounts()[0]
 	err := ks.Unlock(from, passphrase)
-	utils.CheckError(err, utils.FatalMode)
 
-	return
+	return from, err
 }
 
-/*func LoadAccount(ks *keystore.KeyStore, addr, passphrase string) (from accounts.Account) {
+/*func LoadAccount(ks *keystore.KeyStore, addr, passphrase string) (from accounts.Account, err error) {
 
 	if len(ks.Accounts()) == 0 {
-		from = CreateAccount(ks, passphrase)
+		from, err = CreateAccount(ks, passphrase)
+		if err != nil {
+			return
+		}
 	} else {
 		if utils.ValidEthAddress(addr) {
 			ksa := ks.Accounts()
@@ -57,16 +61,15 @@ func LoadAccount(ks *keystore.KeyStore, passphrase string) (from accounts.Accoun
 			}
 
 			if from == (accounts.Account{}) {
-				utils.CheckError(errNotFoundAddr, utils.FatalMode)
+				return from, errNotFoundAddr
 			}
 		} else {
-			utils.CheckError(ErrMalformedAddr, utils.FatalMode)
+			return from, ErrMalformedAddr
 		}
 	}
 
 	// Unlock the loaded account
-	err := ks.Unlock(from, passphrase)
-	utils.CheckError(err, utils.FatalMode)
+	err = ks.Unlock(from, passphrase)
 
 	return
 }*/
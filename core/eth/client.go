@@ -2,13 +2,9 @@ package eth
 
 import (
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/swarleynunez/hidra/core/utils"
 )
 
-func Connect(url string) (ethc *ethclient.Client) {
+func Connect(url string) (*ethclient.Client, error) {
 
-	ethc, err := ethclient.Dial(url)
-	utils.CheckError(err, utils.FatalMode)
-
-	return
+	return ethclient.Dial(url)
 }
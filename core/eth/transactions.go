@@ -7,42 +7,44 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/swarleynunez/hidra/core/utils"
 	"math/big"
-	"strconv"
 )
 
-func Transactor(ctx context.Context, ethc *ethclient.Client, ks *keystore.KeyStore, from accounts.Account, gasLimit uint64) *bind.TransactOpts {
-
-	// Get and parse chain ID (transaction replay protection)
-	chainId, err := strconv.ParseUint(utils.GetEnv("CHAIN_ID"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
+// The chain ID is used as transaction replay protection
+func Transactor(ctx context.Context, ethc bind.ContractTransactor, ks *keystore.KeyStore, from accounts.Account, chainId, gasLimit uint64) (*bind.TransactOpts, error) {
 
 	// Auth transactor type
 	auth, err := bind.NewKeyStoreTransactorWithChainID(ks, from, big.NewInt(int64(chainId)))
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set nonce
 	nonce, err := ethc.PendingNonceAt(ctx, from.Address) // Get loaded Ethereum account current nonce
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		return nil, err
+	}
 	auth.Nonce = big.NewInt(int64(nonce))
 
 	// Set gas limit
 	auth.GasLimit = gasLimit
 
-	return auth
+	return auth, nil
 }
 
-func SignedEtherTransaction(ctx context.Context, ethc *ethclient.Client, ks *keystore.KeyStore, from accounts.Account, passphrase string, to common.Address, value int64) *types.Transaction {
+func SignedEtherTransaction(ctx context.Context, ethc bind.ContractTransactor, ks *keystore.KeyStore, from accounts.Account, passphrase string, chainId uint64, to common.Address, value int64) (*types.Transaction, error) {
 
 	// Set nonce
 	nonce, err := ethc.PendingNonceAt(ctx, from.Address) // Get loaded Ethereum account current nonce
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Suggest gas price
 	gasPrice, err := ethc.SuggestGasPrice(ctx)
-	utils.CheckError(err, utils.FatalMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create transaction
 	tx := types.NewTx(&types.LegacyTx{
@@ -53,13 +55,6 @@ func SignedEtherTransaction(ctx context.Context, ethc *ethclient.Client, ks *key
 		Value:    big.NewInt(value),
 	})
 
-	// Get and parse chain ID (transaction replay protection)
-	chainId, err := strconv.ParseUint(utils.GetEnv("CHAIN_ID"), 10, 64)
-	utils.CheckError(err, utils.FatalMode)
-
 	// Sign transaction
-	stx, err := ks.SignTxWithPassphrase(from, passphrase, tx, big.NewInt(int64(chainId)))
-	utils.CheckError(err, utils.FatalMode)
-
-	return stx
+	return ks.SignTxWithPassphrase(from, passphrase, tx, big.NewInt(int64(chainId)))
 }
@@ -0,0 +1,113 @@
+package experiments
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testEvent(eid uint64, start int64) *types.ExperimentEvent {
+
+	return &types.ExperimentEvent{
+		Eid:       eid,
+		Node:      common.HexToAddress("0x1"),
+		Sender:    common.HexToAddress("0x2"),
+		Solver:    common.HexToAddress("0x3"),
+		Replies:   2,
+		Start:     start,
+		RepliesAt: start + 100,
+		VotesAt:   start + 300,
+		End:       start + 600,
+	}
+}
+
+func TestRecorder(t *testing.T) {
+
+	for _, name := range []string{"run.csv", "run.json"} {
+		path := filepath.Join(t.TempDir(), name)
+
+		// Two runs appended to the same file
+		for run := int64(0); run < 2; run++ {
+			r, err := NewRecorder(path)
+			if err != nil {
+				t.Fatal("ERROR:", t.Name(), err)
+			}
+
+			// Only the samples taken while the event was solved
+			r.addSample(sample{at: run*1000 + 50, cpu: 90, rss: 1024})
+			r.addSample(sample{at: run*1000 + 100, cpu: 10, rss: 2048})
+			r.addSample(sample{at: run*1000 + 500, cpu: 20, rss: 4096})
+
+			if err = r.Record(testEvent(uint64(run+1), run*1000+100)); err != nil {
+				t.Fatal("ERROR:", t.Name(), err)
+			}
+			if err = r.Close(); err != nil {
+				t.Fatal("ERROR:", t.Name(), err)
+			}
+		}
+
+		evs, err := Load(path)
+		if err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+		if len(evs) != 2 ||
+			evs[1].Eid != 2 ||
+			evs[1].Start != 1100 ||
+			evs[1].Solver != common.HexToAddress("0x3") ||
+			evs[1].Samples != 2 ||
+			evs[1].CpuUsage != 15 ||
+			evs[1].MemRSS != 3072 {
+			t.Fatal("ERROR:", t.Name(), name, evs)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"run.txt":  "",
+		"bad.csv":  "eid,node\n1,0x1\n",
+		"bad.json": "{\"eid\": \"x\"}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatal("ERROR:", t.Name(), name)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestSummarize(t *testing.T) {
+
+	// Latencies from 100 to 2000 ms
+	var evs []types.ExperimentEvent
+	for i := int64(1); i <= 20; i++ {
+		ev := testEvent(uint64(i), 1000)
+		ev.End = ev.Start + i*100
+		ev.VotesAt = 0 // Phase not seen
+		evs = append(evs, *ev)
+	}
+
+	sums := map[string]types.ExperimentSummary{}
+	for _, s := range Summarize(evs) {
+		sums[s.Metric] = s
+	}
+
+	lat := sums["latency_ms"]
+	if lat.Count != 20 || lat.Mean != 1050 || lat.P50 != 1000 || lat.P95 != 1900 || lat.Min != 100 || lat.Max != 2000 {
+		t.Fatal("ERROR:", t.Name(), lat)
+	}
+	if sums["votes_ms"].Count != 0 || sums["solve_ms"].Count != 0 || sums["replies_ms"].Mean != 100 || sums["cpu_percent"].Count != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -0,0 +1,245 @@
+package experiments
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/swarleynunez/hidra/core/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Process samples older than this are dropped (longest expected event)
+const maxSampleAge = 10 * time.Minute
+
+var (
+	errUnknownFormat = errors.New("unknown experiment file format (.csv or .json)")
+
+	// CSV columns
+	header = []string{"eid", "node", "sender", "solver", "rcid", "replies", "start", "replies_at", "votes_at", "end", "cpu_percent", "mem_rss", "samples"}
+)
+
+// Process usage at a given time
+type sample struct {
+	at  int64 // Unix time in ms
+	cpu float64
+	rss uint64
+}
+
+// Append-only experiment file (CSV with header or JSON lines)
+type Recorder struct {
+	mutex   sync.Mutex
+	file    *os.File
+	csvw    *csv.Writer // Nil for JSON files
+	samples []sample
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+
+	format, err := fileFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{file: f}
+
+	if format == "csv" {
+		r.csvw = csv.NewWriter(f)
+
+		// New files start with the header
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if fi.Size() == 0 {
+			if err = r.writeCSV(header); err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Blocking process sampler (CPU and resident memory of this process)
+func (r *Recorder) Sample(ctx context.Context, interval time.Duration) error {
+
+	p, err := process.NewProcessWithContext(ctx, int32(os.Getpid()))
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			cpu, err := p.PercentWithContext(ctx, 0) // Since the last call
+			if err != nil {
+				return err
+			}
+			mem, err := p.MemoryInfoWithContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			r.addSample(sample{at: now.UnixMilli(), cpu: cpu, rss: mem.RSS})
+		}
+	}
+}
+
+func (r *Recorder) addSample(s sample) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Drop old samples
+	var cut int
+	for cut < len(r.samples) && s.at-r.samples[cut].at > maxSampleAge.Milliseconds() {
+		cut++
+	}
+	r.samples = append(r.samples[cut:], s)
+}
+
+// Write a solved event with the mean process usage between its start and end
+func (r *Recorder) Record(ev *types.ExperimentEvent) error {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var cpu float64
+	var rss uint64
+	ev.Samples = 0
+	for _, s := range r.samples {
+		if s.at >= ev.Start && s.at <= ev.End {
+			cpu += s.cpu
+			rss += s.rss
+			ev.Samples++
+		}
+	}
+	if ev.Samples > 0 {
+		ev.CpuUsage = cpu / float64(ev.Samples)
+		ev.MemRSS = rss / uint64(ev.Samples)
+	}
+
+	if r.csvw != nil {
+		return r.writeCSV(toRow(ev))
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Write(append(b, '\n'))
+
+	return err
+}
+
+func (r *Recorder) Close() error {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.file.Close()
+}
+
+// Every record is flushed (the daemon may be killed at any time)
+func (r *Recorder) writeCSV(row []string) error {
+
+	if err := r.csvw.Write(row); err != nil {
+		return err
+	}
+	r.csvw.Flush()
+
+	return r.csvw.Error()
+}
+
+// Loading //
+// Records of one or several runs
+func Load(paths ...string) (evs []types.ExperimentEvent, err error) {
+
+	for _, path := range paths {
+		var fevs []types.ExperimentEvent
+		fevs, err = loadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		evs = append(evs, fevs...)
+	}
+
+	return evs, nil
+}
+
+func loadFile(path string) (evs []types.ExperimentEvent, err error) {
+
+	format, err := fileFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "json" {
+		dec := json.NewDecoder(f)
+		for {
+			var ev types.ExperimentEvent
+			if err = dec.Decode(&ev); err == io.EOF {
+				return evs, nil
+			} else if err != nil {
+				return nil, err
+			}
+			evs = append(evs, ev)
+		}
+	}
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		// Header
+		if i == 0 && len(row) > 0 && row[0] == header[0] {
+			continue
+		}
+
+		ev, err := fromRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		evs = append(evs, *ev)
+	}
+
+	return evs, nil
+}
+
+func fileFormat(path string) (string, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv", nil
+	case ".json":
+		return "json", nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnknownFormat, path)
+}
@@ -0,0 +1,140 @@
+package experiments
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+	"math"
+	"sort"
+	"strconv"
+)
+
+var (
+	errMalformedRow = errors.New("malformed experiment record")
+)
+
+// Metric taken from an experiment record (false if it was not measured)
+type metric struct {
+	name  string
+	value func(ev *types.ExperimentEvent) (float64, bool)
+}
+
+// Reported metrics (phase times in ms)
+var metrics = []metric{
+	{"replies_ms", func(ev *types.ExperimentEvent) (float64, bool) { return phase(ev.Start, ev.RepliesAt) }},
+	{"votes_ms", func(ev *types.ExperimentEvent) (float64, bool) { return phase(ev.RepliesAt, ev.VotesAt) }},
+	{"solve_ms", func(ev *types.ExperimentEvent) (float64, bool) { return phase(ev.VotesAt, ev.End) }},
+	{"latency_ms", func(ev *types.ExperimentEvent) (float64, bool) { return phase(ev.Start, ev.End) }},
+	{"replies", func(ev *types.ExperimentEvent) (float64, bool) { return float64(ev.Replies), true }},
+	{"cpu_percent", func(ev *types.ExperimentEvent) (float64, bool) { return ev.CpuUsage, ev.Samples > 0 }},
+	{"mem_rss_mb", func(ev *types.ExperimentEvent) (float64, bool) {
+		return float64(ev.MemRSS) / (1024 * 1024), ev.Samples > 0
+	}},
+}
+
+// Mean, median and 95th percentile of every metric
+func Summarize(evs []types.ExperimentEvent) (sums []types.ExperimentSummary) {
+
+	for _, m := range metrics {
+		var values []float64
+		for i := range evs {
+			if v, ok := m.value(&evs[i]); ok {
+				values = append(values, v)
+			}
+		}
+
+		sum := types.ExperimentSummary{Metric: m.name, Count: len(values)}
+		if len(values) > 0 {
+			sort.Float64s(values)
+
+			var total float64
+			for _, v := range values {
+				total += v
+			}
+			sum.Mean = total / float64(len(values))
+			sum.P50 = percentile(values, 0.50)
+			sum.P95 = percentile(values, 0.95)
+			sum.Min, sum.Max = values[0], values[len(values)-1]
+		}
+		sums = append(sums, sum)
+	}
+
+	return
+}
+
+// Nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+
+	return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+}
+
+// Phases not seen (or seen out of order) are skipped
+func phase(from, to int64) (float64, bool) {
+
+	if from == 0 || to < from {
+		return 0, false
+	}
+
+	return float64(to - from), true
+}
+
+// CSV rows //
+func toRow(ev *types.ExperimentEvent) []string {
+
+	return []string{
+		strconv.FormatUint(ev.Eid, 10),
+		ev.Node.String(),
+		ev.Sender.String(),
+		ev.Solver.String(),
+		strconv.FormatUint(ev.Rcid, 10),
+		strconv.Itoa(ev.Replies),
+		strconv.FormatInt(ev.Start, 10),
+		strconv.FormatInt(ev.RepliesAt, 10),
+		strconv.FormatInt(ev.VotesAt, 10),
+		strconv.FormatInt(ev.End, 10),
+		strconv.FormatFloat(ev.CpuUsage, 'f', 2, 64),
+		strconv.FormatUint(ev.MemRSS, 10),
+		strconv.Itoa(ev.Samples),
+	}
+}
+
+func fromRow(row []string) (ev *types.ExperimentEvent, err error) {
+
+	if len(row) != len(header) {
+		return nil, errMalformedRow
+	}
+	for _, addr := range row[1:4] {
+		if !common.IsHexAddress(addr) {
+			return nil, errMalformedRow
+		}
+	}
+
+	ev = &types.ExperimentEvent{
+		Node:   common.HexToAddress(row[1]),
+		Sender: common.HexToAddress(row[2]),
+		Solver: common.HexToAddress(row[3]),
+	}
+	uints := []*uint64{&ev.Eid, &ev.Rcid, &ev.MemRSS}
+	for i, col := range []int{0, 4, 11} {
+		if *uints[i], err = strconv.ParseUint(row[col], 10, 64); err != nil {
+			return nil, errMalformedRow
+		}
+	}
+	times := []*int64{&ev.Start, &ev.RepliesAt, &ev.VotesAt, &ev.End}
+	for i, col := range []int{6, 7, 8, 9} {
+		if *times[i], err = strconv.ParseInt(row[col], 10, 64); err != nil {
+			return nil, errMalformedRow
+		}
+	}
+	if ev.Replies, err = strconv.Atoi(row[5]); err != nil {
+		return nil, errMalformedRow
+	}
+	if ev.CpuUsage, err = strconv.ParseFloat(row[10], 64); err != nil {
+		return nil, errMalformedRow
+	}
+	if ev.Samples, err = strconv.Atoi(row[12]); err != nil {
+		return nil, errMalformedRow
+	}
+
+	return ev, nil
+}
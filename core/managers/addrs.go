@@ -0,0 +1,163 @@
+package managers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/swarleynunez/hidra/core/types"
+	"net"
+	"slices"
+	"strings"
+)
+
+var (
+	errNoInterfaceAddrs = errors.New("interface without usable addresses")
+
+	// Container, VM and overlay network interfaces (not reachable by peers)
+	virtualIfacePrefixes = []string{"docker", "br-", "veth", "virbr", "vnet", "lxcbr", "lxdbr", "cni", "flannel", "cali", "podman", "vmnet"}
+)
+
+// Advertised addresses (explicit IPs or interface names, comma-separated).
+// If empty, the global unicast addresses of the physical interfaces that are up (no outbound traffic)
+func ResolveAddrs(spec string) ([]net.IP, error) {
+
+	var addrs []net.IP
+	if strings.TrimSpace(spec) == "" {
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || IsVirtualInterface(iface.Name) {
+				continue
+			}
+			ips, err := interfaceIPs(&iface)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if ip.IsGlobalUnicast() {
+					addrs = appendIP(addrs, ip)
+				}
+			}
+		}
+
+		// Offline hosts
+		if len(addrs) == 0 {
+			addrs = append(addrs, net.IPv4(127, 0, 0, 1).To4())
+		}
+	} else {
+		for _, a := range strings.Split(spec, ",") {
+			a = strings.TrimSpace(a)
+			if ip := net.ParseIP(a); ip != nil {
+				addrs = appendIP(addrs, ip)
+				continue
+			}
+
+			iface, err := net.InterfaceByName(a)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a, err)
+			}
+			ips, err := interfaceIPs(iface)
+			if err != nil {
+				return nil, err
+			}
+			if len(ips) == 0 {
+				return nil, fmt.Errorf("%s: %w", a, errNoInterfaceAddrs)
+			}
+			for _, ip := range ips {
+				addrs = appendIP(addrs, ip)
+			}
+		}
+	}
+
+	// IPv4 addresses first (the primary address is the first one)
+	var v4, v6 []net.IP
+	for _, ip := range addrs {
+		if ip4 := ip.To4(); ip4 != nil {
+			v4 = append(v4, ip4)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	return append(v4, v6...), nil
+}
+
+// Hardware specs, advertised addresses and location of the node
+func (n *Node) localSpecs() (*types.NodeSpecs, error) {
+
+	addrs, err := ResolveAddrs(n.cfg.Node.AdvertiseAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := GetSpecs()
+	ns.IP, ns.Addrs = addrs[0], addrs
+	ns.Agg = n.cfg.Network.AggregationSpec()
+	if n.cfg.Node.Location != "" {
+		ns.Location = &types.NodeLocation{}
+		if err = ns.Location.UnmarshalText([]byte(n.cfg.Node.Location)); err != nil {
+			return nil, err
+		}
+	}
+
+	return ns, nil
+}
+
+// Helpers //
+// Unicast addresses of an interface (link-local ones are not reachable by peers)
+func interfaceIPs(iface *net.Interface) (ips []net.IP, err error) {
+
+	ifaddrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range ifaddrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLinkLocalUnicast() || ipnet.IP.IsMulticast() || ipnet.IP.IsUnspecified() {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+
+	return
+}
+
+// Bridges and veth pairs created by Docker, libvirt and CNI plugins (explicit names are not filtered)
+func IsVirtualInterface(name string) bool {
+
+	for _, prefix := range virtualIfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Append without duplicates
+func appendIP(ips []net.IP, add ...net.IP) []net.IP {
+
+	for _, ip := range add {
+		if !slices.ContainsFunc(ips, ip.Equal) {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+func sameIPs(a, b []net.IP) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package managers_test
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestResolveAddrs(t *testing.T) {
+
+	// Explicit IPs (IPv4 first, without duplicates)
+	addrs, err := managers.ResolveAddrs("10.0.0.2, ::1,10.0.0.1,10.0.0.2")
+	if err != nil || len(addrs) != 3 ||
+		!addrs[0].Equal(net.ParseIP("10.0.0.2")) || !addrs[1].Equal(net.ParseIP("10.0.0.1")) || !addrs[2].Equal(net.IPv6loopback) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Interface names
+	addrs, err = managers.ResolveAddrs("lo")
+	if err != nil || !addrs[0].Equal(net.ParseIP("127.0.0.1")) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if _, err = managers.ResolveAddrs("hidra-unknown0"); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Auto-detected (never empty)
+	if addrs, err = managers.ResolveAddrs(""); err != nil || len(addrs) == 0 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
+
+func TestVirtualInterfaces(t *testing.T) {
+
+	for _, name := range []string{"docker0", "br-3f1c2a", "veth9a8b7c", "virbr0", "cni0"} {
+		if !managers.IsVirtualInterface(name) {
+			t.Fatal("ERROR:", t.Name(), name)
+		}
+	}
+	for _, name := range []string{"eth0", "enp3s0", "wlan0", "bond0"} {
+		if managers.IsVirtualInterface(name) {
+			t.Fatal("ERROR:", t.Name(), name)
+		}
+	}
+}
+
+func TestGetNodeAddressFromIP(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	port := func(i int) string { return strconv.Itoa(simnet.BasePort + i) }
+
+	// Emulated nodes: same IP, told apart by their port
+	if c.Nodes[0].GetNodeAddressFromIP("127.0.0.1", port(2)) != c.Nodes[2].GetFromAccount() ||
+		c.Nodes[0].GetNodeAddressFromIP("127.0.0.1", "55555") != (common.Address{}) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Multi-homed node: any of its addresses from any port
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(c.Nodes[3].GetNodeSpecs(c.Nodes[3].GetFromAccount()), &specs)
+	specs.Addrs = append(specs.Addrs, net.ParseIP("10.0.0.3"), net.ParseIP("fd00::3"))
+	if err := c.Nodes[3].UpdateSpecs(ctx, &specs); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+
+	naddr := c.Nodes[3].GetFromAccount()
+	if c.Nodes[0].GetNodeAddressFromIP("10.0.0.3", "55555") != naddr ||
+		c.Nodes[0].GetNodeAddressFromIP("fd00::3", "55555") != naddr ||
+		c.Nodes[0].GetNodeAddressFromIP("10.0.0.4", port(3)) != (common.Address{}) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -3,7 +3,6 @@ package managers
 import (
 	"context"
 	"errors"
-	"fmt"
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
@@ -12,9 +11,9 @@ import (
 	"github.com/swarleynunez/hidra/core/utils"
 	"io"
 	"io/ioutil"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 )
 
 const (
@@ -26,15 +25,15 @@ var (
 )
 
 // Images //
-func existImageLocally(ctx context.Context, imgTag string) bool {
+func (n *Node) existImageLocally(ctx context.Context, imgTag string) bool {
 
 	// Check and format tag
 	imgTag, err := utils.FormatImageTag(imgTag)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Get all local images
-	images, err := _docc.ImageList(ctx, dockertypes.ImageListOptions{All: true})
-	utils.CheckError(err, utils.WarningMode)
+	images, err := n.runtime.ImageList(ctx, dockertypes.ImageListOptions{All: true})
+	utils.LogWarning(err)
 
 	// Search image by tag
 	for i := range images {
@@ -48,35 +47,42 @@ func existImageLocally(ctx context.Context, imgTag string) bool {
 	return false
 }
 
-func pullImage(ctx context.Context, imgTag string) {
+func (n *Node) pullImage(ctx context.Context, imgTag string) {
 
 	// Debug
-	fmt.Print("[", time.Now().UnixMilli(), "] ", "Downloading '"+imgTag+"' image...\n")
+	utils.Info("Downloading image", "image", imgTag)
 
-	out, err := _docc.ImagePull(ctx, imgTag, dockertypes.ImagePullOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	out, err := n.runtime.ImagePull(ctx, imgTag, dockertypes.ImagePullOptions{})
+	utils.LogWarning(err)
 	_, err = io.Copy(ioutil.Discard, out) // Discard output to /dev/null
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 }
 
-func pruneImages(ctx context.Context) {
+func (n *Node) pruneImages(ctx context.Context) {
 
-	_, err := _docc.ImagesPrune(ctx, filters.Args{})
-	utils.CheckError(err, utils.WarningMode)
+	_, err := n.runtime.ImagesPrune(ctx, filters.Args{})
+	utils.LogWarning(err)
 }
 
 // Containers //
-func createDockerContainer(ctx context.Context, cinfo *types.ContainerInfo, cname string) {
+func (n *Node) createDockerContainer(ctx context.Context, cinfo *types.ContainerInfo, cname string) {
 
 	// Check and format image tag
 	imgTag, err := utils.FormatImageTag(cinfo.ImageTag)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
-	if !existImageLocally(ctx, imgTag) {
-		pullImage(ctx, imgTag)
+	if !n.existImageLocally(ctx, imgTag) {
+		n.pullImage(ctx, imgTag)
 	}
 
-	ports := checkNodePorts(ctx, cinfo.Ports)
+	// Artifacts fetched from the cluster (not created without them)
+	binds, err := n.materializeArtifacts(ctx, cinfo, cname)
+	if err != nil {
+		utils.LogWarning(err)
+		return
+	}
+
+	ports := n.checkNodePorts(ctx, cinfo.Ports)
 
 	// Set container configs
 	ctrConfig := &container.Config{
@@ -84,7 +90,7 @@ func createDockerContainer(ctx context.Context, cinfo *types.ContainerInfo, cnam
 		Image: imgTag,
 	}
 	hostConfig := &container.HostConfig{
-		Binds:        cinfo.Volumes,
+		Binds:        append(append([]string(nil), cinfo.Volumes...), binds...),
 		PortBindings: ports,
 		Resources: container.Resources{
 			NanoCPUs: int64(cinfo.CpuLimit),
@@ -93,43 +99,43 @@ func createDockerContainer(ctx context.Context, cinfo *types.ContainerInfo, cnam
 	}
 	netConfig := &network.NetworkingConfig{}
 
-	_, err = _docc.ContainerCreate(ctx, ctrConfig, hostConfig, netConfig, nil, cname)
-	utils.CheckError(err, utils.WarningMode)
+	_, err = n.runtime.ContainerCreate(ctx, ctrConfig, hostConfig, netConfig, nil, cname)
+	utils.LogWarning(err)
 }
 
-func startDockerContainer(ctx context.Context, cname string) {
+func (n *Node) startDockerContainer(ctx context.Context, cname string) {
 
-	err := _docc.ContainerStart(ctx, cname, dockertypes.ContainerStartOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	err := n.runtime.ContainerStart(ctx, cname, dockertypes.ContainerStartOptions{})
+	utils.LogWarning(err)
 }
 
-func restartDockerContainer(ctx context.Context, cname string) {
+func (n *Node) restartDockerContainer(ctx context.Context, cname string) {
 
 	// Stop and start container
-	err := _docc.ContainerRestart(ctx, cname, container.StopOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	err := n.runtime.ContainerRestart(ctx, cname, container.StopOptions{})
+	utils.LogWarning(err)
 }
 
-func renameDockerContainer(ctx context.Context, cname, new string) {
+func (n *Node) renameDockerContainer(ctx context.Context, cname, new string) {
 
-	err := _docc.ContainerRename(ctx, cname, new)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.runtime.ContainerRename(ctx, cname, new)
+	utils.LogWarning(err)
 }
 
-func stopDockerContainer(ctx context.Context, cname string) {
+func (n *Node) stopDockerContainer(ctx context.Context, cname string) {
 
 	// SIGTERM instead of SIGKILL
-	err := _docc.ContainerStop(ctx, cname, container.StopOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	err := n.runtime.ContainerStop(ctx, cname, container.StopOptions{})
+	utils.LogWarning(err)
 }
 
-func removeDockerContainer(ctx context.Context, cname string) {
+func (n *Node) removeDockerContainer(ctx context.Context, cname string) {
 
-	err := _docc.ContainerRemove(ctx, cname, dockertypes.ContainerRemoveOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	err := n.runtime.ContainerRemove(ctx, cname, dockertypes.ContainerRemoveOptions{})
+	utils.LogWarning(err)
 }
 
-/*func BackupContainer() {
+/*func (n *Node) BackupContainer() {
 
 	// TODO. Backup tasks. Improve flow
 	// commit --> create an image from a container (snapshot preserving rw)
@@ -138,15 +144,22 @@ func removeDockerContainer(ctx context.Context, cname string) {
 }*/
 
 // all: only running containers (false) or all containers (true)
-func SearchDockerContainers(ctx context.Context, key, value string, all bool) []dockertypes.Container {
+func (n *Node) SearchDockerContainers(ctx context.Context, key, value string, all bool) []dockertypes.Container {
 
 	filter := filters.Args{}
 	if key != "" && value != "" {
 		filter = filters.NewArgs(filters.KeyValuePair{Key: key, Value: value})
 	}
 
-	ctrs, err := _docc.ContainerList(ctx, dockertypes.ContainerListOptions{Size: true, All: all, Filters: filter})
-	utils.CheckError(err, utils.WarningMode)
+	ctrs, err := n.runtime.ContainerList(ctx, dockertypes.ContainerListOptions{Size: true, All: all, Filters: filter})
+	utils.LogWarning(err)
+
+	// Docker matches names by substring (e.g. "ctr1" also matches "ctr10")
+	if key == "name" && value != "" {
+		ctrs = slices.DeleteFunc(ctrs, func(c dockertypes.Container) bool {
+			return !slices.Contains(c.Names, "/"+value)
+		})
+	}
 
 	if len(ctrs) > 0 {
 		return ctrs
@@ -156,13 +169,17 @@ func SearchDockerContainers(ctx context.Context, key, value string, all bool) []
 }
 
 // Volumes //
-func pruneVolumes(ctx context.Context) {
+func (n *Node) pruneVolumes(ctx context.Context) {
 
-	_, err := _docc.VolumesPrune(ctx, filters.Args{})
-	utils.CheckError(err, utils.WarningMode)
+	_, err := n.runtime.VolumesPrune(ctx, filters.Args{})
+	utils.LogWarning(err)
 }
 
 // Helpers //
+func (n *Node) IsDockerConnected() bool {
+	return n.runtime != nil
+}
+
 // Format cname from a rcid
 func GetContainerName(rcid uint64) string {
 	return cnameTemplate + strconv.FormatUint(rcid, 10)
@@ -175,15 +192,15 @@ func getRegContainerId(cname string) uint64 {
 	s := strings.Replace(cname, cnameTemplate, "", -1)
 
 	rcid, err := strconv.ParseUint(s, 10, 64)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	return rcid
 }
 
 // Check if a port is already allocated by docker
-func isPortAllocatedByDocker(ctx context.Context, port string) bool {
+func (n *Node) isPortAllocatedByDocker(ctx context.Context, port string) bool {
 
-	ctrs := SearchDockerContainers(ctx, "", "", true)
+	ctrs := n.SearchDockerContainers(ctx, "", "", true)
 	if ctrs != nil {
 		for _, ctr := range ctrs {
 			for _, p := range ctr.Ports {
@@ -200,9 +217,9 @@ func isPortAllocatedByDocker(ctx context.Context, port string) bool {
 }
 
 // Get mapped port information of a container
-func getContainerPortInfo(ctx context.Context, cname string) (*dockertypes.Port, error) {
+func (n *Node) getContainerPortInfo(ctx context.Context, cname string) (*dockertypes.Port, error) {
 
-	c := SearchDockerContainers(ctx, "name", cname, false)
+	c := n.SearchDockerContainers(ctx, "name", cname, false)
 	if c != nil {
 		// TODO: just looking for the first mapped port...
 		return &c[0].Ports[0], nil
@@ -1 +1,96 @@
 package managers
+
+import (
+	"context"
+	dockertypes "github.com/docker/docker/api/types"
+	"strings"
+	"testing"
+)
+
+// Container runtime fake (unused methods panic)
+type fakeRuntime struct {
+	ContainerRuntime
+	ctrs    map[string]bool // Container name --> running
+	started []string
+}
+
+func (fr *fakeRuntime) ContainerList(_ context.Context, opts dockertypes.ContainerListOptions) (ctrs []dockertypes.Container, err error) {
+
+	name := opts.Filters.Get("name")
+	for cname, running := range fr.ctrs {
+		if (len(name) == 0 || strings.Contains(cname, name[0])) && (running || opts.All) {
+			ctrs = append(ctrs, dockertypes.Container{ID: cname, Names: []string{"/" + cname}})
+		}
+	}
+
+	return
+}
+
+func (fr *fakeRuntime) ContainerStart(_ context.Context, cname string, _ dockertypes.ContainerStartOptions) error {
+
+	fr.ctrs[cname] = true
+	fr.started = append(fr.started, cname)
+
+	return nil
+}
+
+func TestStartContainer(t *testing.T) {
+
+	// Two logical nodes in the same process
+	fr1 := &fakeRuntime{ctrs: map[string]bool{GetContainerName(1): false}}
+	fr2 := &fakeRuntime{ctrs: map[string]bool{GetContainerName(1): true}}
+	n1, n2 := &Node{runtime: fr1}, &Node{runtime: fr2}
+
+	n1.StartContainer(context.Background(), GetContainerName(1))
+	n2.StartContainer(context.Background(), GetContainerName(1))
+	n2.StartContainer(context.Background(), GetContainerName(2))
+
+	if len(fr1.started) != 1 || !fr1.ctrs[GetContainerName(1)] || len(fr2.started) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestDockerNotConnected(t *testing.T) {
+
+	n := &Node{}
+	if n.IsDockerConnected() {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if _, err := n.GetContainerState(context.Background(), 1); err != errDockerNotConnected {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestSearchContainerName(t *testing.T) {
+
+	fr := &fakeRuntime{ctrs: map[string]bool{GetContainerName(1): true, GetContainerName(10): true}}
+	n := &Node{runtime: fr}
+
+	ctrs := n.SearchDockerContainers(context.Background(), "name", GetContainerName(1), false)
+	if len(ctrs) != 1 || ctrs[0].ID != GetContainerName(1) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestContainerCpuUsage(t *testing.T) {
+
+	n := &Node{}
+	sample := func(ctr, sys uint64) *dockertypes.StatsJSON {
+		var stats dockertypes.StatsJSON
+		stats.CPUStats.CPUUsage.TotalUsage = ctr
+		stats.CPUStats.SystemUsage = sys
+		stats.CPUStats.OnlineCPUs = 2
+		return &stats
+	}
+
+	// First one-shot read has no previous sample
+	if n.cpuUsage(1, sample(1000, 10000)) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Delta against the previous read: (500 / 1000) * 2 cores
+	if n.cpuUsage(1, sample(1500, 11000)) != 100 || n.cpuUsage(2, sample(1500, 11000)) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
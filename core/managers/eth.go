@@ -7,6 +7,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/swarleynunez/hidra/core/bindings"
 	"github.com/swarleynunez/hidra/core/eth"
+	"github.com/swarleynunez/hidra/core/metrics"
+	"github.com/swarleynunez/hidra/core/storage"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
 )
@@ -18,87 +20,111 @@ type RepAction struct {
 }*/
 
 // Instances //
-func controllerInstance() (cinst *bindings.Controller) {
+func controllerInstance(caddr string, chain ChainClient) (*bindings.Controller, error) {
 
 	// Controller smart contract address
-	caddr := utils.GetEnv("CONTROLLER_ADDR")
-
-	if utils.ValidEthAddress(caddr) {
-		inst, err := bindings.NewController(common.HexToAddress(caddr), _ethc)
-		utils.CheckError(err, utils.FatalMode)
-		cinst = inst
-	} else {
-		utils.CheckError(eth.ErrMalformedAddr, utils.FatalMode)
+	if !utils.ValidEthAddress(caddr) {
+		return nil, eth.ErrMalformedAddr
 	}
 
-	return
+	return bindings.NewController(common.HexToAddress(caddr), chain)
 }
 
-/*func faucetInstance(faddr common.Address) (finst *bindings.Faucet) {
+/*func (n *Node) faucetInstance(faddr common.Address) (finst *bindings.Faucet) {
 
-	finst, err := bindings.NewFaucet(faddr, _ethc)
-	utils.CheckError(err, utils.FatalMode)
+	finst, err := bindings.NewFaucet(faddr, n.chain)
+	utils.LogWarning(err)
 
 	return
 }*/
 
-func nodeInstance(naddr common.Address) (ninst *bindings.Node) {
+func (n *Node) nodeInstance(naddr common.Address) (ninst *bindings.Node) {
 
-	ninst, err := bindings.NewNode(naddr, _ethc)
-	utils.CheckError(err, utils.WarningMode)
+	ninst, err := bindings.NewNode(naddr, n.chain)
+	utils.LogWarning(err)
 
 	return
 }
 
 // Setters //
-func DeployController(ctx context.Context) common.Address {
+func (n *Node) DeployController(ctx context.Context) (common.Address, error) {
 
-	for {
-		// Create and configure a transactor
-		auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000000)
-
-		// Create smart contract
-		caddr, _, _, err := bindings.DeployController(auth, _ethc)
+	// Create and configure a transactor
+	auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000000)
+	if err != nil {
+		return common.Address{}, err
+	}
 
-		if err == nil {
-			return caddr
-		} else {
-			utils.CheckError(err, utils.FatalMode)
-		}
+	// Create smart contract (also loaded by this node)
+	caddr, _, cinst, err := bindings.DeployController(auth, n.chain)
+	if err != nil {
+		return common.Address{}, err
 	}
+	n.cinst = cinst
+
+	return caddr, nil
 }
 
-func RegisterNode(ctx context.Context, port uint16) {
+func (n *Node) RegisterNode(ctx context.Context, port uint16) error {
 
 	// Txn data encoding
-	ns := GetSpecs()
+	ns, err := n.localSpecs()
+	if err != nil {
+		return err
+	}
 	ns.Port = port
+	ns.BlobPort = blobPort(n.cfg.Storage.Addr)
 	specs := utils.MarshalJSON(ns)
 
-	for {
-		// Create and configure a transactor
-		auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000001)
+	// Create and configure a transactor
+	auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000001)
+	if err != nil {
+		return err
+	}
 
-		// Send transaction
-		_, err := _cinst.RegisterNode(auth, specs)
+	// Send transaction
+	_, err = n.cinst.RegisterNode(auth, specs)
 
-		if err == nil {
-			return
-		} else {
-			utils.CheckError(err, utils.FatalMode)
-		}
+	return err
+}
+
+// Only the node can update its own specs
+func (n *Node) UpdateSpecs(ctx context.Context, ns *types.NodeSpecs) error {
+
+	// Txn data encoding
+	specs := utils.MarshalJSON(ns)
+
+	// Create and configure a transactor
+	auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000012)
+	if err != nil {
+		return err
 	}
+
+	// Send transaction
+	_, err = n.nodeInstance(n.getNodeContract(n.from.Address)).UpdateSpecs(auth, specs)
+
+	return err
 }
 
 // Reputable functions //
-func SendEvent(ctx context.Context, etype *types.EventType, rcid uint64) error {
+func (n *Node) SendEvent(ctx context.Context, etype *types.EventType, rcid uint64) error {
 
 	// Checking zone
 	if rcid > 0 { // Has the event a linked container?
-		if !existContainer(rcid) ||
-			(!isApplicationOwner(GetContainer(rcid).Appid, _from.Address) && !IsContainerHost(rcid, _from.Address)) ||
-			isContainerAutodeployed(rcid) ||
-			isContainerUnregistered(rcid) {
+		if !n.existContainer(rcid) ||
+			(!n.isApplicationOwner(n.GetContainer(rcid).Appid, n.from.Address) && !n.IsContainerHost(rcid, n.from.Address)) ||
+			n.isContainerAutodeployed(rcid) ||
+			n.isContainerUnregistered(rcid) {
+			return errors.New(SendEventAction + ": transaction not sent")
+		}
+	}
+	switch etype.RequiredTask { // Blobs to create or update are replicated from the sender
+	case types.CreateTask, types.UpdateTask:
+		if n.blobs == nil || !n.blobs.Has(etype.Blob) {
+			return errors.New(SendEventAction + ": transaction not sent")
+		}
+	case types.ReadTask, types.DeleteTask:
+		if !storage.ValidCID(etype.Blob) {
 			return errors.New(SendEventAction + ": transaction not sent")
 		}
 	}
@@ -108,31 +134,36 @@ func SendEvent(ctx context.Context, etype *types.EventType, rcid uint64) error {
 
 	for {
 		// Create and configure a transactor
-		auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000002)
+		auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000002)
+		if err != nil {
+			return err
+		}
 
 		// Send transaction
-		_, err := _cinst.SendEvent(auth, et, rcid)
+		_, err = n.cinst.SendEvent(auth, et, rcid)
+		metrics.Transaction(SendEventAction, err)
 
 		if err != nil {
-			//utils.CheckError(err, utils.WarningMode)
+			//utils.LogWarning(err)
 			continue
 		}
 		return err
 	}
 }
 
-func SendReply(ctx context.Context, eid uint64, repScores []bindings.DELReputationScore) error {
+func (n *Node) SendReply(ctx context.Context, eid uint64, repScores []bindings.DELReputationScore) error {
 
 	// Checking zone
-	if existEvent(eid) &&
-		!isEventSolved(eid) &&
-		!GetEvent(eid).HasRequiredReplies &&
-		!HasRequiredCount(GetClusterConfig().NodesTh, uint64(GetEventReplyCount(eid))) &&
-		!hasAlreadyReplied(eid, _from.Address) {
+	if n.existEvent(eid) &&
+		!n.isEventSolved(eid) &&
+		!n.isEventRetried(eid) &&
+		!n.GetEvent(eid).HasRequiredReplies &&
+		!n.HasRequiredCount(n.GetClusterConfig().NodesTh, uint64(n.GetEventReplyCount(eid))) &&
+		!n.hasAlreadyReplied(eid, n.from.Address) {
 		reputedNodes := make(map[common.Address]bool)
 		for _, v := range repScores {
-			if v.Node == GetFromAccount() ||
-				!IsNodeRegistered(v.Node) ||
+			if v.Node == n.GetFromAccount() ||
+				!n.IsNodeRegistered(v.Node) ||
 				reputedNodes[v.Node] {
 				return errors.New(SendEventAction + ": transaction not sent")
 			}
@@ -141,13 +172,17 @@ func SendReply(ctx context.Context, eid uint64, repScores []bindings.DELReputati
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000003)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000003)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.SendReply(auth, eid, repScores)
+			_, err = n.cinst.SendReply(auth, eid, repScores)
+			metrics.Transaction(SendReplyAction, err)
 
 			if err != nil {
-				//utils.CheckError(err, utils.WarningMode)
+				//utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -157,25 +192,30 @@ func SendReply(ctx context.Context, eid uint64, repScores []bindings.DELReputati
 	}
 }
 
-func VoteSolver(ctx context.Context, eid uint64, candAddr common.Address) error {
+func (n *Node) VoteSolver(ctx context.Context, eid uint64, candAddr common.Address) error {
 
 	// Checking zone
-	if existEvent(eid) &&
-		!isEventSolved(eid) &&
-		GetEvent(eid).HasRequiredReplies &&
-		!GetEvent(eid).HasRequiredVotes &&
-		IsNodeRegistered(candAddr) &&
-		!hasAlreadyVoted(eid, _from.Address) {
+	if n.existEvent(eid) &&
+		!n.isEventSolved(eid) &&
+		!n.isEventRetried(eid) &&
+		n.GetEvent(eid).HasRequiredReplies &&
+		!n.GetEvent(eid).HasRequiredVotes &&
+		n.IsNodeRegistered(candAddr) &&
+		!n.hasAlreadyVoted(eid, n.from.Address) {
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000004)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000004)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.VoteSolver(auth, eid, candAddr)
+			_, err = n.cinst.VoteSolver(auth, eid, candAddr)
+			metrics.Transaction(VoteSolverAction, err)
 
 			if err != nil {
-				//utils.CheckError(err, utils.WarningMode)
+				//utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -185,22 +225,27 @@ func VoteSolver(ctx context.Context, eid uint64, candAddr common.Address) error
 	}
 }
 
-func SolveEvent(ctx context.Context, eid uint64) error {
+func (n *Node) SolveEvent(ctx context.Context, eid uint64) error {
 
 	// Checking zone
-	if existEvent(eid) &&
-		!isEventSolved(eid) &&
-		canSolveEvent(eid, _from.Address) {
+	if n.existEvent(eid) &&
+		!n.isEventSolved(eid) &&
+		!n.isEventRetried(eid) &&
+		n.canSolveEvent(eid, n.from.Address) {
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000005)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000005)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.SolveEvent(auth, eid)
+			_, err = n.cinst.SolveEvent(auth, eid)
+			metrics.Transaction(SolveEventAction, err)
 
 			if err != nil {
-				//utils.CheckError(err, utils.WarningMode)
+				//utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -210,7 +255,12 @@ func SolveEvent(ctx context.Context, eid uint64) error {
 	}
 }
 
-func RegisterApplication(ctx context.Context, ainfo *types.ApplicationInfo, cinfos []types.ContainerInfo, autodeploy bool) error {
+func (n *Node) RegisterApplication(ctx context.Context, ainfo *types.ApplicationInfo, cinfos []types.ContainerInfo, autodeploy bool) error {
+
+	// Checking zone (artifacts are served by the owner)
+	if err := n.checkArtifacts(cinfos); err != nil {
+		return err
+	}
 
 	// Txn data encoding
 	ai := utils.MarshalJSON(ainfo)
@@ -221,38 +271,45 @@ func RegisterApplication(ctx context.Context, ainfo *types.ApplicationInfo, cinf
 
 	for {
 		// Create and configure a transactor
-		auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000006)
+		auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000006)
+		if err != nil {
+			return err
+		}
 
 		// Send transaction
-		_, err := _cinst.RegisterApplication(auth, ai, ci, autodeploy)
+		_, err = n.cinst.RegisterApplication(auth, ai, ci, autodeploy)
+		metrics.Transaction(RegisterAppAction, err)
 
 		if err != nil {
-			//utils.CheckError(err, utils.WarningMode)
+			//utils.LogWarning(err)
 			continue
 		}
 		return err
 	}
 }
 
-/*func RegisterContainer(ctx context.Context, appid uint64, cinfo *types.ContainerInfo, autodeploy bool) error {
+/*func (n *Node) RegisterContainer(ctx context.Context, appid uint64, cinfo *types.ContainerInfo, autodeploy bool) error {
 
 	// Checking zone
-	if existApplication(appid) &&
-		isApplicationOwner(appid, _from.Address) &&
-		!IsApplicationUnregistered(appid) {
+	if n.existApplication(appid) &&
+		n.isApplicationOwner(appid, n.from.Address) &&
+		!n.IsApplicationUnregistered(appid) {
 
 		// Txn data encoding
 		ci := utils.MarshalJSON(cinfo)
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000007)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000007)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.RegisterContainer(auth, appid, ci, autodeploy)
+			_, err = n.cinst.RegisterContainer(auth, appid, ci, autodeploy)
 
 			if err != nil {
-				utils.CheckError(err, utils.WarningMode)
+				utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -262,26 +319,29 @@ func RegisterApplication(ctx context.Context, ainfo *types.ApplicationInfo, cinf
 	}
 }
 
-func ActivateContainer(ctx context.Context, rcid uint64) error {
+func (n *Node) ActivateContainer(ctx context.Context, rcid uint64) error {
 
-	appid := GetContainer(rcid).Appid
+	appid := n.GetContainer(rcid).Appid
 
 	// Checking zone
-	if existContainer(rcid) &&
-		isApplicationOwner(appid, _from.Address) &&
-		IsContainerHost(rcid, _from.Address) &&
-		!isContainerUnregistered(rcid) &&
-		!isContainerActive(rcid) {
+	if n.existContainer(rcid) &&
+		n.isApplicationOwner(appid, n.from.Address) &&
+		n.IsContainerHost(rcid, n.from.Address) &&
+		!n.isContainerUnregistered(rcid) &&
+		!n.isContainerActive(rcid) {
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000008)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000008)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.ActivateContainer(auth, rcid)
+			_, err = n.cinst.ActivateContainer(auth, rcid)
 
 			if err != nil {
-				utils.CheckError(err, utils.WarningMode)
+				utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -291,30 +351,33 @@ func ActivateContainer(ctx context.Context, rcid uint64) error {
 	}
 }
 
-func UpdateContainerInfo(ctx context.Context, rcid uint64, cinfo *types.ContainerInfo) error {
+func (n *Node) UpdateContainerInfo(ctx context.Context, rcid uint64, cinfo *types.ContainerInfo) error {
 
-	limit := getActionLimit(UpdateCtrAction)
-	appid := GetContainer(rcid).Appid
+	limit := n.getActionLimit(UpdateCtrAction)
+	appid := n.GetContainer(rcid).Appid
 
 	// Checking zone
-	if hasNodeReputation(_from.Address, limit) &&
-		existContainer(rcid) &&
-		isApplicationOwner(appid, _from.Address) &&
-		!isContainerInCurrentEvent(rcid) &&
-		!isContainerUnregistered(rcid) {
+	if n.hasNodeReputation(n.from.Address, limit) &&
+		n.existContainer(rcid) &&
+		n.isApplicationOwner(appid, n.from.Address) &&
+		!n.isContainerInCurrentEvent(rcid) &&
+		!n.isContainerUnregistered(rcid) {
 
 		// Txn data encoding
 		ci := utils.MarshalJSON(cinfo)
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000009)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000009)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.UpdateContainerInfo(auth, rcid, ci)
+			_, err = n.cinst.UpdateContainerInfo(auth, rcid, ci)
 
 			if err != nil {
-				utils.CheckError(err, utils.WarningMode)
+				utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -324,32 +387,35 @@ func UpdateContainerInfo(ctx context.Context, rcid uint64, cinfo *types.Containe
 	}
 }
 
-func UnregisterApplication(ctx context.Context, appid uint64) error {
+func (n *Node) UnregisterApplication(ctx context.Context, appid uint64) error {
 
-	ctrs := GetApplicationContainers(appid)
+	ctrs := n.GetApplicationContainers(appid)
 	actions := []RepAction{{UnregisterAppAction, 1}, {UnregisterCtrAction, len(ctrs)}}
 
 	// Checking zone
-	if hasEstimatedReputation(_from.Address, actions) &&
-		existApplication(appid) &&
-		isApplicationOwner(appid, _from.Address) &&
-		!IsApplicationUnregistered(appid) {
+	if n.hasEstimatedReputation(n.from.Address, actions) &&
+		n.existApplication(appid) &&
+		n.isApplicationOwner(appid, n.from.Address) &&
+		!n.IsApplicationUnregistered(appid) {
 		// Has the application a container that is currently being managed?
 		for _, ctr := range ctrs {
-			if isContainerInCurrentEvent(ctr) {
+			if n.isContainerInCurrentEvent(ctr) {
 				return errors.New(UnregisterAppAction + ": transaction not sent")
 			}
 		}
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000010)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000010)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.UnregisterApplication(auth, appid)
+			_, err = n.cinst.UnregisterApplication(auth, appid)
 
 			if err != nil {
-				utils.CheckError(err, utils.WarningMode)
+				utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -359,27 +425,30 @@ func UnregisterApplication(ctx context.Context, appid uint64) error {
 	}
 }
 
-func UnregisterContainer(ctx context.Context, rcid uint64) error {
+func (n *Node) UnregisterContainer(ctx context.Context, rcid uint64) error {
 
-	limit := getActionLimit(UnregisterCtrAction)
-	appid := GetContainer(rcid).Appid
+	limit := n.getActionLimit(UnregisterCtrAction)
+	appid := n.GetContainer(rcid).Appid
 
 	// Checking zone
-	if hasNodeReputation(_from.Address, limit) &&
-		existContainer(rcid) &&
-		isApplicationOwner(appid, _from.Address) &&
-		!isContainerInCurrentEvent(rcid) &&
-		!isContainerUnregistered(rcid) {
+	if n.hasNodeReputation(n.from.Address, limit) &&
+		n.existContainer(rcid) &&
+		n.isApplicationOwner(appid, n.from.Address) &&
+		!n.isContainerInCurrentEvent(rcid) &&
+		!n.isContainerUnregistered(rcid) {
 
 		for {
 			// Create and configure a transactor
-			auth := eth.Transactor(ctx, _ethc, _ks, _from, 8000011)
+			auth, err := eth.Transactor(ctx, n.chain, n.ks, n.from, n.cfg.Eth.ChainID, 8000011)
+			if err != nil {
+				return err
+			}
 
 			// Send transaction
-			_, err := _cinst.UnregisterContainer(auth, rcid)
+			_, err = n.cinst.UnregisterContainer(auth, rcid)
 
 			if err != nil {
-				utils.CheckError(err, utils.WarningMode)
+				utils.LogWarning(err)
 				continue
 			}
 			return err
@@ -390,37 +459,39 @@ func UnregisterContainer(ctx context.Context, rcid uint64) error {
 }*/
 
 // Getters //
-/*func getFaucetContract() (faddr common.Address) {
+/*func (n *Node) getFaucetContract() (faddr common.Address) {
 
-	faddr, err := _cinst.Faucet(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.FatalMode)
+	faddr, err := n.cinst.Faucet(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	return
 }*/
 
-func getNodeContract(addr common.Address) (naddr common.Address) {
+func (n *Node) getNodeContract(addr common.Address) (naddr common.Address) {
 
-	naddr, err := _cinst.Nodes(&bind.CallOpts{From: _from.Address}, addr)
-	utils.CheckError(err, utils.WarningMode)
+	naddr, err := n.cinst.Nodes(&bind.CallOpts{From: n.from.Address}, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func getClusterState() *types.ClusterState {
+func (n *Node) GetClusterState() (*types.ClusterState, error) {
 
-	state, err := _cinst.State(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	state, err := n.cinst.State(&bind.CallOpts{From: n.from.Address})
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert binding struct to native struct
 	s := types.ClusterState(state)
 
-	return &s
+	return &s, nil
 }
 
-func GetClusterConfig() *types.ClusterConfig {
+func (n *Node) GetClusterConfig() *types.ClusterConfig {
 
-	config, err := _cinst.Config(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	config, err := n.cinst.Config(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	// Convert binding struct to native struct
 	c := types.ClusterConfig(config)
@@ -428,57 +499,77 @@ func GetClusterConfig() *types.ClusterConfig {
 	return &c
 }
 
-/*func getActionLimit(action string) (limit int64) {
+/*func (n *Node) getActionLimit(action string) (limit int64) {
 
-	limit, err := _finst.GetActionLimit(&bind.CallOpts{From: _from.Address}, action)
-	utils.CheckError(err, utils.WarningMode)
+	limit, err := _finst.GetActionLimit(&bind.CallOpts{From: n.from.Address}, action)
+	utils.LogWarning(err)
 
 	return
 }
 
-func getActionVariation(action string) (avar int64) {
+func (n *Node) getActionVariation(action string) (avar int64) {
 
-	avar, err := _finst.GetActionVariation(&bind.CallOpts{From: _from.Address}, action)
-	utils.CheckError(err, utils.WarningMode)
+	avar, err := _finst.GetActionVariation(&bind.CallOpts{From: n.from.Address}, action)
+	utils.LogWarning(err)
 
 	return
 }*/
 
-func GetAllNodeSpecs() map[common.Address]string {
+func (n *Node) GetAllNodeSpecs() map[common.Address]string {
 
-	rn, err := _cinst.GetRegisteredNodes(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	// Registry cache
+	if nodes, found := n.registry.allNodeSpecs(); found {
+		return nodes
+	}
+
+	return n.fetchAllNodeSpecs()
+}
+
+func (n *Node) fetchAllNodeSpecs() map[common.Address]string {
+
+	rn, err := n.cinst.GetRegisteredNodes(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	nodes := make(map[common.Address]string)
 	for _, addr := range rn {
-		nodes[addr] = GetNodeSpecs(addr)
+		nodes[addr] = n.fetchNodeSpecs(addr)
 	}
 
 	return nodes
 }
 
-func GetNodeSpecs(addr common.Address) (specs string) {
+func (n *Node) GetNodeSpecs(addr common.Address) string {
+
+	// Registry cache
+	if specs, found := n.registry.nodeSpecs(addr); found {
+		return specs
+	}
+
+	return n.fetchNodeSpecs(addr)
+}
+
+func (n *Node) fetchNodeSpecs(addr common.Address) (specs string) {
 
-	ninst := nodeInstance(getNodeContract(addr))
-	specs, err := ninst.GetSpecs(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	ninst := n.nodeInstance(n.getNodeContract(addr))
+	specs, err := ninst.GetSpecs(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	return
 }
 
-/*func getNodeReputation(addr common.Address) (rep int64) {
+/*func (n *Node) getNodeReputation(addr common.Address) (rep int64) {
 
-	ninst := nodeInstance(getNodeContract(addr))
-	rep, err := ninst.GetReputation(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	ninst := n.nodeInstance(n.getNodeContract(addr))
+	rep, err := ninst.GetReputation(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	return
 }*/
 
-func GetEvent(eid uint64) *types.Event {
+func (n *Node) GetEvent(eid uint64) *types.Event {
 
-	ce, err := _cinst.Events(&bind.CallOpts{From: _from.Address}, eid)
-	utils.CheckError(err, utils.WarningMode)
+	ce, err := n.cinst.Events(&bind.CallOpts{From: n.from.Address}, eid)
+	utils.LogWarning(err)
 
 	// Convert binding struct to native struct
 	e := types.Event(ce)
@@ -486,19 +577,35 @@ func GetEvent(eid uint64) *types.Event {
 	return &e
 }
 
-func GetEventReplyCount(eid uint64) int {
+// Last n cluster events (event ids start at 1)
+func (n *Node) GetLatestEvents(last uint64) (map[uint64]*types.Event, error) {
 
-	c, err := _cinst.GetEventReplyCount(&bind.CallOpts{From: _from.Address}, eid)
-	utils.CheckError(err, utils.WarningMode)
+	state, err := n.GetClusterState()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(map[uint64]*types.Event)
+	for eid := state.NextEventId; eid > 1 && uint64(len(events)) < last; eid-- {
+		events[eid-1] = n.GetEvent(eid - 1)
+	}
+
+	return events, nil
+}
+
+func (n *Node) GetEventReplyCount(eid uint64) int {
+
+	c, err := n.cinst.GetEventReplyCount(&bind.CallOpts{From: n.from.Address}, eid)
+	utils.LogWarning(err)
 
 	return int(c.Int64())
 }
 
-func GetEventReplies(eid uint64) (r []types.EventReply) {
+func (n *Node) GetEventReplies(eid uint64) (r []types.EventReply) {
 
-	for i := 0; i < GetEventReplyCount(eid); i++ {
-		raddr, rss, rat, err := _cinst.GetEventReply(&bind.CallOpts{From: _from.Address}, eid, uint64(i))
-		utils.CheckError(err, utils.WarningMode)
+	for i := 0; i < n.GetEventReplyCount(eid); i++ {
+		raddr, rss, rat, err := n.cinst.GetEventReply(&bind.CallOpts{From: n.from.Address}, eid, uint64(i))
+		utils.LogWarning(err)
 
 		r = append(r, types.EventReply{Replier: raddr, RepScores: rss, RepliedAt: rat})
 	}
@@ -506,10 +613,20 @@ func GetEventReplies(eid uint64) (r []types.EventReply) {
 	return
 }
 
-func GetApplication(appid uint64) *types.Application {
+func (n *Node) GetApplication(appid uint64) *types.Application {
 
-	app, err := _cinst.Apps(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	// Registry cache
+	if a := n.registry.application(appid); a != nil {
+		return a
+	}
+
+	return n.fetchApplication(appid)
+}
+
+func (n *Node) fetchApplication(appid uint64) *types.Application {
+
+	app, err := n.cinst.Apps(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	// Convert binding struct to native struct
 	a := types.Application(app)
@@ -517,10 +634,20 @@ func GetApplication(appid uint64) *types.Application {
 	return &a
 }
 
-func GetContainer(rcid uint64) *types.Container {
+func (n *Node) GetContainer(rcid uint64) *types.Container {
+
+	// Registry cache
+	if c := n.registry.container(rcid); c != nil {
+		return c
+	}
+
+	return n.fetchContainer(rcid)
+}
+
+func (n *Node) fetchContainer(rcid uint64) *types.Container {
 
-	ctr, err := _cinst.Ctrs(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	ctr, err := n.cinst.Ctrs(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	// Convert binding struct to native struct
 	c := types.Container(ctr)
@@ -528,118 +655,155 @@ func GetContainer(rcid uint64) *types.Container {
 	return &c
 }
 
-func GetContainerInstances(rcid uint64) (insts []bindings.DCRContainerInstance) {
+func (n *Node) GetContainerInstances(rcid uint64) []bindings.DCRContainerInstance {
 
-	insts, err := _cinst.GetContainerInstances(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	// Registry cache
+	if insts, found := n.registry.instances(rcid); found {
+		return insts
+	}
+
+	return n.fetchContainerInstances(rcid)
+}
+
+func (n *Node) fetchContainerInstances(rcid uint64) (insts []bindings.DCRContainerInstance) {
+
+	insts, err := n.cinst.GetContainerInstances(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func GetApplicationContainers(appid uint64) (ctrs []uint64) {
+func (n *Node) GetApplicationContainers(appid uint64) (ctrs []uint64) {
 
-	ctrs, err := _cinst.GetApplicationContainers(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	ctrs, err := n.cinst.GetApplicationContainers(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func GetApplicationContainersData(appid uint64) map[uint64]*types.Container {
+func (n *Node) GetApplicationContainersData(appid uint64) map[uint64]*types.Container {
 
-	ac, err := _cinst.GetApplicationContainers(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	ac, err := n.cinst.GetApplicationContainers(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	ctrs := make(map[uint64]*types.Container)
 	for _, rcid := range ac {
-		ctrs[rcid] = GetContainer(rcid)
+		ctrs[rcid] = n.GetContainer(rcid)
 	}
 
 	return ctrs
 }
 
-/*func GetContainersFromCurrentEvents() map[uint64]*types.Container {
+/*func (n *Node) GetContainersFromCurrentEvents() map[uint64]*types.Container {
 
-	ce, err := _cinst.GetCurrentEvents(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	ce, err := n.cinst.GetCurrentEvents(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	ctrs := make(map[uint64]*types.Container)
 	for _, eid := range ce {
-		rcid := GetEvent(eid).Rcid
+		rcid := n.GetEvent(eid).Rcid
 		if rcid > 0 {
-			ctrs[rcid] = GetContainer(rcid)
+			ctrs[rcid] = n.GetContainer(rcid)
 		}
 	}
 
 	return ctrs
 }*/
 
-func GetActiveApplications() map[uint64]*types.Application {
+func (n *Node) GetActiveApplications() map[uint64]*types.Application {
 
-	aa, err := _cinst.GetActiveApplications(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	aa, err := n.cinst.GetActiveApplications(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	apps := make(map[uint64]*types.Application)
 	for _, appid := range aa {
-		apps[appid] = GetApplication(appid)
+		apps[appid] = n.GetApplication(appid)
 	}
 
 	return apps
 }
 
-func GetActiveApplicationsLength() int {
+func (n *Node) GetActiveApplicationsLength() int {
 
-	aa, err := _cinst.GetActiveApplications(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	aa, err := n.cinst.GetActiveApplications(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	return len(aa)
 }
 
-func GetActiveContainers() map[uint64]*types.Container {
+func (n *Node) GetActiveContainers() map[uint64]*types.Container {
 
-	ac, err := _cinst.GetActiveContainers(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	// Registry cache
+	if ctrs, found := n.registry.activeContainers(); found {
+		return ctrs
+	}
+
+	return n.fetchActiveContainers()
+}
+
+func (n *Node) fetchActiveContainers() map[uint64]*types.Container {
+
+	ac, err := n.cinst.GetActiveContainers(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	ctrs := make(map[uint64]*types.Container)
 	for _, rcid := range ac {
-		ctrs[rcid] = GetContainer(rcid)
+		ctrs[rcid] = n.fetchContainer(rcid)
+	}
+
+	return ctrs
+}
+
+// Active containers hosted by the node
+func (n *Node) GetHostedContainers() map[uint64]*types.ContainerInfo {
+
+	ctrs := make(map[uint64]*types.ContainerInfo)
+	for rcid, ctr := range n.GetActiveContainers() {
+		if n.IsContainerHost(rcid, n.from.Address) {
+			// Decode container info
+			var cinfo types.ContainerInfo
+			utils.UnmarshalJSON(ctr.Info, &cinfo)
+
+			ctrs[rcid] = &cinfo
+		}
 	}
 
 	return ctrs
 }
 
-func GetActiveContainersLength() int {
+func (n *Node) GetActiveContainersLength() int {
 
-	ac, err := _cinst.GetActiveContainers(&bind.CallOpts{From: _from.Address})
-	utils.CheckError(err, utils.WarningMode)
+	ac, err := n.cinst.GetActiveContainers(&bind.CallOpts{From: n.from.Address})
+	utils.LogWarning(err)
 
 	return len(ac)
 }
 
 // Helpers //
-func IsNodeRegistered(addr common.Address) (r bool) {
+func (n *Node) IsNodeRegistered(addr common.Address) (r bool) {
 
-	r, err := _cinst.IsNodeRegistered(&bind.CallOpts{From: _from.Address}, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsNodeRegistered(&bind.CallOpts{From: n.from.Address}, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-/*func hasNodeReputation(addr common.Address, lrep int64) (r bool) {
+/*func (n *Node) hasNodeReputation(addr common.Address, lrep int64) (r bool) {
 
 	// Check if the node has enough reputation (greater or equal than a limit)
-	r, err := _cinst.HasNodeReputation(&bind.CallOpts{From: _from.Address}, addr, lrep)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.HasNodeReputation(&bind.CallOpts{From: n.from.Address}, addr, lrep)
+	utils.LogWarning(err)
 
 	return
 }
 
-func hasEstimatedReputation(addr common.Address, actions []RepAction) (r bool) {
+func (n *Node) hasEstimatedReputation(addr common.Address, actions []RepAction) (r bool) {
 
 	// Initial node reputation
-	rep := getNodeReputation(addr)
+	rep := n.getNodeReputation(addr)
 
 	for i := range actions {
-		limit := getActionLimit(actions[i].name)
+		limit := n.getActionLimit(actions[i].name)
 
 		// For each execution
 		for j := 0; j < actions[i].count; j++ {
@@ -648,137 +812,137 @@ func hasEstimatedReputation(addr common.Address, actions []RepAction) (r bool) {
 			}
 
 			// Simulate next reputation
-			rep += getActionVariation(actions[i].name)
+			rep += n.getActionVariation(actions[i].name)
 		}
 	}
 
 	return true
 }*/
 
-func HasRequiredCount(th uint8, count uint64) (r bool) {
+func (n *Node) HasRequiredCount(th uint8, count uint64) (r bool) {
 
-	r, err := _cinst.HasRequiredCount(&bind.CallOpts{From: _from.Address}, th, count)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.HasRequiredCount(&bind.CallOpts{From: n.from.Address}, th, count)
+	utils.LogWarning(err)
 
 	return
 }
 
-func isApplicationOwner(appid uint64, addr common.Address) (r bool) {
+func (n *Node) isApplicationOwner(appid uint64, addr common.Address) (r bool) {
 
-	r, err := _cinst.IsApplicationOwner(&bind.CallOpts{From: _from.Address}, appid, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsApplicationOwner(&bind.CallOpts{From: n.from.Address}, appid, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func IsContainerHost(rcid uint64, addr common.Address) (r bool) {
+func (n *Node) IsContainerHost(rcid uint64, addr common.Address) (r bool) {
 
-	r, err := _cinst.IsContainerHost(&bind.CallOpts{From: _from.Address}, rcid, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsContainerHost(&bind.CallOpts{From: n.from.Address}, rcid, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func hasAlreadyReplied(eid uint64, addr common.Address) (r bool) {
+func (n *Node) hasAlreadyReplied(eid uint64, addr common.Address) (r bool) {
 
-	r, err := _cinst.HasAlreadyReplied(&bind.CallOpts{From: _from.Address}, eid, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.HasAlreadyReplied(&bind.CallOpts{From: n.from.Address}, eid, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func hasAlreadyVoted(eid uint64, addr common.Address) (r bool) {
+func (n *Node) hasAlreadyVoted(eid uint64, addr common.Address) (r bool) {
 
-	r, err := _cinst.HasAlreadyVoted(&bind.CallOpts{From: _from.Address}, eid, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.HasAlreadyVoted(&bind.CallOpts{From: n.from.Address}, eid, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func existEvent(eid uint64) (r bool) {
+func (n *Node) existEvent(eid uint64) (r bool) {
 
-	r, err := _cinst.ExistEvent(&bind.CallOpts{From: _from.Address}, eid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.ExistEvent(&bind.CallOpts{From: n.from.Address}, eid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func isEventSolved(eid uint64) (r bool) {
+func (n *Node) isEventSolved(eid uint64) (r bool) {
 
-	r, err := _cinst.IsEventSolved(&bind.CallOpts{From: _from.Address}, eid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsEventSolved(&bind.CallOpts{From: n.from.Address}, eid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func canSolveEvent(eid uint64, addr common.Address) (r bool) {
+func (n *Node) canSolveEvent(eid uint64, addr common.Address) (r bool) {
 
-	r, err := _cinst.CanSolveEvent(&bind.CallOpts{From: _from.Address}, eid, addr)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.CanSolveEvent(&bind.CallOpts{From: n.from.Address}, eid, addr)
+	utils.LogWarning(err)
 
 	return
 }
 
-func existApplication(appid uint64) (r bool) {
+func (n *Node) existApplication(appid uint64) (r bool) {
 
-	r, err := _cinst.ExistApplication(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.ExistApplication(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func isApplicationActive(appid uint64) (r bool) {
+func (n *Node) isApplicationActive(appid uint64) (r bool) {
 
-	r, err := _cinst.IsApplicationActive(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsApplicationActive(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func IsApplicationUnregistered(appid uint64) (r bool) {
+func (n *Node) IsApplicationUnregistered(appid uint64) (r bool) {
 
-	r, err := _cinst.IsApplicationUnregistered(&bind.CallOpts{From: _from.Address}, appid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsApplicationUnregistered(&bind.CallOpts{From: n.from.Address}, appid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func existContainer(rcid uint64) (r bool) {
+func (n *Node) existContainer(rcid uint64) (r bool) {
 
-	r, err := _cinst.ExistContainer(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.ExistContainer(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func isContainerAutodeployed(rcid uint64) (r bool) {
+func (n *Node) isContainerAutodeployed(rcid uint64) (r bool) {
 
-	r, err := _cinst.IsContainerAutodeployed(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsContainerAutodeployed(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }
 
-func isContainerActive(rcid uint64) (r bool) {
+func (n *Node) isContainerActive(rcid uint64) (r bool) {
 
-	r, err := _cinst.IsContainerActive(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsContainerActive(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }
 
-/*func isContainerInCurrentEvent(rcid uint64) (r bool) {
+/*func (n *Node) isContainerInCurrentEvent(rcid uint64) (r bool) {
 
-	r, err := _cinst.IsContainerInCurrentEvent(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsContainerInCurrentEvent(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }*/
 
-func isContainerUnregistered(rcid uint64) (r bool) {
+func (n *Node) isContainerUnregistered(rcid uint64) (r bool) {
 
-	r, err := _cinst.IsContainerUnregistered(&bind.CallOpts{From: _from.Address}, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	r, err := n.cinst.IsContainerUnregistered(&bind.CallOpts{From: n.from.Address}, rcid)
+	utils.LogWarning(err)
 
 	return
 }
@@ -1,330 +1,251 @@
-package managers
-
-/*func init() {
+package managers_test
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"github.com/swarleynunez/hidra/inputs"
+	"golang.org/x/exp/slices"
+	"strconv"
+	"testing"
+)
+
+// 4 nodes: 2 required replies and 2 required votes (66% thresholds)
+const clusterSize = 4
+
+func newTestCluster(t *testing.T) *simnet.Cluster {
+
+	c, err := simnet.NewCluster(context.Background(), clusterSize)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	t.Cleanup(c.Close)
+
+	return c
+}
 
-	InitNode(context.Background(), true)
+// Reputation scores given by a node to the rest of the cluster
+func testScores(c *simnet.Cluster, from int) types.NodeStore {
 
-	// New controller smart contract for each test execution
-	caddr := DeployController()
-	utils.SetEnvKey("CONTROLLER_ADDR", caddr.String())
+	ns := types.NodeStore{}
+	for i, n := range c.Nodes {
+		if i != from {
+			ns[n.GetFromAccount()] = &types.NodeInfo{Reputation: types.ReputationInfo{Score: 1}}
+		}
+	}
 
-	time.Sleep(100 * time.Millisecond)
-	InitNode(context.Background(), false)
+	return ns
 }
 
-func TestRegisterNodes(t *testing.T) {
+// Replies of the given nodes and votes for a solver (mined after each phase)
+func solveByVotes(t *testing.T, c *simnet.Cluster, eid uint64, repliers []int, solver int) {
 
-	RegisterNode()
-	time.Sleep(100 * time.Millisecond)
-	if !IsNodeRegistered(_from.Address) {
-		t.Fatal("ERROR:", t.Name())
-	}
+	ctx := context.Background()
 
-	_from = eth.LoadAccount(_ks, "0x5cb50d3e5a4666fd90c4e6226942ee47ef400348", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	RegisterNode()
-	time.Sleep(100 * time.Millisecond)
-	if !IsNodeRegistered(_from.Address) {
+	for _, i := range repliers {
+		if err := c.Nodes[i].SendReply(ctx, eid, managers.GetReputationScores(testScores(c, i))); err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+	}
+	c.Commit()
+	if !c.Nodes[0].GetEvent(eid).HasRequiredReplies {
 		t.Fatal("ERROR:", t.Name())
 	}
 
-	_from = eth.LoadAccount(_ks, "0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	RegisterNode()
-	time.Sleep(100 * time.Millisecond)
-	if !IsNodeRegistered(_from.Address) {
+	saddr := c.Nodes[solver].GetFromAccount()
+	for _, i := range repliers {
+		if err := c.Nodes[i].VoteSolver(ctx, eid, saddr); err != nil {
+			t.Fatal("ERROR:", t.Name(), err)
+		}
+	}
+	c.Commit()
+	if event := c.Nodes[0].GetEvent(eid); !event.HasRequiredVotes || event.Solver != saddr {
 		t.Fatal("ERROR:", t.Name())
 	}
 }
 
-func TestHasNodeReputation(t *testing.T) {
+func TestRegisterNodes(t *testing.T) {
 
-	_from = eth.LoadAccount(_ks, "0xbfdcef8b53327344018a2c569d288f8249c4ff89", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
+	c := newTestCluster(t)
 
-	if !hasNodeReputation(_from.Address, 99) {
+	specs := c.Nodes[0].GetAllNodeSpecs()
+	if len(specs) != clusterSize {
 		t.Fatal("ERROR:", t.Name())
 	}
 
-	if !hasNodeReputation(_from.Address, 100) {
-		t.Fatal("ERROR:", t.Name())
+	for i, n := range c.Nodes {
+		if !n.IsNodeRegistered(n.GetFromAccount()) {
+			t.Fatal("ERROR:", t.Name())
+		}
+
+		ip, port := c.Nodes[0].GetNodeIPFromAddress(n.GetFromAccount())
+		if c.Nodes[0].GetNodeAddressFromIP(ip, port) != n.GetFromAccount() || port != strconv.Itoa(simnet.BasePort+i) {
+			t.Fatal("ERROR:", t.Name())
+		}
 	}
 
-	if hasNodeReputation(_from.Address, 101) {
+	// Unknown node
+	if c.Nodes[1].IsNodeRegistered(common.HexToAddress("0x1")) {
 		t.Fatal("ERROR:", t.Name())
 	}
 }
 
-func TestSendEvent(t *testing.T) {
+func TestDELFlow(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	sender := c.Nodes[0]
 
-	etype := types.EventType{
-		RequiredTask:     types.PingNodeTask,
-		Resource: types.NoResource,
+	// SendEvent
+	etype := types.EventType{RequiredTask: types.PingNodeTask, Resource: types.NoResource}
+	if err := sender.SendEvent(ctx, &etype, 0); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
 	}
-	SendEvent(&etype, 0, GetState())
-
-	time.Sleep(100 * time.Millisecond)
-	event := GetEvent(getClusterState().NextEventId - 1)
-	if event.EType == "" ||
-		event.Sender != _from.Address ||
-		!utils.EmptyEthAddress(event.Solver.String()) ||
-		event.Rcid > 0 ||
+	c.Commit()
+
+	eid := uint64(1)
+	event := sender.GetEvent(eid)
+	if event.Sender != sender.GetFromAccount() ||
+		event.Rcid != 0 ||
 		event.SentAt.Uint64() == 0 ||
 		event.SolvedAt.Uint64() != 0 {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestSendReply(t *testing.T) {
-
-	eid := getClusterState().NextEventId - 1
 
-	_from = eth.LoadAccount(_ks, "0x5cb50d3e5a4666fd90c4e6226942ee47ef400348", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	SendReply(eid, GetState())
+	// SendReply and VoteSolver
+	solveByVotes(t, c, eid, []int{1, 2}, 3)
 
-	_from = eth.LoadAccount(_ks, "0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	SendReply(eid, GetState())
-
-	time.Sleep(100 * time.Millisecond)
-	replies := GetEventReplies(eid)
-	if len(replies) != 3 ||
-		replies[2].Replier != _from.Address ||
-		replies[2].NodeState == "" ||
-		len(replies[2].Voters) != 0 ||
-		replies[2].RepliedAt.Uint64() == 0 {
+	replies := sender.GetEventReplies(eid)
+	if len(replies) != 2 || replies[1].Replier != c.Nodes[2].GetFromAccount() || len(replies[1].RepScores) != clusterSize-1 {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestVoteSolver(t *testing.T) {
-
-	_from = eth.LoadAccount(_ks, "0xbfdcef8b53327344018a2c569d288f8249c4ff89", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-
-	eid := getClusterState().NextEventId - 1
-
-	VoteSolver(eid, _from.Address)
-
-	_from = eth.LoadAccount(_ks, "0x5cb50d3e5a4666fd90c4e6226942ee47ef400348", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	VoteSolver(eid, common.HexToAddress("0xbfdcef8b53327344018a2c569d288f8249c4ff89"))
-
-	_from = eth.LoadAccount(_ks, "0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	VoteSolver(eid, common.HexToAddress("0xbfdcef8b53327344018a2c569d288f8249c4ff89"))
 
-	time.Sleep(100 * time.Millisecond)
-	if GetEvent(eid).Solver != common.HexToAddress("0xbfdcef8b53327344018a2c569d288f8249c4ff89") ||
-		len(GetEventReplies(eid)[0].Voters) != 3 {
+	// Repeated replies and votes are not sent
+	if sender.SendReply(ctx, eid, nil) == nil || c.Nodes[1].VoteSolver(ctx, eid, sender.GetFromAccount()) == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestSolveEvent(t *testing.T) {
-
-	_from = eth.LoadAccount(_ks, "0xbfdcef8b53327344018a2c569d288f8249c4ff89", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-
-	eid := getClusterState().NextEventId - 1
 
-	SolveEvent(eid)
+	// Only the voted solver can solve the event
+	if sender.SolveEvent(ctx, eid) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	if err := c.Nodes[3].SolveEvent(ctx, eid); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
 
-	time.Sleep(100 * time.Millisecond)
-	if GetEvent(eid).SolvedAt.Uint64() == 0 {
+	if events, err := sender.GetLatestEvents(10); err != nil || sender.GetEvent(eid).SolvedAt.Uint64() == 0 || events[eid] == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
 }
 
-func TestRegisterApplication(t *testing.T) {
+func TestDCRFlow(t *testing.T) {
 
-	RegisterApplication(&inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, false)
+	c := newTestCluster(t)
+	ctx := context.Background()
+	owner := c.Nodes[0]
 
-	time.Sleep(100 * time.Millisecond)
-	appid := getClusterState().NextAppId - 1
-	rcid := getClusterState().NextCtrId - 1
-	app := GetApplication(appid)
-	ctr := GetContainer(rcid)
-	if getClusterState().NextAppId != 2 ||
-		app.Owner != common.HexToAddress("0xbfdcef8b53327344018a2c569d288f8249c4ff89") ||
-		app.Info == "" ||
-		len(GetApplicationContainers(appid)) != 1 ||
+	// RegisterApplication (not in autodeploy mode)
+	if err := owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, false); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+
+	appid, rcid := uint64(1), uint64(1)
+	app := owner.GetApplication(appid)
+	if app.Owner != owner.GetFromAccount() ||
 		app.RegisteredAt.Uint64() == 0 ||
-		app.UnregisteredAt.Uint64() != 0 ||
-		len(getActiveApplications()) != 1 ||
-		getClusterState().NextCtrId != 2 ||
-		ctr.Appid != appid ||
-		ctr.Info == "" ||
-		len(GetContainerInstances(rcid)) > 0 ||
-		ctr.RegisteredAt.Uint64() == 0 ||
-		ctr.UnregisteredAt.Uint64() != 0 ||
-		len(GetActiveContainers()) != 0 {
+		!slices.Equal(owner.GetApplicationContainers(appid), []uint64{rcid}) ||
+		owner.GetContainer(rcid).Appid != appid ||
+		len(owner.GetContainerInstances(rcid)) != 0 ||
+		len(owner.GetActiveContainers()) != 0 {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestClusterState(t *testing.T) {
 
-	if existEvent(0) ||
-		existApplication(0) ||
-		existContainer(0) {
-		t.Fatal("ERROR:", t.Name())
+	// Activation: the cluster selects a host through a new container event
+	etype := types.EventType{RequiredTask: types.NewContainerTask, Resource: types.AllResources}
+	if err := owner.SendEvent(ctx, &etype, rcid); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
 	}
-}
+	c.Commit()
 
-func TestRegisterContainer(t *testing.T) {
+	eid := uint64(1)
+	solveByVotes(t, c, eid, []int{0, 2}, 1)
 
-	RegisterContainer(getClusterState().NextAppId-1, &inputs.CtrInfo, false)
+	host := c.Nodes[1]
+	var cinfo types.ContainerInfo
+	utils.UnmarshalJSON(host.GetContainer(rcid).Info, &cinfo)
+	host.NewContainer(ctx, &cinfo, appid, rcid, true)
+	host.RunEventTask(ctx, host.GetEvent(eid), eid)
+	c.Commit()
 
-	time.Sleep(100 * time.Millisecond)
-	if getClusterState().NextCtrId != 3 {
+	insts := host.GetContainerInstances(rcid)
+	if host.GetEvent(eid).SolvedAt.Uint64() == 0 ||
+		len(insts) != 1 ||
+		!host.IsContainerHost(rcid, host.GetFromAccount()) ||
+		owner.GetActiveApplications()[appid] == nil ||
+		owner.GetHostedContainers()[rcid] != nil ||
+		host.GetHostedContainers()[rcid] == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestActivateContainer(t *testing.T) {
-
-	RegisterContainer(getClusterState().NextAppId-1, &inputs.CtrInfo, true)
-
-	time.Sleep(100 * time.Millisecond)
-	rcid := getClusterState().NextCtrId - 1
-	ActivateContainer(rcid)
 
-	time.Sleep(100 * time.Millisecond)
-	insts := GetContainerInstances(rcid)
-	if len(insts) != 1 ||
-		insts[0].Host != _from.Address ||
-		insts[0].StartedAt.Uint64() == 0 ||
-		!isContainerActive(rcid) ||
-		len(GetActiveContainers()) != 1 {
+	// Fake Docker and ONOS of the host
+	cname := managers.GetContainerName(rcid)
+	if !c.Runtimes[1].Running(cname) ||
+		c.Runtimes[0].Running(cname) ||
+		!slices.Equal(c.Routers[1].Requests(), []string{"inst_add 1", "vs_on 1"}) {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestUpdateContainerInfo(t *testing.T) {
-
-	rcid := getClusterState().NextCtrId - 1
-	inputs.CtrInfo.ServiceType = types.FrameworkServ
-	inputs.CtrInfo.Impact = 5
-	inputs.CtrInfo.ImageTag = "python"
-	inputs.CtrInfo.Volumes = nil
-	inputs.CtrInfo.Ports = nil
-
-	UpdateContainerInfo(rcid, &inputs.CtrInfo)
 
-	time.Sleep(100 * time.Millisecond)
-	var cinfo types.ContainerInfo
-	utils.UnmarshalJSON(GetContainer(rcid).Info, &cinfo)
-	if cinfo.ServiceType != types.FrameworkServ ||
-		cinfo.Impact != 5 ||
-		cinfo.ImageTag != "python" ||
-		cinfo.Volumes != nil ||
-		cinfo.Ports != nil {
+	// Unregistration (local cleanup only, not supported by the controller contract)
+	host.RemoveContainer(ctx, appid, rcid, true)
+	if c.Runtimes[1].Running(cname) ||
+		c.Routers[1].Requests()[2] != "inst_del 1 1" ||
+		host.IsApplicationUnregistered(appid) {
 		t.Fatal("ERROR:", t.Name())
 	}
 }
 
-func TestUnregisterApplication(t *testing.T) {
-
-	appid := getClusterState().NextAppId - 1
+// Not sent transactions
+func TestSendEventChecks(t *testing.T) {
 
-	UnregisterApplication(appid)
+	c := newTestCluster(t)
+	ctx := context.Background()
 
-	time.Sleep(100 * time.Millisecond)
-	app := GetApplication(appid)
-	if app.UnregisteredAt.Uint64() == 0 ||
-		len(GetApplicationContainers(appid)) != 0 ||
-		len(getActiveApplications()) != 0 ||
-		len(GetActiveContainers()) != 0 {
+	etype := types.EventType{RequiredTask: types.MigrateContainerTask, Resource: types.CpuResource}
+	if c.Nodes[0].SendEvent(ctx, &etype, 1) == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
-}
-
-func TestUnregisterContainer(t *testing.T) {
 
-	RegisterApplication(&inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, true)
-
-	time.Sleep(100 * time.Millisecond)
-	rcid := getClusterState().NextCtrId - 1
-	ActivateContainer(rcid)
-
-	time.Sleep(100 * time.Millisecond)
-	UnregisterContainer(rcid)
-
-	time.Sleep(100 * time.Millisecond)
-	ctr := GetContainer(rcid)
-	appid := getClusterState().NextAppId - 1
-	if ctr.UnregisteredAt.Uint64() == 0 ||
-		isContainerActive(rcid) ||
-		len(GetApplicationContainers(appid)) != 0 ||
-		len(GetActiveContainers()) != 0 {
+	// Self-reputations
+	self := []bindings.DELReputationScore{{Node: c.Nodes[0].GetFromAccount(), Score: "1"}}
+	if c.Nodes[0].SendReply(ctx, 1, self) == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
 }
 
-func TestEventWithContainer(t *testing.T) {
-
-	RegisterContainer(getClusterState().NextAppId-1, &inputs.CtrInfo, false)
+func TestClusterStateError(t *testing.T) {
 
-	// Send event
-	time.Sleep(100 * time.Millisecond)
-	etype := types.EventType{
-		RequiredTask:     types.MigrateContainerTask,
-		Resource: types.CpuResource,
+	c := newTestCluster(t)
+	if state, err := c.Nodes[0].GetClusterState(); err != nil || state.NextEventId != 1 {
+		t.Fatal("ERROR:", t.Name(), err)
 	}
-	rcid := getClusterState().NextCtrId - 1
-	SendEvent(&etype, rcid, GetState())
 
-	time.Sleep(100 * time.Millisecond)
-	eid := getClusterState().NextEventId - 1
-	event := GetEvent(eid)
-	if event.Rcid == 0 {
-		t.Fatal("ERROR:", t.Name())
+	// No controller deployed at the configured address (no zero state)
+	cfg := *c.Config
+	cfg.Eth.Controller = common.HexToAddress("0x1").String()
+	node, err := managers.NewNode(&cfg, &managers.NodeDeps{Chain: c.Backend}, false)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
 	}
-
-	// Send replies
-	_from = eth.LoadAccount(_ks, "0x5cb50d3e5a4666fd90c4e6226942ee47ef400348", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	SendReply(eid, GetState())
-	_from = eth.LoadAccount(_ks, "0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	SendReply(eid, GetState())
-
-	// Vote solver
-	time.Sleep(100 * time.Millisecond)
-	_from = eth.LoadAccount(_ks, "0xbfdcef8b53327344018a2c569d288f8249c4ff89", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	VoteSolver(eid, common.HexToAddress("0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff"))
-	_from = eth.LoadAccount(_ks, "0x5cb50d3e5a4666fd90c4e6226942ee47ef400348", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	VoteSolver(eid, common.HexToAddress("0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff"))
-	_from = eth.LoadAccount(_ks, "0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff", "12345678")
-	_nonce, _ = _ethc.PendingNonceAt(context.Background(), _from.Address)
-	VoteSolver(eid, common.HexToAddress("0x10a99a5329b9f3ccae04a8a1afad03d4d2e9b1ff"))
-
-	time.Sleep(100 * time.Millisecond)
-	event = GetEvent(eid)
-	insts := GetContainerInstances(rcid)
-	if utils.EmptyEthAddress(event.Solver.String()) ||
-		len(insts) != 1 ||
-		utils.EmptyEthAddress(insts[0].Host.String()) ||
-		insts[0].StartedAt.Uint64() != 0 {
+	if state, err := node.GetClusterState(); err == nil || state != nil {
 		t.Fatal("ERROR:", t.Name())
 	}
-
-	// Solve event
-	time.Sleep(100 * time.Millisecond)
-	SolveEvent(eid)
-
-	time.Sleep(100 * time.Millisecond)
-	event = GetEvent(eid)
-	insts = GetContainerInstances(rcid)
-	if event.SolvedAt.Uint64() == 0 ||
-		len(insts) != 1 ||
-		utils.EmptyEthAddress(insts[0].Host.String()) ||
-		insts[0].StartedAt.Uint64() == 0 ||
-		!isContainerActive(rcid) {
+	if _, err = node.GetLatestEvents(10); err == nil {
 		t.Fatal("ERROR:", t.Name())
 	}
-}*/
+}
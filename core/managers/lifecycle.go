@@ -0,0 +1,143 @@
+package managers
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"sort"
+)
+
+var (
+	errNodeNotEmpty     = errors.New("node still hosts active containers")
+	errNodeDeregistered = errors.New("node already deregistered")
+)
+
+// Getters //
+func (n *Node) GetNodeStatus(addr common.Address) types.NodeStatus {
+
+	// Get and decode node specs
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(n.GetNodeSpecs(addr), &specs)
+
+	return specs.Status
+}
+
+// Registered peers aggregating reply scores unlike this node (their votes would split)
+func (n *Node) AggregationMismatches() (peers []common.Address) {
+
+	own := n.cfg.Network.AggregationSpec()
+	for addr, encoded := range n.GetAllNodeSpecs() {
+		var specs types.NodeSpecs
+		utils.UnmarshalJSON(encoded, &specs)
+		if addr == n.from.Address || specs.Status == types.DeregisteredNode {
+			continue
+		}
+
+		agg := specs.Agg
+		if agg == "" {
+			agg = "topk" // Not advertised by older nodes
+		}
+		if agg != own {
+			peers = append(peers, addr)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Hex() < peers[j].Hex() })
+
+	return
+}
+
+// Lifecycle //
+// The status is advertised in the node specs (the controller has no node states)
+func (n *Node) SetNodeStatus(ctx context.Context, status types.NodeStatus) error {
+
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(n.fetchNodeSpecs(n.from.Address), &specs) // Not cached
+	if specs.Status == types.DeregisteredNode {
+		return errNodeDeregistered
+	}
+	if specs.Status == status {
+		return nil
+	}
+	specs.Status = status
+
+	return n.UpdateSpecs(ctx, &specs)
+}
+
+// Push the current hardware specs, addresses and location if they changed (advertised ports and status are kept)
+func (n *Node) RefreshSpecs(ctx context.Context) (bool, error) {
+
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(n.fetchNodeSpecs(n.from.Address), &specs) // Not cached
+
+	cur, err := n.localSpecs()
+	if err != nil {
+		return false, err
+	}
+	if !specsChanged(&specs, cur) {
+		return false, nil
+	}
+	cur.Port, cur.BlobPort, cur.Status = specs.Port, specs.BlobPort, specs.Status
+
+	return true, n.UpdateSpecs(ctx, cur)
+}
+
+// Stop being a solver candidate and ask the cluster to migrate the hosted containers.
+// On failure, the containers whose migration was already requested are returned
+func (n *Node) DrainNode(ctx context.Context) (sent []uint64, err error) {
+
+	if err = n.SetNodeStatus(ctx, types.DrainingNode); err != nil {
+		return nil, err
+	}
+
+	etype := types.EventType{RequiredTask: types.MigrateContainerTask, Resource: types.AllResources}
+	for _, rcid := range n.migratableContainers() {
+		if err = n.SendEvent(ctx, &etype, rcid); err != nil {
+			return sent, err
+		}
+		sent = append(sent, rcid)
+	}
+
+	return
+}
+
+// The controller cannot unregister nodes, so the node stays registered as deregistered.
+// Autodeployed containers are not migrated by the cluster and keep running on the node
+func (n *Node) DeregisterNode(ctx context.Context) error {
+
+	if len(n.migratableContainers()) > 0 {
+		return errNodeNotEmpty
+	}
+
+	return n.SetNodeStatus(ctx, types.DeregisteredNode)
+}
+
+// Helpers //
+// Hosted containers to be migrated before leaving the cluster (autodeployed ones excluded)
+func (n *Node) migratableContainers() (rcids []uint64) {
+
+	for rcid := range n.GetHostedContainers() {
+		if !n.isContainerAutodeployed(rcid) {
+			rcids = append(rcids, rcid)
+		}
+	}
+	sort.Slice(rcids, func(i, j int) bool { return rcids[i] < rcids[j] })
+
+	return
+}
+
+// CPU frequency is not compared (it changes with the CPU scaling)
+func specsChanged(old, cur *types.NodeSpecs) bool {
+
+	return old.Arch != cur.Arch ||
+		old.Cores != cur.Cores ||
+		old.MemTotal != cur.MemTotal ||
+		old.DiskTotal != cur.DiskTotal ||
+		old.OS != cur.OS ||
+		!old.IP.Equal(cur.IP) ||
+		!sameIPs(old.Addrs, cur.Addrs) ||
+		(old.Location == nil) != (cur.Location == nil) ||
+		(old.Location != nil && *old.Location != *cur.Location) ||
+		old.Agg != cur.Agg
+}
@@ -0,0 +1,111 @@
+package managers_test
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"github.com/swarleynunez/hidra/inputs"
+	"golang.org/x/exp/slices"
+	"testing"
+)
+
+func TestNodeLifecycle(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	owner, host := c.Nodes[0], c.Nodes[1]
+
+	// Container hosted by node 1
+	if err := owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, false); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	etype := types.EventType{RequiredTask: types.NewContainerTask, Resource: types.AllResources}
+	if err := owner.SendEvent(ctx, &etype, 1); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	solveByVotes(t, c, 1, []int{0, 2}, 1)
+	var cinfo types.ContainerInfo
+	utils.UnmarshalJSON(host.GetContainer(1).Info, &cinfo)
+	host.NewContainer(ctx, &cinfo, 1, 1, true)
+	host.RunEventTask(ctx, host.GetEvent(1), 1)
+	c.Commit()
+
+	// Unchanged specs are not pushed
+	if updated, err := host.RefreshSpecs(ctx); err != nil || updated {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Not empty
+	if host.DeregisterNode(ctx) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Drain: status advertised and the hosted container migrated
+	rcids, err := host.DrainNode(ctx)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+
+	var etype2 types.EventType
+	event := owner.GetEvent(2)
+	utils.UnmarshalJSON(event.EType, &etype2)
+	if !slices.Equal(rcids, []uint64{1}) ||
+		owner.GetNodeStatus(host.GetFromAccount()) != types.DrainingNode ||
+		event.Sender != host.GetFromAccount() || event.Rcid != 1 ||
+		etype2.RequiredTask != types.MigrateContainerTask {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Cancel the drain
+	if err = host.SetNodeStatus(ctx, types.ActiveNode); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if owner.GetNodeStatus(host.GetFromAccount()) != types.ActiveNode {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Empty node (the specs keep the advertised ports)
+	leaving := c.Nodes[3]
+	if err = leaving.DeregisterNode(ctx); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(owner.GetNodeSpecs(leaving.GetFromAccount()), &specs)
+	if specs.Status != types.DeregisteredNode || specs.Port == 0 ||
+		leaving.SetNodeStatus(ctx, types.ActiveNode) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestAggregationMismatches(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+
+	// Registered with the default aggregation
+	if peers := c.Nodes[0].AggregationMismatches(); len(peers) != 0 {
+		t.Fatal("ERROR:", t.Name(), peers)
+	}
+
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(c.Nodes[1].GetNodeSpecs(c.Nodes[1].GetFromAccount()), &specs)
+	if specs.Agg != "topk" {
+		t.Fatal("ERROR:", t.Name(), specs.Agg)
+	}
+
+	// A peer aggregating reply scores differently
+	specs.Agg = "trimmed:20"
+	if err := c.Nodes[1].UpdateSpecs(ctx, &specs); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if peers := c.Nodes[0].AggregationMismatches(); len(peers) != 1 || peers[0] != c.Nodes[1].GetFromAccount() {
+		t.Fatal("ERROR:", t.Name(), peers)
+	}
+}
@@ -2,26 +2,36 @@ package managers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	dockertypes "github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
 	psutilnet "github.com/shirou/gopsutil/v3/net"
 	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/docker"
 	"github.com/swarleynunez/hidra/core/eth"
 	"github.com/swarleynunez/hidra/core/onos"
+	"github.com/swarleynunez/hidra/core/storage"
 	"github.com/swarleynunez/hidra/core/types"
 	"github.com/swarleynunez/hidra/core/utils"
+	"io"
+	"math"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 )
@@ -41,73 +51,157 @@ const (
 )
 
 var (
-	// Unexported and "readonly" global parameters
-	_ethc   *ethclient.Client
-	_ks     *keystore.KeyStore
-	_from   accounts.Account
-	_pmutex *sync.Mutex
-	_cinst  *bindings.Controller
-	//_finst  *bindings.Faucet
-	_docc  *client.Client
-	_onosc *onos.Client
-
 	// Errors
-	errUnknownTask = errors.New("unknown event task")
+	errUnknownTask        = errors.New("unknown event task")
+	errDockerNotConnected = errors.New("docker client not connected")
 )
 
+// Blockchain access (geth IPC client or a simulated backend)
+type ChainClient interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// Container operations used by the node (subset of the Docker client)
+type ContainerRuntime interface {
+	ImageList(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error)
+	ImagePull(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error)
+	ImagesPrune(ctx context.Context, pruneFilter filters.Args) (dockertypes.ImagesPruneReport, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, container string, options dockertypes.ContainerStartOptions) error
+	ContainerRestart(ctx context.Context, container string, options container.StopOptions) error
+	ContainerRename(ctx context.Context, container, newContainerName string) error
+	ContainerStop(ctx context.Context, container string, options container.StopOptions) error
+	ContainerRemove(ctx context.Context, container string, options dockertypes.ContainerRemoveOptions) error
+	ContainerList(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error)
+	ContainerStatsOneShot(ctx context.Context, container string) (dockertypes.ContainerStats, error)
+	DiskUsage(ctx context.Context, options dockertypes.DiskUsageOptions) (dockertypes.DiskUsage, error)
+	VolumesPrune(ctx context.Context, pruneFilter filters.Args) (dockertypes.VolumesPruneReport, error)
+}
+
+// Virtual service routing (ONOS SDN controller)
+type ServiceRouter interface {
+	Request(rname, body string, params ...uint64) error
+}
+
+// Logical fog node (several nodes can run in the same process)
+type Node struct {
+	cfg      *config.Config
+	chain    ChainClient
+	ks       *keystore.KeyStore
+	from     accounts.Account
+	cinst    *bindings.Controller
+	runtime  ContainerRuntime                // Nil if Docker is not connected
+	router   ServiceRouter                   // Nil if the ONOS module is disabled
+	blobs    *storage.Store                  // Nil if CRUD tasks are not supported
+	registry *registry                       // Nil if the on-chain state is not cached
+	pmutex   sync.Mutex                      // To synchronize access to network ports
+	cpu      map[uint64]dockertypes.CPUStats // Previous CPU sample per hosted container
+	cmutex   sync.Mutex                      // To synchronize access to CPU samples
+	retried  map[uint64]uint64               // Stalled event --> re-issued event
+	rmutex   sync.Mutex                      // To synchronize access to re-issued events
+	//finst  *bindings.Faucet
+}
+
+// Node dependencies (the chain client and an unlocked account are required)
+type NodeDeps struct {
+	Chain    ChainClient
+	Keystore *keystore.KeyStore
+	Account  accounts.Account
+	Runtime  ContainerRuntime
+	Router   ServiceRouter
+	Blobs    *storage.Store
+}
+
 type networks map[string]dockertypes.NetworkStats
 
 // Init //
-func InitNode(ctx context.Context, deploying bool) {
-
-	// Load .env
-	utils.LoadEnv()
-	nodeDir := utils.GetEnv("ETH_NODE_DIR")
-	pass := utils.GetEnv("ETH_NODE_PASS")
+// Connect to the local services of the node (the configuration must be already validated)
+func InitNode(ctx context.Context, cfg *config.Config, deploying bool) (*Node, error) {
 
 	// Connect to the Ethereum local node
-	_ethc = eth.Connect(utils.FormatPath(nodeDir, "geth.ipc"))
+	ethc, err := eth.Connect(utils.FormatPath(cfg.Eth.NodeDir, "geth.ipc"))
+	if err != nil {
+		return nil, err
+	}
 
 	// Load Ethereum keystore
-	keypath := utils.FormatPath(nodeDir, "keystore")
-	_ks = eth.LoadKeystore(keypath)
+	keypath := utils.FormatPath(cfg.Eth.NodeDir, "keystore")
+	ks := eth.LoadKeystore(keypath)
 
 	// Load and unlock an Ethereum account
-	_from = eth.LoadAccount(_ks, pass)
+	from, err := eth.LoadAccount(ks, cfg.Eth.NodePass)
+	if err != nil {
+		return nil, err
+	}
+	utils.SetLogNode(from.Address.String())
 
 	// Debug
-	fmt.Println("-->", "Loaded EOA:", _from.Address.String())
+	utils.Info("Loaded EOA", "account", from.Address.String())
+
+	deps := &NodeDeps{Chain: ethc, Keystore: ks, Account: from, Blobs: storage.NewStore(cfg.Storage.Dir)}
+
+	// Connect to the Docker local daemon (not needed to deploy the controller)
+	if cfg.Docker.Enabled && !deploying {
+		docc, err := docker.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deps.Runtime = docc
+	}
+
+	// Connect to a cluster ONOS controller
+	onosc, err := onos.Connect(&cfg.ONOS)
+	if err != nil {
+		return nil, err
+	}
+	if onosc.Enabled {
+		deps.Router = onosc
+	}
+
+	return NewNode(cfg, deps, deploying)
+}
+
+// Node from already connected dependencies (fakes and simulated backends included)
+func NewNode(cfg *config.Config, deps *NodeDeps, deploying bool) (*Node, error) {
+
+	n := &Node{
+		cfg:     cfg,
+		chain:   deps.Chain,
+		ks:      deps.Keystore,
+		from:    deps.Account,
+		runtime: deps.Runtime,
+		router:  deps.Router,
+		blobs:   deps.Blobs,
+	}
 
 	// Get smart contracts instances
 	if !deploying {
-		_cinst = controllerInstance()
-		//_finst = faucetInstance(getFaucetContract())
+		cinst, err := controllerInstance(cfg.Eth.Controller, n.chain)
+		if err != nil {
+			return nil, err
+		}
+		n.cinst = cinst
+		//n.finst = n.faucetInstance(n.getFaucetContract())
 
 		// Debug
-		fmt.Println("-->", "Loaded smart contract:", utils.GetEnv("CONTROLLER_ADDR"))
+		utils.Info("Loaded smart contract", "controller", cfg.Eth.Controller)
 	}
 
-	// Connect to the Docker local daemon
-	//_docc = docker.Connect(ctx)
-
-	// Connect to a cluster ONOS controller
-	_onosc = onos.Connect()
-
-	// Mutex to synchronize access to network ports
-	_pmutex = &sync.Mutex{}
+	return n, nil
 }
 
-func InitNodeState(ctx context.Context) {
+func (n *Node) InitNodeState(ctx context.Context) {
 
 	// Get DCR active containers
-	ctrs := GetActiveContainers()
+	ctrs := n.GetActiveContainers()
 	for rcid := range ctrs {
 		// Am I the host?
-		if IsContainerHost(rcid, _from.Address) {
+		if n.IsContainerHost(rcid, n.from.Address) {
 			cname := GetContainerName(rcid)
 
 			// Does the container exist locally?
-			c := SearchDockerContainers(ctx, "name", cname, true)
+			c := n.SearchDockerContainers(ctx, "name", cname, true)
 			if c == nil {
 				// Decode container info
 				var cinfo types.ContainerInfo
@@ -115,14 +209,14 @@ func InitNodeState(ctx context.Context) {
 
 				// TODO: check ONOS SDN state when nodes initialize its container state
 				go func() {
-					createDockerContainer(ctx, &cinfo, cname)
-					startDockerContainer(ctx, cname)
+					n.createDockerContainer(ctx, &cinfo, cname)
+					n.startDockerContainer(ctx, cname)
 				}()
 			} else {
 				// Is the container running?
-				c = SearchDockerContainers(ctx, "name", cname, false)
+				c = n.SearchDockerContainers(ctx, "name", cname, false)
 				if c == nil {
-					startDockerContainer(ctx, cname)
+					n.startDockerContainer(ctx, cname)
 				}
 			}
 		}
@@ -130,30 +224,31 @@ func InitNodeState(ctx context.Context) {
 }
 
 // Getters //
-func GetFromAccount() common.Address {
-	return _from.Address
+func (n *Node) GetFromAccount() common.Address {
+	return n.from.Address
 }
 
-func GetControllerInst() *bindings.Controller {
-	return _cinst
+func (n *Node) GetControllerInst() *bindings.Controller {
+	return n.cinst
 }
 
+// Hardware specs (the advertised addresses are set by the node)
 func GetSpecs() *types.NodeSpecs {
 
 	hi, err := host.Info()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	cores, err := cpu.Counts(true) // Counting physical and logical cores
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	ci, err := cpu.Info()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	vm, err := mem.VirtualMemory()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	du, err := disk.Usage("/") // File system root path
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	return &types.NodeSpecs{
 		Arch:      hi.KernelArch,
@@ -162,27 +257,26 @@ func GetSpecs() *types.NodeSpecs {
 		MemTotal:  vm.Total,
 		DiskTotal: du.Total,
 		OS:        hi.OS,
-		IP:        GetNodeIP(),
 	}
 }
 
 func GetState() *types.State {
 
 	cp, err := cpu.Percent(0, false) // Total CPU usage (all cores)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	vm, err := mem.VirtualMemory()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	du, err := disk.Usage("/") // File system root path
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Entirely disk usage
 	du.Used = du.Total - du.Free
 	du.UsedPercent = (float64(du.Used) / float64(du.Total)) * 100.0
 
 	/*dio, err := disk.IOCounters()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Store disks information in a slice
 	var disks []*disk.IOCountersStat
@@ -196,13 +290,13 @@ func GetState() *types.State {
 	})
 
 	proc, err := process.Processes()
-	utils.CheckError(err, utils.WarningMode)*/
+	utils.LogWarning(err)*/
 
 	nio, err := psutilnet.IOCounters(false) // Get global net I/O stats (all NICs)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	/*ni, err := net.Interfaces()
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Store each interface as pointer
 	var inets []*net.InterfaceStat
@@ -211,7 +305,7 @@ func GetState() *types.State {
 	}
 
 	nc, err := net.Connections("inet") // Only inet connections (tcp, udp)
-	utils.CheckError(err, utils.WarningMode)
+	utils.LogWarning(err)
 
 	// Store each connection as pointer
 	var conns []*net.ConnectionStat
@@ -234,55 +328,92 @@ func GetState() *types.State {
 	}
 }
 
-/*func getContainerState(ctx context.Context, cname string) *types.State {
+// Hosted container state from the Docker stats API
+func (n *Node) GetContainerState(ctx context.Context, rcid uint64) (*types.State, error) {
+
+	if !n.IsDockerConnected() {
+		return nil, errDockerNotConnected
+	}
+
+	// TODO. Container summary (only if the container is running)
+	cname := GetContainerName(rcid)
+	ctr := n.SearchDockerContainers(ctx, "name", cname, false)
+	if ctr == nil {
+		return nil, errContainerNotFound
+	}
 
 	// Get current stats
-	cs, err := _docc.ContainerStatsOneShot(ctx, cname)
-	utils.CheckError(err, utils.WarningMode)
+	cs, err := n.runtime.ContainerStatsOneShot(ctx, cname)
+	if err != nil {
+		return nil, err
+	}
+	defer cs.Body.Close()
 
 	// Decode stats
 	var stats dockertypes.StatsJSON
 	err = json.NewDecoder(cs.Body).Decode(&stats)
-	utils.CheckError(err, utils.WarningMode)
-
-	// TODO. Container summary (only if the container is running)
-	ctr := SearchDockerContainers(ctx, "name", cname, false)
+	if err != nil {
+		return nil, err
+	}
 
 	// Group all NICs
 	ns := groupNetworkStats(stats.Networks)
 
 	return &types.State{
-		CpuUsage:       calculateCpuPercent(&stats.CPUStats, &stats.PreCPUStats),
+		CpuUsage:       n.cpuUsage(rcid, &stats),
 		MemUsage:       stats.MemoryStats.Usage,
-		DiskUsage:      uint64(ctr[0].SizeRw) + getVolumesSize(ctx, ctr[0].Mounts), // Get disk usage (rw size and volumes size)
+		DiskUsage:      uint64(ctr[0].SizeRw) + n.getVolumesSize(ctx, ctr[0].Mounts), // Get disk usage (rw size and volumes size)
 		NetPacketsSent: ns.TxPackets,
 		NetPacketsRecv: ns.RxPackets,
+	}, nil
+}
+
+// Node with the given address (nodes sharing an IP, like emulated nodes, are told apart by their port)
+func (n *Node) GetNodeAddressFromIP(ip, port string) (nodeAddr common.Address) {
+
+	pip := net.ParseIP(ip)
+	if pip == nil {
+		return
 	}
-}*/
 
-func GetNodeAddressFromIP(ip, port string) (nodeAddr common.Address) {
+	// Registry cache (misses are looked up on chain, e.g. nodes registered or updated since the last sync)
+	if addr, found := n.registry.nodeAddress(pip, port); found {
+		return addr
+	}
+	defer func() {
+		if !utils.EmptyEthAddress(nodeAddr.String()) {
+			n.RefreshNode(nodeAddr)
+		}
+	}()
 
-	allSpecs := GetAllNodeSpecs()
-	for k, v := range allSpecs {
+	var matches []common.Address
+	for k, v := range n.fetchAllNodeSpecs() {
 		// Get and decode node specs
 		var specs types.NodeSpecs
 		utils.UnmarshalJSON(v, &specs)
 
-		// TODO. IP + port (due to the emulation of nodes)
-		if specs.IP.String() == ip && strconv.FormatUint(uint64(specs.Port), 10) == port {
-			nodeAddr = k
-			break
+		if !specs.HasIP(pip) {
+			continue
 		}
+		if strconv.FormatUint(uint64(specs.Port), 10) == port {
+			return k
+		}
+		matches = append(matches, k)
+	}
+
+	// Peers connect from ephemeral ports
+	if len(matches) == 1 {
+		nodeAddr = matches[0]
 	}
 
 	return
 }
 
-func GetNodeIPFromAddress(addr common.Address) (nodeIP, nodePort string) {
+func (n *Node) GetNodeIPFromAddress(addr common.Address) (nodeIP, nodePort string) {
 
 	// Get and decode node specs
 	var specs types.NodeSpecs
-	utils.UnmarshalJSON(GetNodeSpecs(addr), &specs)
+	utils.UnmarshalJSON(n.GetNodeSpecs(addr), &specs)
 
 	nodeIP = specs.IP.String()
 	nodePort = strconv.FormatUint(uint64(specs.Port), 10)
@@ -292,21 +423,28 @@ func GetNodeIPFromAddress(addr common.Address) (nodeIP, nodePort string) {
 
 func GetReputationScores(nodeStore types.NodeStore) (repScores []bindings.DELReputationScore) {
 
-	fmt.Println("\nLOCAL REPUTATIONS:")
-
 	for k, v := range nodeStore {
-		s := strconv.FormatFloat(v.Reputation.Score, 'f', -1, 64)
+		s := EncodeReputationClaim(GetReputationClaim(&v.Reputation))
 		repScores = append(repScores, bindings.DELReputationScore{Node: k, Score: s})
 
-		fmt.Println(bindings.DELReputationScore{Node: k, Score: s}, v.Reputation.Values)
+		utils.Debug("Local reputation", "node", k.String(), "claim", s, "values", v.Reputation.Values)
 	}
 
+	// Deterministic reply encoding
+	sort.Slice(repScores, func(i, j int) bool { return repScores[i].Node.Hex() < repScores[j].Node.Hex() })
+
 	return
 }
 
 // Handling //
 // Tasks to execute when the sender and the solver are the same node
-func RunTask(ctx context.Context, event *types.Event, eid uint64) {
+func (n *Node) RunTask(ctx context.Context, event *types.Event, eid uint64) {
+
+	// Superseded by a re-issued event (solved by another node)
+	if by := n.GetRetriedBy(eid); by > 0 {
+		utils.Warn("Event task skipped", "eid", eid, "retried_by", by)
+		return
+	}
 
 	// Decode event type
 	var etype types.EventType
@@ -316,32 +454,45 @@ func RunTask(ctx context.Context, event *types.Event, eid uint64) {
 	case types.NewContainerTask:
 		if event.Rcid > 0 {
 			// Get container linked to the event
-			ctr := GetContainer(event.Rcid)
+			ctr := n.GetContainer(event.Rcid)
 
 			// Decode container info
 			var cinfo types.ContainerInfo
 			utils.UnmarshalJSON(ctr.Info, &cinfo)
 
 			// Run task
-			// NewContainer(ctx, &cinfo, ctr.Appid, event.Rcid, true)
+			// n.NewContainer(ctx, &cinfo, ctr.Appid, event.Rcid, true)
 		}
 	case types.MigrateContainerTask:
 		if event.Rcid > 0 {
 			// TODO: run tasks to balance cluster nodes (resource usage)?
-			// RestartContainer(ctx, GetContainerName(event.Rcid))
+			// n.RestartContainer(ctx, GetContainerName(event.Rcid))
 		}
+	case types.CreateTask, types.ReadTask, types.UpdateTask, types.DeleteTask:
+		if err := n.runBlobTask(ctx, &etype, event.Sender); err != nil {
+			utils.LogWarning(err)
+			return
+		}
+	case types.PingNodeTask:
+		// Replies are the probe (nothing to execute)
 	default:
-		utils.CheckError(errUnknownTask, utils.WarningMode)
+		utils.LogWarning(errUnknownTask)
 		return
 	}
 
 	// Solve related event
-	err := SolveEvent(ctx, eid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.SolveEvent(ctx, eid)
+	utils.LogWarning(err)
 }
 
 // Tasks to execute when the cluster selects a solver
-func RunEventTask(ctx context.Context, event *types.Event, eid uint64) {
+func (n *Node) RunEventTask(ctx context.Context, event *types.Event, eid uint64) {
+
+	// Superseded by a re-issued event (solved by another node)
+	if by := n.GetRetriedBy(eid); by > 0 {
+		utils.Warn("Event task skipped", "eid", eid, "retried_by", by)
+		return
+	}
 
 	// Decode event type
 	var etype types.EventType
@@ -351,27 +502,35 @@ func RunEventTask(ctx context.Context, event *types.Event, eid uint64) {
 	case types.NewContainerTask, types.MigrateContainerTask:
 		if event.Rcid > 0 {
 			// Get container linked to the event
-			ctr := GetContainer(event.Rcid)
+			ctr := n.GetContainer(event.Rcid)
 
 			// Decode container info
 			var cinfo types.ContainerInfo
 			utils.UnmarshalJSON(ctr.Info, &cinfo)
 
 			// Run event task
-			// NewContainer(ctx, &cinfo, ctr.Appid, event.Rcid, true)
+			// n.NewContainer(ctx, &cinfo, ctr.Appid, event.Rcid, true)
+		}
+	case types.CreateTask, types.ReadTask, types.UpdateTask, types.DeleteTask:
+		// Replicate the blob from the sender (not solved if it fails)
+		if err := n.runBlobTask(ctx, &etype, event.Sender); err != nil {
+			utils.LogWarning(err)
+			return
 		}
+	case types.PingNodeTask:
+		// Replies are the probe (nothing to execute)
 	default:
-		utils.CheckError(errUnknownTask, utils.WarningMode)
+		utils.LogWarning(errUnknownTask)
 		return
 	}
 
 	// Solve related event
-	err := SolveEvent(ctx, eid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.SolveEvent(ctx, eid)
+	utils.LogWarning(err)
 }
 
 // Tasks to execute when the cluster solve an event
-func RunEventEndingTask(ctx context.Context, event *types.Event) {
+func (n *Node) RunEventEndingTask(ctx context.Context, event *types.Event) {
 
 	// Decode event type
 	var etype types.EventType
@@ -383,32 +542,38 @@ func RunEventEndingTask(ctx context.Context, event *types.Event) {
 	case types.MigrateContainerTask:
 		if event.Rcid > 0 {
 			// Get container linked to the event
-			_ = GetContainer(event.Rcid)
+			_ = n.GetContainer(event.Rcid)
 
 			// Run ending task
-			// StopContainer(ctx, ctr.Appid, event.Rcid, true)
+			// n.StopContainer(ctx, ctr.Appid, event.Rcid, true)
 		}
+	case types.ReadTask:
+		// Get a replica from the solver
+		err := n.FetchBlob(ctx, etype.Blob, event.Solver)
+		utils.LogWarning(err)
+	case types.CreateTask, types.UpdateTask, types.DeleteTask, types.PingNodeTask:
+		// Do nothing
 	default:
-		utils.CheckError(errUnknownTask, utils.WarningMode)
+		utils.LogWarning(errUnknownTask)
 		return
 	}
 }
 
 // Tasks //
 // onosaction: require ONOS SDN additional actions?
-func NewContainer(ctx context.Context, cinfo *types.ContainerInfo, appid, rcid uint64, onosaction bool) {
+func (n *Node) NewContainer(ctx context.Context, cinfo *types.ContainerInfo, appid, rcid uint64, onosaction bool) {
 
 	// Does the container exist locally?
 	cname := GetContainerName(rcid)
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c == nil {
-		createDockerContainer(ctx, cinfo, cname)
-		startDockerContainer(ctx, cname)
+		n.createDockerContainer(ctx, cinfo, cname)
+		n.startDockerContainer(ctx, cname)
 	} else {
 		// Is the container running?
-		c = SearchDockerContainers(ctx, "name", cname, false)
+		c = n.SearchDockerContainers(ctx, "name", cname, false)
 		if c == nil {
-			startDockerContainer(ctx, cname)
+			n.startDockerContainer(ctx, cname)
 		}
 	}
 
@@ -417,130 +582,167 @@ func NewContainer(ctx context.Context, cinfo *types.ContainerInfo, appid, rcid u
 
 	// ONOS SDN plugin
 	if onosaction {
-		ONOSAddVSInstance(ctx, appid, rcid, GetNodeIP())
-		ONOSActivateVirtualService(appid)
+		nip, _ := n.GetNodeIPFromAddress(n.from.Address)
+		n.ONOSAddVSInstance(ctx, appid, rcid, net.ParseIP(nip))
+		n.ONOSActivateVirtualService(appid)
 	}
 }
 
-func StartContainer(ctx context.Context, cname string) {
+func (n *Node) StartContainer(ctx context.Context, cname string) {
 
 	// Does the container exist locally?
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c != nil {
 		// Is the container running?
-		c = SearchDockerContainers(ctx, "name", cname, false)
+		c = n.SearchDockerContainers(ctx, "name", cname, false)
 		if c == nil {
-			startDockerContainer(ctx, cname)
+			n.startDockerContainer(ctx, cname)
 		}
 	}
 }
 
-func RestartContainer(ctx context.Context, cname string) {
+func (n *Node) RestartContainer(ctx context.Context, cname string) {
 
 	// Does the container exist locally?
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c != nil {
 		// Is the container running?
-		c = SearchDockerContainers(ctx, "name", cname, false)
+		c = n.SearchDockerContainers(ctx, "name", cname, false)
 		if c == nil {
-			startDockerContainer(ctx, cname)
+			n.startDockerContainer(ctx, cname)
 		} else {
-			restartDockerContainer(ctx, cname)
+			n.restartDockerContainer(ctx, cname)
 		}
 	}
 }
 
 // Rename a container (temporarily, before remove the container)
-func RenameContainer(ctx context.Context, cname string) (cid string) {
+func (n *Node) RenameContainer(ctx context.Context, cname string) (cid string) {
 
 	// Does the container exist locally?
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c != nil {
 		cid = c[0].ID
-		renameDockerContainer(ctx, cname, cid)
+		n.renameDockerContainer(ctx, cname, cid)
 	}
 
 	return
 }
 
 // onosaction: require ONOS SDN additional actions?
-func StopContainer(ctx context.Context, appid, rcid uint64, onosaction bool) {
+func (n *Node) StopContainer(ctx context.Context, appid, rcid uint64, onosaction bool) {
 
 	// ONOS SDN plugin
 	if onosaction {
-		ONOSDeleteVSInstance(appid, rcid)
+		n.ONOSDeleteVSInstance(appid, rcid)
 	}
 
 	// Does the container exist locally?
 	cname := GetContainerName(rcid)
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c != nil {
 		// Is the container running?
-		c = SearchDockerContainers(ctx, "name", cname, false)
+		c = n.SearchDockerContainers(ctx, "name", cname, false)
 		if c != nil {
-			stopDockerContainer(ctx, cname)
+			n.stopDockerContainer(ctx, cname)
 		}
 	}
 }
 
 // onosaction: require ONOS SDN additional actions?
-func RemoveContainer(ctx context.Context, appid, rcid uint64, onosaction bool) {
+func (n *Node) RemoveContainer(ctx context.Context, appid, rcid uint64, onosaction bool) {
 
 	// ONOS SDN plugin
 	if onosaction {
-		ONOSDeleteVSInstance(appid, rcid)
+		n.ONOSDeleteVSInstance(appid, rcid)
 	}
 
 	// Does the container exist locally?
 	cname := GetContainerName(rcid)
-	c := SearchDockerContainers(ctx, "name", cname, true)
+	c := n.SearchDockerContainers(ctx, "name", cname, true)
 	if c != nil {
 		// Is the container running?
-		c = SearchDockerContainers(ctx, "name", cname, false)
+		c = n.SearchDockerContainers(ctx, "name", cname, false)
 		if c != nil {
-			stopDockerContainer(ctx, cname)
-			removeDockerContainer(ctx, cname)
+			n.stopDockerContainer(ctx, cname)
+			n.removeDockerContainer(ctx, cname)
 		} else {
-			removeDockerContainer(ctx, cname)
+			n.removeDockerContainer(ctx, cname)
 		}
 	}
+	n.removeArtifacts(cname)
+
+	n.cmutex.Lock()
+	delete(n.cpu, rcid)
+	n.cmutex.Unlock()
 }
 
-/*func RemoveDCRApplication(ctx context.Context, appid uint64) error {
+/*func (n *Node) RemoveDCRApplication(ctx context.Context, appid uint64) error {
 
-	err := UnregisterApplication(ctx, appid)
+	err := n.UnregisterApplication(ctx, appid)
 	if err == nil {
-		ONOSDeleteVirtualService(appid)
+		n.ONOSDeleteVirtualService(appid)
 	}
 
 	return err
 }*/
 
 // Helpers //
-/*func calculateCpuPercent(cpu, precpu *dockertypes.CPUStats) (pct float64) {
+// Docker CLI style (100% per core)
+// One-shot stats carry no precpu sample, so the previous read is kept per container
+func (n *Node) cpuUsage(rcid uint64, stats *dockertypes.StatsJSON) float64 {
+
+	n.cmutex.Lock()
+	defer n.cmutex.Unlock()
+
+	if n.cpu == nil {
+		n.cpu = make(map[uint64]dockertypes.CPUStats)
+	}
+
+	prev, found := n.cpu[rcid]
+	if !found {
+		prev = stats.PreCPUStats
+	}
+	n.cpu[rcid] = stats.CPUStats
+
+	// An empty sample would report the lifetime average
+	if prev.SystemUsage == 0 {
+		return 0
+	}
+
+	return calculateCpuPercent(&stats.CPUStats, &prev)
+}
+
+func calculateCpuPercent(cpu, precpu *dockertypes.CPUStats) (pct float64) {
 
 	// Container and system cpu times variation
 	ctrDelta := float64(cpu.CPUUsage.TotalUsage) - float64(precpu.CPUUsage.TotalUsage)
 	sysDelta := float64(cpu.SystemUsage) - float64(precpu.SystemUsage)
 
 	if ctrDelta > 0.0 && sysDelta > 0.0 {
-		cores := float64(len(cpu.CPUUsage.PercpuUsage)) // Number of cores
+		cores := float64(cpu.OnlineCPUs) // Number of cores
+		if cores == 0.0 {
+			cores = float64(len(cpu.CPUUsage.PercpuUsage)) // Not set by old daemons
+		}
 		pct = (ctrDelta / sysDelta) * cores * 100.0
 	}
 
 	return
 }
 
-func getVolumesSize(ctx context.Context, mnts []dockertypes.MountPoint) (r uint64) {
+func (n *Node) getVolumesSize(ctx context.Context, mnts []dockertypes.MountPoint) (r uint64) {
 
 	// Get docker disk usage info (docker system df -v)
-	resp, err := _docc.DiskUsage(ctx, dockertypes.DiskUsageOptions{})
-	utils.CheckError(err, utils.WarningMode)
+	resp, err := n.runtime.DiskUsage(ctx, dockertypes.DiskUsageOptions{Types: []dockertypes.DiskUsageObject{dockertypes.VolumeObject}})
+	if err != nil {
+		utils.LogWarning(err)
+		return
+	}
 
 	// Search and compare volumes by name
 	for i := range mnts {
 		for j := range resp.Volumes {
-			if mnts[i].Name == resp.Volumes[j].Name {
+			if mnts[i].Name == resp.Volumes[j].Name && resp.Volumes[j].UsageData != nil {
 				// Volume stats
 				count := resp.Volumes[j].UsageData.RefCount // Number of containers using this volume
 				size := resp.Volumes[j].UsageData.Size
@@ -570,25 +772,14 @@ func groupNetworkStats(net networks) (ns dockertypes.NetworkStats) {
 	}
 
 	return
-}*/
-
-func GetNodeIP() net.IP {
-
-	conn, err := net.Dial("udp", "1.2.3.4:80")
-	utils.CheckError(err, utils.FatalMode)
-	defer conn.Close()
-
-	//return conn.LocalAddr().(*net.UDPAddr).IP // TODO. Type assertion of an interface type
-	return net.ParseIP("127.0.0.1")
-
 }
 
-func checkNodePorts(ctx context.Context, ports nat.PortMap) nat.PortMap {
+func (n *Node) checkNodePorts(ctx context.Context, ports nat.PortMap) nat.PortMap {
 
 	// To avoid repeated ports
 	var usedPorts []string
 
-	_pmutex.Lock()
+	n.pmutex.Lock()
 	for i := range ports {
 		for j := range ports[i] {
 			// Container configured port (string format)
@@ -604,21 +795,21 @@ func checkNodePorts(ctx context.Context, ports nat.PortMap) nat.PortMap {
 					}
 				}
 
-				if isNodePortAvailable(i.Proto(), "localhost", strp) && !isPortAllocatedByDocker(ctx, strp) && !found {
+				if isNodePortAvailable(i.Proto(), "localhost", strp) && !n.isPortAllocatedByDocker(ctx, strp) && !found {
 					ports[i][j].HostPort = strp
 					usedPorts = append(usedPorts, strp)
 					break
 				} else {
 					// Set the next port
 					nump, err := strconv.ParseUint(strp, 10, 64)
-					utils.CheckError(err, utils.WarningMode)
+					utils.LogWarning(err)
 					nump++
 					strp = strconv.FormatUint(nump, 10)
 				}
 			}
 		}
 	}
-	_pmutex.Unlock()
+	n.pmutex.Unlock()
 
 	return ports
 }
@@ -636,25 +827,30 @@ func isNodePortAvailable(network, host, port string) bool {
 	}
 }
 
-func CanExecuteContainer(addr common.Address, ctrCpuLimit, ctrMemLimit uint64) (r bool) {
+func (n *Node) CanExecuteContainer(addr common.Address, ctrCpuLimit, ctrMemLimit uint64) (r bool) {
 
 	var cpuUsage, memUsage uint64
 
-	// Get DCR active containers
-	for rcid, ctr := range GetActiveContainers() {
-		// Decode container info
-		var cinfo types.ContainerInfo
-		utils.UnmarshalJSON(ctr.Info, &cinfo)
-
-		if IsContainerHost(rcid, addr) {
-			cpuUsage += cinfo.CpuLimit
-			memUsage += cinfo.MemLimit
+	// Registry cache
+	if u, found := n.registry.hostUsage(addr); found {
+		cpuUsage, memUsage = u.cpu, u.mem
+	} else {
+		// Get DCR active containers
+		for rcid, ctr := range n.GetActiveContainers() {
+			// Decode container info
+			var cinfo types.ContainerInfo
+			utils.UnmarshalJSON(ctr.Info, &cinfo)
+
+			if n.IsContainerHost(rcid, addr) {
+				cpuUsage += cinfo.CpuLimit
+				memUsage += cinfo.MemLimit
+			}
 		}
 	}
 
 	// Get and decode node specs
 	var specs types.NodeSpecs
-	utils.UnmarshalJSON(GetNodeSpecs(addr), &specs)
+	utils.UnmarshalJSON(n.GetNodeSpecs(addr), &specs)
 
 	// Free resources to execute the new container?
 	if cpuUsage+ctrCpuLimit <= specs.Cores*1e9 &&
@@ -662,17 +858,18 @@ func CanExecuteContainer(addr common.Address, ctrCpuLimit, ctrMemLimit uint64) (
 		r = true
 	}
 
-	fmt.Println("RES:", addr, cpuUsage, ctrCpuLimit, specs.Cores*1e9, "|", memUsage, ctrMemLimit, specs.MemTotal, r)
+	utils.Debug("Resource check", "node", addr.String(), "cpu", cpuUsage, "cpuLimit", ctrCpuLimit, "cores", specs.Cores,
+		"mem", memUsage, "memLimit", ctrMemLimit, "memTotal", specs.MemTotal, "ok", r)
 
 	return
 }
 
 // Experiments //
-func PrintFinalStatistics(nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
+func (n *Node) PrintFinalStatistics(nodeStore types.NodeStore, pktCounter *types.PacketCounter) {
 
 	// DCR
-	fmt.Println("\n--> Active DCR applications:", GetActiveApplicationsLength())
-	fmt.Println("--> Active DCR containers:", GetActiveContainersLength())
+	fmt.Println("\n--> Active DCR applications:", n.GetActiveApplicationsLength())
+	fmt.Println("--> Active DCR containers:", n.GetActiveContainersLength())
 
 	// Network packets
 	fmt.Println("--> Total network packets:", pktCounter.Total)
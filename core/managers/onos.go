@@ -8,9 +8,9 @@ import (
 	"strings"
 )
 
-func ONOSAddVirtualService(appid uint64, desc string, vip net.IP, vproto string, vport uint16) {
+func (n *Node) ONOSAddVirtualService(appid uint64, desc string, vip net.IP, vproto string, vport uint16) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
@@ -24,48 +24,48 @@ func ONOSAddVirtualService(appid uint64, desc string, vip net.IP, vproto string,
 		},
 	}
 
-	err := _onosc.Request("vs_add", utils.MarshalJSON(vs))
-	utils.CheckError(err, utils.WarningMode)
+	err := n.router.Request("vs_add", utils.MarshalJSON(vs))
+	utils.LogWarning(err)
 }
 
-func ONOSActivateVirtualService(appid uint64) {
+func (n *Node) ONOSActivateVirtualService(appid uint64) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
-	err := _onosc.Request("vs_on", "", appid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.router.Request("vs_on", "", appid)
+	utils.LogWarning(err)
 }
 
-/*func ONOSDeactivateVirtualService(appid uint64) {
+/*func (n *Node) ONOSDeactivateVirtualService(appid uint64) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
-	err := _onosc.Request("vs_off", "", appid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.router.Request("vs_off", "", appid)
+	utils.LogWarning(err)
 }*/
 
-func ONOSDeleteVirtualService(appid uint64) {
+func (n *Node) ONOSDeleteVirtualService(appid uint64) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
-	err := _onosc.Request("vs_del", "", appid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.router.Request("vs_del", "", appid)
+	utils.LogWarning(err)
 }
 
-func ONOSAddVSInstance(ctx context.Context, appid, rcid uint64, nip net.IP) {
+func (n *Node) ONOSAddVSInstance(ctx context.Context, appid, rcid uint64, nip net.IP) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
-	port, err := getContainerPortInfo(ctx, GetContainerName(rcid))
-	utils.CheckError(err, utils.WarningMode)
+	port, err := n.getContainerPortInfo(ctx, GetContainerName(rcid))
+	utils.LogWarning(err)
 
 	if err == nil {
 		inst := types.ONOSVSInstance{
@@ -75,17 +75,17 @@ func ONOSAddVSInstance(ctx context.Context, appid, rcid uint64, nip net.IP) {
 			Port:     port.PublicPort,
 		}
 
-		err = _onosc.Request("inst_add", utils.MarshalJSON(inst), appid)
-		utils.CheckError(err, utils.WarningMode)
+		err = n.router.Request("inst_add", utils.MarshalJSON(inst), appid)
+		utils.LogWarning(err)
 	}
 }
 
-func ONOSDeleteVSInstance(appid, rcid uint64) {
+func (n *Node) ONOSDeleteVSInstance(appid, rcid uint64) {
 
-	if !_onosc.Enabled {
+	if n.router == nil {
 		return
 	}
 
-	err := _onosc.Request("inst_del", "", appid, rcid)
-	utils.CheckError(err, utils.WarningMode)
+	err := n.router.Request("inst_del", "", appid, rcid)
+	utils.LogWarning(err)
 }
@@ -1,9 +1,15 @@
 package managers
 
 import (
-	"context"
+	"encoding/pem"
+	"errors"
+	"github.com/swarleynunez/hidra/core/onos"
+	"github.com/swarleynunez/hidra/core/onos/onostest"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 var (
@@ -11,95 +17,133 @@ var (
 	rcid  uint64 = 1
 )
 
-func init() {
+// Node with only a service router (ONOS client connected to the fake controller)
+func newONOSTestNode(t *testing.T, srv *onostest.Server, opts onos.Options) (*Node, *onos.Client) {
 
-	InitNode(context.Background(), false)
-}
+	opts.Host = srv.Listener.Addr().String()
+	opts.User, opts.Pass = srv.User, srv.Pass
+	opts.RetryBackoff = 10 * time.Millisecond
 
-func TestONOSRequest(t *testing.T) {
+	onosc, err := onos.NewClient(opts)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
 
-	ONOSAddVirtualService(appid, "NGINX V1", net.IP("192.168.0.10"), "TCP", 8888)
-	ONOSAddVirtualService(appid, "NGINX VX", net.IP("192.168.0.20"), "UDP", 1234)
-	ONOSAddVirtualService(appid+1, "NGINX V2", net.IP("192.168.0.11"), "TCP", 8888)
-	ONOSAddVirtualService(appid+2, "NGINX V3", net.IP("192.168.0.11"), "TCP", 8080)
-	ONOSAddVirtualService(appid+3, "NGINX VX", net.IP("192.168.0.10"), "TCP", 8888)
-	ONOSAddVSInstance(context.Background(), appid, rcid, net.IP("172.19.202.107"))
-	ONOSAddVSInstance(context.Background(), appid, rcid, net.IP("172.19.202.107"))
-	ONOSAddVSInstance(context.Background(), appid, rcid, net.IP("172.19.202.108"))
-	ONOSAddVSInstance(context.Background(), appid, rcid, net.IP("172.19.202.109"))
-	ONOSDeleteVSInstance(appid, rcid)
-	ONOSDeleteVSInstance(appid+1, rcid)
+	return &Node{router: onosc}, onosc
 }
 
-/*func TestONOSGetAllVServices(t *testing.T) {
+func TestONOSRequest(t *testing.T) {
 
-	err := onosc.Request("vss", "")
-	utils.CheckError(err, utils.WarningMode)
-}
+	srv := onostest.NewServer("onos", "rocks")
+	defer srv.Close()
+	n, onosc := newONOSTestNode(t, srv, onos.Options{})
+
+	n.ONOSAddVirtualService(appid, "NGINX V1", net.ParseIP("192.168.0.10"), "tcp", 8888)
+	n.ONOSAddVirtualService(appid+1, "NGINX V2", net.ParseIP("192.168.0.11"), "udp", 1234)
+	n.ONOSActivateVirtualService(appid)
+
+	vs, found := srv.VirtualService(appid)
+	if !found ||
+		vs.State != "ON" ||
+		vs.Server.IP != "192.168.0.10" ||
+		vs.Server.Protocol != "TCP" ||
+		vs.Server.Port != 8888 {
+		t.Fatal("ERROR:", t.Name())
+	}
 
-func TestONOSGetAllVServicesOn(t *testing.T) {
+	n.ONOSDeleteVirtualService(appid + 1)
+	if _, found = srv.VirtualService(appid + 1); found {
+		t.Fatal("ERROR:", t.Name())
+	}
 
-	err := onosc.Request("vss_on", "")
-	utils.CheckError(err, utils.WarningMode)
+	// Wrong credentials are neither retried nor queued
+	onosc, _ = onos.NewClient(onos.Options{Host: srv.Listener.Addr().String(), User: "onos", Pass: "wrong"})
+	requests := srv.Requests()
+	if err := onosc.Request("vs_on", "", appid); err == nil ||
+		onosc.Degraded() ||
+		srv.Requests() != requests+1 {
+		t.Fatal("ERROR:", t.Name())
+	}
 }
 
-func TestONOSGetAllVServicesOff(t *testing.T) {
-
-	err := onosc.Request("vss_off", "")
-	utils.CheckError(err, utils.WarningMode)
-}
+func TestONOSRetries(t *testing.T) {
 
-func TestONOSActivateVService(t *testing.T) {
+	srv := onostest.NewServer("onos", "rocks")
+	defer srv.Close()
+	_, onosc := newONOSTestNode(t, srv, onos.Options{MaxRetries: 2})
 
-	err := onosc.Request("vs_on", "", vsid)
-	utils.CheckError(err, utils.WarningMode)
-}
+	// Idempotent routes are retried before giving up
+	srv.SetAvailable(false)
+	if err := onosc.Request("ping", ""); err == nil || srv.Requests() != 3 {
+		t.Fatal("ERROR:", t.Name())
+	}
 
-func TestONOSGetVService(t *testing.T) {
+	// State-changing routes are not retried
+	for _, rname := range []string{"vs_add", "vs_on", "vs_off", "inst_add", "inst_del", "vs_del"} {
+		if onos.Routes[rname].Idempotent {
+			t.Fatal("ERROR:", t.Name(), rname)
+		}
+	}
 
-	err := onosc.Request("vs", "", vsid)
-	utils.CheckError(err, utils.WarningMode)
+	// Retries disabled
+	_, onosc = newONOSTestNode(t, srv, onos.Options{})
+	requests := srv.Requests()
+	if err := onosc.Request("ping", ""); err == nil || srv.Requests() != requests+1 {
+		t.Fatal("ERROR:", t.Name())
+	}
 }
 
-func TestONOSDeactivateVService(t *testing.T) {
+func TestONOSDegradedMode(t *testing.T) {
 
-	err := onosc.Request("vs_off", "", vsid)
-	utils.CheckError(err, utils.WarningMode)
-}
+	srv := onostest.NewServer("onos", "rocks")
+	defer srv.Close()
+	n, onosc := newONOSTestNode(t, srv, onos.Options{MaxRetries: 1})
 
-func TestONOSSetVServiceServer(t *testing.T) {
+	// VS operations are queued while the controller is unreachable
+	srv.SetAvailable(false)
+	n.ONOSAddVirtualService(appid, "NGINX V1", net.ParseIP("192.168.0.10"), "tcp", 8888)
+	n.ONOSActivateVirtualService(appid)
+	n.ONOSDeleteVSInstance(appid, rcid)
+	if !onosc.Degraded() || onosc.QueueLength() != 3 {
+		t.Fatal("ERROR:", t.Name())
+	}
 
-	s := types.ONOSVSServer{
-		IP:       "20.0.0.20",
-		Protocol: "UDP",
-		Port:     1234,
+	// Queued operations are replayed in order once the controller is back
+	srv.SetAvailable(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for onosc.Degraded() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
 	}
 
-	err := onosc.Request("server_set", utils.MarshalJSON(s), vsid)
-	utils.CheckError(err, utils.WarningMode)
+	vs, found := srv.VirtualService(appid)
+	if onosc.Degraded() ||
+		onosc.QueueLength() != 0 ||
+		!found ||
+		vs.State != "ON" {
+		t.Fatal("ERROR:", t.Name())
+	}
 }
 
-func TestONOSAddVServiceInst(t *testing.T) {
+func TestONOSTLS(t *testing.T) {
 
-	inst := types.ONOSVSInstance{
-		ID:       instid + 1,
-		IP:       "192.168.0.10",
-		Protocol: "UDP",
-		Port:     8080,
-	}
+	srv := onostest.NewTLSServer("onos", "rocks")
+	defer srv.Close()
 
-	err := onosc.Request("inst_add", utils.MarshalJSON(inst), vsid)
-	utils.CheckError(err, utils.WarningMode)
-}
+	// Trust the fake controller certificate through a custom CA file
+	ca := filepath.Join(t.TempDir(), "onos-ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, b, 0600); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	_, onosc := newONOSTestNode(t, srv, onos.Options{Scheme: "https", CAFile: ca})
 
-func TestONOSDeleteVServiceInst(t *testing.T) {
+	if err := onosc.Request("ping", ""); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
 
-	err := onosc.Request("inst_del", "", vsid, instid)
-	utils.CheckError(err, utils.WarningMode)
+	// Untrusted certificate: the request fails (no degraded mode)
+	_, onosc = newONOSTestNode(t, srv, onos.Options{Scheme: "https"})
+	if err := onosc.Request("vs_on", "", appid); err == nil || errors.Is(err, onos.ErrRequestQueued) || onosc.Degraded() {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
 }
-
-func TestONOSDeleteVService(t *testing.T) {
-
-	err := onosc.Request("vs_del", "", vsid)
-	utils.CheckError(err, utils.WarningMode)
-}*/
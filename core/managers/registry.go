@@ -0,0 +1,291 @@
+package managers
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/bindings"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// In-memory copy of the on-chain registry (nodes, applications and active containers).
+// Populated once and kept current by the contract event watchers (a nil registry is not enabled)
+type registry struct {
+	mutex  sync.RWMutex
+	nodes  map[common.Address]string     // Encoded node specs
+	byAddr map[string]common.Address     // Advertised IP:PORT
+	byIP   map[string][]common.Address   // Advertised IPs (shared by emulated nodes)
+	apps   map[uint64]*types.Application // Applications of the active containers
+	ctrs   map[uint64]*types.Container   // Active containers
+	insts  map[uint64][]bindings.DCRContainerInstance
+	usage  map[common.Address]*resourceUsage // Limits of the hosted active containers
+}
+
+type resourceUsage struct {
+	cpu uint64
+	mem uint64
+}
+
+// Registry //
+// Only the daemon keeps the registry (one-shot commands read the chain)
+func (n *Node) EnableRegistry() {
+
+	if n.registry == nil {
+		n.registry = &registry{}
+	}
+	n.SyncRegistry()
+}
+
+// Full registry reload (node registrations, spec updates and container activations emit no events)
+func (n *Node) SyncRegistry() {
+
+	if n.registry == nil {
+		return
+	}
+
+	nodes := n.fetchAllNodeSpecs()
+	ctrs := n.fetchActiveContainers()
+	apps := make(map[uint64]*types.Application)
+	insts := make(map[uint64][]bindings.DCRContainerInstance)
+	for rcid, ctr := range ctrs {
+		insts[rcid] = n.fetchContainerInstances(rcid)
+		if apps[ctr.Appid] == nil {
+			apps[ctr.Appid] = n.fetchApplication(ctr.Appid)
+		}
+	}
+
+	r := n.registry
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nodes, r.apps, r.ctrs, r.insts = nodes, apps, ctrs, insts
+	r.indexNodes()
+	r.computeUsage()
+
+	utils.Debug("Registry synced", "nodes", len(nodes), "containers", len(ctrs), "applications", len(apps))
+}
+
+// Reload the specs of a node
+func (n *Node) RefreshNode(addr common.Address) {
+
+	if n.registry == nil || !n.IsNodeRegistered(addr) {
+		return
+	}
+
+	specs := n.fetchNodeSpecs(addr)
+
+	r := n.registry
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nodes[addr] = specs
+	r.indexNodes()
+}
+
+// Reload a container, its instances and its application (registered, solved, updated or unregistered)
+func (n *Node) RefreshContainer(rcid uint64) {
+
+	if n.registry == nil || !n.existContainer(rcid) {
+		return
+	}
+
+	ctr := n.fetchContainer(rcid)
+	active := n.isContainerActive(rcid)
+	app := n.fetchApplication(ctr.Appid)
+	var insts []bindings.DCRContainerInstance
+	if active {
+		insts = n.fetchContainerInstances(rcid)
+	}
+
+	r := n.registry
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if active {
+		r.ctrs[rcid], r.insts[rcid] = ctr, insts
+	} else {
+		delete(r.ctrs, rcid)
+		delete(r.insts, rcid)
+	}
+	r.apps[ctr.Appid] = app
+	r.computeUsage()
+}
+
+// Indexes (the mutex must be held) //
+func (r *registry) indexNodes() {
+
+	r.byAddr = make(map[string]common.Address)
+	r.byIP = make(map[string][]common.Address)
+	for addr, encoded := range r.nodes {
+		var specs types.NodeSpecs
+		utils.UnmarshalJSON(encoded, &specs)
+
+		port := strconv.FormatUint(uint64(specs.Port), 10)
+		for _, ip := range appendIP([]net.IP{specs.IP}, specs.Addrs...) {
+			if ip == nil {
+				continue
+			}
+			r.byAddr[net.JoinHostPort(ip.String(), port)] = addr
+			r.byIP[ip.String()] = append(r.byIP[ip.String()], addr)
+		}
+	}
+}
+
+// Hosts are the nodes of the last instances
+func (r *registry) computeUsage() {
+
+	r.usage = make(map[common.Address]*resourceUsage)
+	for rcid, ctr := range r.ctrs {
+		insts := r.insts[rcid]
+		if len(insts) == 0 {
+			continue
+		}
+
+		// Decode container info
+		var cinfo types.ContainerInfo
+		utils.UnmarshalJSON(ctr.Info, &cinfo)
+
+		host := insts[len(insts)-1].Host
+		if r.usage[host] == nil {
+			r.usage[host] = &resourceUsage{}
+		}
+		r.usage[host].cpu += cinfo.CpuLimit
+		r.usage[host].mem += cinfo.MemLimit
+	}
+}
+
+// Getters (copies, nil-safe) //
+func (r *registry) allNodeSpecs() (map[common.Address]string, bool) {
+
+	if r == nil {
+		return nil, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	nodes := make(map[common.Address]string, len(r.nodes))
+	for addr, specs := range r.nodes {
+		nodes[addr] = specs
+	}
+
+	return nodes, true
+}
+
+func (r *registry) nodeSpecs(addr common.Address) (string, bool) {
+
+	if r == nil {
+		return "", false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	specs, found := r.nodes[addr]
+
+	return specs, found
+}
+
+func (r *registry) nodeAddress(ip net.IP, port string) (common.Address, bool) {
+
+	if r == nil {
+		return common.Address{}, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Exact IP:PORT or, from ephemeral ports, the only node advertising the IP
+	if addr, found := r.byAddr[net.JoinHostPort(ip.String(), port)]; found {
+		return addr, true
+	}
+	if addrs := r.byIP[ip.String()]; len(addrs) == 1 {
+		return addrs[0], true
+	}
+
+	return common.Address{}, false
+}
+
+func (r *registry) hostUsage(addr common.Address) (resourceUsage, bool) {
+
+	if r == nil {
+		return resourceUsage{}, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if u := r.usage[addr]; u != nil {
+		return *u, true
+	}
+
+	return resourceUsage{}, true
+}
+
+func (r *registry) application(appid uint64) *types.Application {
+
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if a := r.apps[appid]; a != nil {
+		app := *a
+		return &app
+	}
+
+	return nil
+}
+
+func (r *registry) container(rcid uint64) *types.Container {
+
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if c := r.ctrs[rcid]; c != nil {
+		ctr := *c
+		return &ctr
+	}
+
+	return nil
+}
+
+func (r *registry) instances(rcid uint64) ([]bindings.DCRContainerInstance, bool) {
+
+	if r == nil {
+		return nil, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	insts, found := r.insts[rcid]
+
+	return append([]bindings.DCRContainerInstance(nil), insts...), found
+}
+
+func (r *registry) activeContainers() (map[uint64]*types.Container, bool) {
+
+	if r == nil {
+		return nil, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ctrs := make(map[uint64]*types.Container, len(r.ctrs))
+	for rcid, c := range r.ctrs {
+		ctr := *c
+		ctrs[rcid] = &ctr
+	}
+
+	return ctrs, true
+}
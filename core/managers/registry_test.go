@@ -0,0 +1,84 @@
+package managers_test
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"github.com/swarleynunez/hidra/inputs"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	cached, owner := c.Nodes[0], c.Nodes[1]
+	cached.EnableRegistry()
+
+	port := strconv.Itoa(simnet.BasePort + 2)
+	if len(cached.GetAllNodeSpecs()) != clusterSize ||
+		cached.GetNodeAddressFromIP("127.0.0.1", port) != c.Nodes[2].GetFromAccount() {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Spec updates are looked up on chain on a cache miss (and cached)
+	naddr := c.Nodes[3].GetFromAccount()
+	var specs types.NodeSpecs
+	utils.UnmarshalJSON(c.Nodes[3].GetNodeSpecs(naddr), &specs)
+	specs.Addrs = append(specs.Addrs, net.ParseIP("10.0.0.3"))
+	if err := c.Nodes[3].UpdateSpecs(ctx, &specs); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	hasIP := func() bool {
+		var cspecs types.NodeSpecs
+		utils.UnmarshalJSON(cached.GetNodeSpecs(naddr), &cspecs)
+		return cspecs.HasIP(net.ParseIP("10.0.0.3"))
+	}
+	if hasIP() || cached.GetNodeAddressFromIP("10.0.0.3", "55555") != naddr || !hasIP() {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Container hosted by node 2 (refreshed when its event is solved)
+	if err := owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{inputs.CtrInfo}, false); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	etype := types.EventType{RequiredTask: types.NewContainerTask, Resource: types.AllResources}
+	if err := owner.SendEvent(ctx, &etype, 1); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	solveByVotes(t, c, 1, []int{0, 1}, 2)
+	host := c.Nodes[2]
+	host.RunEventTask(ctx, host.GetEvent(1), 1)
+	c.Commit()
+
+	if len(cached.GetActiveContainers()) != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cached.RefreshContainer(1)
+
+	// Same answers as the chain
+	haddr := host.GetFromAccount()
+	var hspecs types.NodeSpecs
+	utils.UnmarshalJSON(cached.GetNodeSpecs(haddr), &hspecs)
+	mem := hspecs.MemTotal - inputs.CtrInfo.MemLimit + 1 // Only without the hosted container
+	insts := cached.GetContainerInstances(1)
+	if len(cached.GetActiveContainers()) != 1 ||
+		len(insts) != 1 || insts[0].Host != haddr ||
+		cached.GetApplication(1).Owner != owner.GetFromAccount() ||
+		cached.CanExecuteContainer(haddr, 0, mem) || owner.CanExecuteContainer(haddr, 0, mem) ||
+		!cached.CanExecuteContainer(haddr, 0, 0) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Full reload
+	cached.SyncRegistry()
+	if len(cached.GetActiveContainers()) != 1 || len(cached.GetAllNodeSpecs()) != clusterSize {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -0,0 +1,103 @@
+package managers
+
+import (
+	"errors"
+	"github.com/swarleynunez/hidra/core/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	claimVersion = "v1"
+
+	// Closed epochs kept as reply evidence
+	MaxEvidenceEpochs = 10
+)
+
+var (
+	errMalformedClaim = errors.New("malformed reputation claim")
+)
+
+// Reputation claims //
+// Canonical encoding (v1;s=SCORE;e=EPOCHS;p=PACKETS;ok=OK_PACKETS;lat=LATENCY_MS).
+// Replies are transactions, so every claim is signed by its replier
+func EncodeReputationClaim(c *types.ReputationClaim) string {
+
+	return claimVersion +
+		";s=" + strconv.FormatFloat(c.Score, 'f', -1, 64) +
+		";e=" + strconv.FormatUint(c.Epochs, 10) +
+		";p=" + strconv.FormatUint(c.TotalPackets, 10) +
+		";ok=" + strconv.FormatUint(c.OKPackets, 10) +
+		";lat=" + strconv.FormatUint(c.Latency, 10)
+}
+
+// Bare scores are accepted as claims without evidence
+func DecodeReputationClaim(s string) (*types.ReputationClaim, error) {
+
+	c := &types.ReputationClaim{}
+	if !strings.HasPrefix(s, claimVersion+";") {
+		score, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errMalformedClaim
+		}
+		c.Score = score
+	} else {
+		fields := strings.Split(s, ";")
+		if len(fields) != 6 {
+			return nil, errMalformedClaim
+		}
+
+		var err error
+		values := make([]string, len(fields)-1)
+		for i, key := range []string{"s", "e", "p", "ok", "lat"} {
+			var found bool
+			if values[i], found = strings.CutPrefix(fields[i+1], key+"="); !found {
+				return nil, errMalformedClaim
+			}
+		}
+		c.Score, err = strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, errMalformedClaim
+		}
+		for i, v := range []*uint64{&c.Epochs, &c.TotalPackets, &c.OKPackets, &c.Latency} {
+			if *v, err = strconv.ParseUint(values[i+1], 10, 64); err != nil {
+				return nil, errMalformedClaim
+			}
+		}
+
+		// Only the canonical encoding (one encoding per claim)
+		if EncodeReputationClaim(c) != s {
+			return nil, errMalformedClaim
+		}
+	}
+
+	// Self-consistent claims (every epoch has packets)
+	if math.IsNaN(c.Score) || c.Score < 0 || c.Score > 1 ||
+		c.OKPackets > c.TotalPackets ||
+		c.TotalPackets < c.Epochs ||
+		(c.Epochs == 0 && c.TotalPackets > 0) {
+		return nil, errMalformedClaim
+	}
+
+	return c, nil
+}
+
+// Claim of a peer reputation (latest closed epochs as evidence)
+func GetReputationClaim(info *types.ReputationInfo) *types.ReputationClaim {
+
+	c := &types.ReputationClaim{Score: info.Score}
+
+	var latency uint64
+	for _, es := range info.Evidence {
+		c.Epochs++
+		c.OKPackets += es.OKPackets
+		c.TotalPackets += es.TotalPackets
+		latency += es.Latency * es.OKPackets
+	}
+	if c.OKPackets > 0 {
+		c.Latency = latency / c.OKPackets
+	}
+
+	return c
+}
@@ -0,0 +1,51 @@
+package managers_test
+
+import (
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/types"
+	"testing"
+)
+
+func TestReputationClaims(t *testing.T) {
+
+	info := types.ReputationInfo{
+		Score: 0.5,
+		Evidence: []types.EpochSummary{
+			{OKPackets: 90, TotalPackets: 100, Latency: 10},
+			{OKPackets: 10, TotalPackets: 100, Latency: 110},
+		},
+	}
+	claim := managers.GetReputationClaim(&info)
+	if claim.Epochs != 2 || claim.OKPackets != 100 || claim.TotalPackets != 200 || claim.Latency != 20 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Deterministic encoding
+	s := managers.EncodeReputationClaim(claim)
+	decoded, err := managers.DecodeReputationClaim(s)
+	if err != nil || s != "v1;s=0.5;e=2;p=200;ok=100;lat=20" || *decoded != *claim {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Bare scores (no evidence)
+	decoded, err = managers.DecodeReputationClaim("0.75")
+	if err != nil || decoded.Score != 0.75 || decoded.Epochs != 0 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Non-canonical or inconsistent claims
+	for _, s = range []string{
+		"v1;s=0.50;e=2;p=200;ok=100;lat=20",
+		"v1;e=2;s=0.5;p=200;ok=100;lat=20",
+		"v1;s=0.5;e=2;p=200;ok=201;lat=20",
+		"v1;s=0.5;e=0;p=200;ok=100;lat=20",
+		"v1;s=1.5;e=2;p=200;ok=100;lat=20",
+		"v1;s=0.5;e=2;p=200;ok=100",
+		"NaN",
+		"-1",
+	} {
+		if _, err = managers.DecodeReputationClaim(s); err == nil {
+			t.Fatal("ERROR:", t.Name(), s)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package managers
+
+import (
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+)
+
+// Re-issued events //
+// Stalled events are superseded by their retries (a late solver must not solve them too)
+func (n *Node) SetRetriedBy(eid, by uint64) {
+
+	n.rmutex.Lock()
+	defer n.rmutex.Unlock()
+
+	if n.retried == nil {
+		n.retried = make(map[uint64]uint64)
+	}
+	n.retried[eid] = by
+}
+
+// Retry of an event (0 if it was not re-issued). Later events are scanned if no retry was seen
+func (n *Node) GetRetriedBy(eid uint64) uint64 {
+
+	n.rmutex.Lock()
+	by := n.retried[eid]
+	n.rmutex.Unlock()
+	if by > 0 {
+		return by
+	}
+
+	state, err := n.GetClusterState()
+	if err != nil {
+		utils.LogWarning(err)
+		return 0
+	}
+
+	for next := eid + 1; next < state.NextEventId; next++ {
+		var etype types.EventType
+		utils.UnmarshalJSON(n.GetEvent(next).EType, &etype)
+		if etype.Retry == eid {
+			n.SetRetriedBy(eid, next)
+			return next
+		}
+	}
+
+	return 0
+}
+
+func (n *Node) isEventRetried(eid uint64) bool {
+	return n.GetRetriedBy(eid) > 0
+}
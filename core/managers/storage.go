@@ -0,0 +1,220 @@
+package managers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/swarleynunez/hidra/core/storage"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/core/utils"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const volumesDir = "volumes" // Container artifacts (under the blob store directory)
+
+var (
+	errNoBlobStore      = errors.New("blob store not configured")
+	errBadArtifact      = errors.New("malformed container artifact (cid and absolute target required)")
+	errArtifactNotLocal = errors.New("artifact not stored by the application owner")
+)
+
+func (n *Node) GetBlobStore() *storage.Store {
+	return n.blobs
+}
+
+// Blob server port advertised in the node specs
+func blobPort(addr string) uint16 {
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0
+	}
+
+	return uint16(p)
+}
+
+// Blob servers of the cluster nodes (first ones tried first)
+func (n *Node) blobPeers(first ...common.Address) (peers []string) {
+
+	seen := map[common.Address]bool{n.from.Address: true}
+	add := func(addr common.Address, encoded string) {
+		if seen[addr] {
+			return
+		}
+		seen[addr] = true
+
+		var specs types.NodeSpecs
+		utils.UnmarshalJSON(encoded, &specs)
+		if specs.BlobPort == 0 || specs.Status == types.DeregisteredNode {
+			return
+		}
+
+		// Every advertised address (primary first)
+		port := strconv.FormatUint(uint64(specs.BlobPort), 10)
+		for _, ip := range appendIP([]net.IP{specs.IP}, specs.Addrs...) {
+			if ip != nil {
+				peers = append(peers, net.JoinHostPort(ip.String(), port))
+			}
+		}
+	}
+
+	for _, addr := range first {
+		add(addr, n.GetNodeSpecs(addr))
+	}
+	for addr, specs := range n.GetAllNodeSpecs() {
+		add(addr, specs)
+	}
+
+	return
+}
+
+// Replicate a blob into the local store (from the given nodes first, then from any node)
+func (n *Node) FetchBlob(ctx context.Context, cid string, from ...common.Address) error {
+
+	if n.blobs == nil {
+		return errNoBlobStore
+	}
+	if n.blobs.Has(cid) {
+		return nil
+	}
+
+	timeout := time.Duration(n.cfg.Storage.FetchTimeout) * time.Second
+	err := n.blobs.Fetch(ctx, cid, n.blobPeers(from...), timeout)
+	if err == nil {
+		utils.Debug("Blob replicated", "cid", cid)
+	}
+
+	return err
+}
+
+// CRUD tasks run by the event solver (blobs already stored locally are not fetched)
+func (n *Node) runBlobTask(ctx context.Context, etype *types.EventType, sender common.Address) error {
+
+	if n.blobs == nil {
+		return errNoBlobStore
+	}
+
+	switch etype.RequiredTask {
+	case types.CreateTask, types.ReadTask:
+		return n.FetchBlob(ctx, etype.Blob, sender)
+	case types.UpdateTask:
+		if err := n.FetchBlob(ctx, etype.Blob, sender); err != nil {
+			return err
+		}
+		return n.DropBlobReplica(etype)
+	case types.DeleteTask:
+		return n.DropBlobReplica(etype)
+	}
+
+	return nil
+}
+
+// Blob removed or replaced by a solved event (every replica holder drops its copy, not only the solver)
+func (n *Node) DropBlobReplica(etype *types.EventType) error {
+
+	if n.blobs == nil {
+		return nil
+	}
+
+	switch etype.RequiredTask {
+	case types.UpdateTask:
+		if etype.Prev != "" && etype.Prev != etype.Blob {
+			return n.blobs.Delete(etype.Prev)
+		}
+	case types.DeleteTask:
+		return n.blobs.Delete(etype.Blob)
+	}
+
+	return nil
+}
+
+// Nodes storing a blob (this node included)
+func (n *Node) CountBlobReplicas(ctx context.Context, cid string) (count int) {
+
+	if n.blobs != nil && n.blobs.Has(cid) {
+		count++
+	}
+
+	timeout := time.Duration(n.cfg.Storage.FetchTimeout) * time.Second
+	for _, peer := range n.blobPeers() {
+		found, err := storage.Probe(ctx, peer, cid, timeout)
+		if err != nil {
+			utils.Debug("Blob probe failed", "peer", peer, "cid", cid, "err", err)
+		} else if found {
+			count++
+		}
+	}
+
+	return
+}
+
+// Artifacts //
+func (n *Node) checkArtifacts(cinfos []types.ContainerInfo) error {
+
+	for i := range cinfos {
+		for _, a := range cinfos[i].Artifacts {
+			if !storage.ValidCID(a.CID) || !path.IsAbs(a.Target) {
+				return fmt.Errorf("%w: %s", errBadArtifact, a.Target)
+			}
+			if n.blobs == nil || !n.blobs.Has(a.CID) {
+				return fmt.Errorf("%w: %s", errArtifactNotLocal, a.CID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Fetch the artifacts of a container and copy them into its volume (read-only binds)
+func (n *Node) materializeArtifacts(ctx context.Context, cinfo *types.ContainerInfo, cname string) (binds []string, err error) {
+
+	if len(cinfo.Artifacts) == 0 {
+		return nil, nil
+	}
+	if n.blobs == nil {
+		return nil, errNoBlobStore
+	}
+
+	// Docker binds require absolute host paths
+	dir, err := filepath.Abs(filepath.Join(n.blobs.Dir(), volumesDir, cname))
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	for i, a := range cinfo.Artifacts {
+		if err = n.FetchBlob(ctx, a.CID); err != nil {
+			return nil, err
+		}
+
+		file := filepath.Join(dir, strconv.Itoa(i)+"-"+path.Base(a.Target))
+		if err = n.blobs.Export(a.CID, file); err != nil {
+			return nil, err
+		}
+		binds = append(binds, file+":"+a.Target+":ro")
+	}
+
+	return binds, nil
+}
+
+func (n *Node) removeArtifacts(cname string) {
+
+	if n.blobs == nil {
+		return
+	}
+
+	err := os.RemoveAll(filepath.Join(n.blobs.Dir(), volumesDir, cname))
+	utils.LogWarning(err)
+}
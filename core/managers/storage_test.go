@@ -0,0 +1,149 @@
+package managers_test
+
+import (
+	"context"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/simnet"
+	"github.com/swarleynunez/hidra/core/types"
+	"github.com/swarleynunez/hidra/inputs"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Send a blob event solved by the last node (the solver runs its event task)
+func runBlobEvent(t *testing.T, c *simnet.Cluster, eid uint64, etype *types.EventType) *types.Event {
+
+	ctx := context.Background()
+	if err := c.Nodes[0].SendEvent(ctx, etype, 0); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	solveByVotes(t, c, eid, []int{1, 2}, clusterSize-1)
+
+	c.Nodes[clusterSize-1].RunEventTask(ctx, c.Nodes[0].GetEvent(eid), eid)
+	c.Commit()
+
+	return c.Nodes[0].GetEvent(eid)
+}
+
+func TestBlobTasks(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	sender, solver := c.Blobs[0], c.Blobs[clusterSize-1]
+
+	v1, err := sender.Put(strings.NewReader("v1"))
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Blobs to create must be stored by the sender
+	missing := strings.Repeat("0", 64)
+	if c.Nodes[0].SendEvent(ctx, &types.EventType{RequiredTask: types.CreateTask, Blob: missing}, 0) == nil ||
+		c.Nodes[0].SendEvent(ctx, &types.EventType{RequiredTask: types.ReadTask, Blob: "x"}, 0) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// CreateTask: replicated from the sender
+	event := runBlobEvent(t, c, 1, &types.EventType{RequiredTask: types.CreateTask, Blob: v1})
+	if event.SolvedAt.Uint64() == 0 || !solver.Has(v1) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// UpdateTask: the previous blob is removed
+	v2, err := sender.Put(strings.NewReader("v2"))
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	event = runBlobEvent(t, c, 2, &types.EventType{RequiredTask: types.UpdateTask, Blob: v2, Prev: v1})
+	if event.SolvedAt.Uint64() == 0 || !solver.Has(v2) || solver.Has(v1) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// ReadTask: the sender gets a replica from the solver
+	if err = sender.Delete(v2); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	event = runBlobEvent(t, c, 3, &types.EventType{RequiredTask: types.ReadTask, Blob: v2})
+	c.Nodes[0].RunEventEndingTask(ctx, event)
+	if event.SolvedAt.Uint64() == 0 || !sender.Has(v2) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// DeleteTask (other replica holders drop their copy once solved)
+	if _, err = c.Blobs[1].Put(strings.NewReader("v2")); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	etype := &types.EventType{RequiredTask: types.DeleteTask, Blob: v2}
+	event = runBlobEvent(t, c, 4, etype)
+	if err = c.Nodes[1].DropBlobReplica(etype); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if event.SolvedAt.Uint64() == 0 || solver.Has(v2) || c.Blobs[1].Has(v2) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Blobs that cannot be replicated are not solved
+	if err = sender.Delete(v1); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	event = runBlobEvent(t, c, 5, &types.EventType{RequiredTask: types.ReadTask, Blob: v1})
+	if event.SolvedAt.Uint64() != 0 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+
+	c := newTestCluster(t)
+	ctx := context.Background()
+	owner, host := c.Nodes[0], c.Nodes[1]
+
+	cid, err := c.Blobs[0].Put(strings.NewReader("conf"))
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Artifacts must be valid and stored by the owner
+	cinfo := inputs.CtrInfo
+	cinfo.Artifacts = []types.Artifact{{CID: cid, Target: "etc/app.conf"}}
+	if owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{cinfo}, false) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	cinfo.Artifacts = []types.Artifact{{CID: strings.Repeat("0", 64), Target: "/etc/app.conf"}}
+	if owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{cinfo}, false) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	cinfo.Artifacts = []types.Artifact{{CID: cid, Target: "/etc/app.conf"}}
+	if err = owner.RegisterApplication(ctx, &inputs.AppInfo, []types.ContainerInfo{cinfo}, false); err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	c.Commit()
+	if owner.CountBlobReplicas(ctx, cid) != 1 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// The host fetches the artifact from the owner and mounts it read-only
+	host.NewContainer(ctx, &cinfo, 1, 1, false)
+	cname := managers.GetContainerName(1)
+	mounts := c.Runtimes[1].Mounts(cname)
+	if !c.Blobs[1].Has(cid) || len(mounts) != len(cinfo.Volumes)+1 {
+		t.Fatal("ERROR:", t.Name())
+	}
+	art := mounts[len(mounts)-1]
+	if art.Destination != "/etc/app.conf" || art.RW {
+		t.Fatal("ERROR:", t.Name())
+	}
+	content, err := os.ReadFile(art.Source)
+	if err != nil || string(content) != "conf" || owner.CountBlobReplicas(ctx, cid) != 2 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	// Volume files are removed with the container
+	host.RemoveContainer(ctx, 1, 1, false)
+	if _, err = os.Stat(art.Source); !os.IsNotExist(err) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/swarleynunez/hidra/core/types"
+	"net/http"
+	"time"
+)
+
+const (
+	namespace = "hidra"
+
+	// DEL phases
+	RepliesPhase = "replies" // NewEvent --> RequiredReplies
+	VotesPhase   = "votes"   // RequiredReplies --> RequiredVotes
+	SolvePhase   = "solve"   // RequiredVotes --> EventSolved
+	TotalPhase   = "total"   // NewEvent --> EventSolved
+
+	// ONOS request outcomes
+	ONOSSuccess     = "success"
+	ONOSQueued      = "queued"
+	ONOSUnreachable = "unreachable"
+	ONOSError       = "error"
+)
+
+var (
+	// Unexported registry (only HIDRA and runtime metrics)
+	_reg = prometheus.NewRegistry()
+
+	delPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "del",
+		Name:      "phase_duration_seconds",
+		Help:      "Duration of the DEL event phases seen by the node.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10), // 0.25s to 128s
+	}, []string{"phase"})
+
+	delStalledEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "del",
+		Name:      "stalled_events_total",
+		Help:      "Events whose phase deadline expired (by last reached phase).",
+	}, []string{"phase"})
+
+	txsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "eth",
+		Name:      "transactions_sent_total",
+		Help:      "Transactions sent to the controller contract.",
+	}, []string{"action"})
+
+	txsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "eth",
+		Name:      "transactions_failed_total",
+		Help:      "Transactions that could not be sent (each attempt).",
+	}, []string{"action"})
+
+	peerPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "peer",
+		Name:      "packets_total",
+		Help:      "Packets exchanged with each peer (simulated losses as lost).",
+	}, []string{"peer", "status"})
+
+	peerReputation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "peer",
+		Name:      "reputation_score",
+		Help:      "Local reputation score of each peer (0-1).",
+	}, []string{"peer"})
+
+	inconsistentClaims = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "peer",
+		Name:      "inconsistent_claims_total",
+		Help:      "Reputation claims of each replier inconsistent with the rest.",
+	}, []string{"replier"})
+
+	containersHosted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "dcr",
+		Name:      "containers_hosted",
+		Help:      "Active DCR containers hosted by the node.",
+	})
+
+	artifactReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "artifact_replicas",
+		Help:      "Nodes storing each artifact of the active containers.",
+	}, []string{"cid"})
+
+	onosRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "onos",
+		Name:      "requests_total",
+		Help:      "Requests to the ONOS controller by route and outcome.",
+	}, []string{"route", "outcome"})
+)
+
+func init() {
+
+	_reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		delPhaseDuration,
+		delStalledEvents,
+		txsSent,
+		txsFailed,
+		peerPackets,
+		peerReputation,
+		inconsistentClaims,
+		containersHosted,
+		artifactReplicas,
+		onosRequests,
+	)
+}
+
+// Endpoint //
+func Handler() http.Handler {
+
+	return promhttp.HandlerFor(_reg, promhttp.HandlerOpts{})
+}
+
+// Blocking (like http.ListenAndServe)
+func Serve(addr string) error {
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// Observers //
+// Observe the phases of a solved event (unix times in ms, phases not seen are skipped)
+func ObserveEventTimes(et *types.EventTimes) {
+
+	observePhase(RepliesPhase, et.Start, et.Replies)
+	observePhase(VotesPhase, et.Replies, et.Votes)
+	observePhase(SolvePhase, et.Votes, et.End)
+	observePhase(TotalPhase, et.Start, et.End)
+}
+
+func StalledEvent(phase string) {
+	delStalledEvents.WithLabelValues(phase).Inc()
+}
+
+func Transaction(action string, err error) {
+
+	if err != nil {
+		txsFailed.WithLabelValues(action).Inc()
+	} else {
+		txsSent.WithLabelValues(action).Inc()
+	}
+}
+
+func Packet(peer common.Address, lost bool) {
+
+	status := "ok"
+	if lost {
+		status = "lost"
+	}
+
+	peerPackets.WithLabelValues(peer.String(), status).Inc()
+}
+
+func PeerReputation(peer common.Address, score float64) {
+
+	peerReputation.WithLabelValues(peer.String()).Set(score)
+}
+
+func InconsistentClaims(replier common.Address, count int) {
+
+	inconsistentClaims.WithLabelValues(replier.String()).Add(float64(count))
+}
+
+func ContainersHosted(count int) {
+
+	containersHosted.Set(float64(count))
+}
+
+// Artifacts no longer used are dropped
+func ArtifactReplicas(replicas map[string]int) {
+
+	artifactReplicas.Reset()
+	for cid, count := range replicas {
+		artifactReplicas.WithLabelValues(cid).Set(float64(count))
+	}
+}
+
+func ONOSRequest(route, outcome string) {
+
+	onosRequests.WithLabelValues(route, outcome).Inc()
+}
+
+// Helpers //
+func observePhase(phase string, from, to int64) {
+
+	if from > 0 && to >= from {
+		d := time.Duration(to-from) * time.Millisecond
+		delPhaseDuration.WithLabelValues(phase).Observe(d.Seconds())
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/swarleynunez/hidra/core/types"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestObserveEventTimes(t *testing.T) {
+
+	// Votes time not seen (only replies and total phases)
+	ObserveEventTimes(&types.EventTimes{Start: 1000, Replies: 1500, End: 4000})
+
+	if testutil.CollectAndCount(delPhaseDuration) != 2 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestCounters(t *testing.T) {
+
+	peer := common.HexToAddress("0x1")
+	Transaction("sendEvent", nil)
+	Transaction("sendEvent", errors.New("nonce too low"))
+	Transaction("sendEvent", errors.New("nonce too low"))
+	Packet(peer, false)
+	Packet(peer, true)
+	PeerReputation(peer, 0.5)
+	ONOSRequest("vs_add", ONOSQueued)
+
+	if testutil.ToFloat64(txsSent.WithLabelValues("sendEvent")) != 1 ||
+		testutil.ToFloat64(txsFailed.WithLabelValues("sendEvent")) != 2 ||
+		testutil.ToFloat64(peerPackets.WithLabelValues(peer.String(), "lost")) != 1 ||
+		testutil.ToFloat64(peerReputation.WithLabelValues(peer.String())) != 0.5 ||
+		testutil.ToFloat64(onosRequests.WithLabelValues("vs_add", ONOSQueued)) != 1 {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	ContainersHosted(2)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	b, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	if rec.Code != 200 ||
+		!strings.Contains(string(b), "hidra_dcr_containers_hosted 2") ||
+		!strings.Contains(string(b), "go_goroutines") {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -1,67 +1,165 @@
 package onos
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"github.com/swarleynunez/hidra/core/config"
 	"github.com/swarleynunez/hidra/core/utils"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
-var (
-	errMalformedIP   = errors.New("malformed onos controller ip")
-	errMalformedPort = errors.New("malformed onos controller port")
+const (
+	defaultPath         = "/onos/vs"
+	defaultTimeout      = 30 * time.Second
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+	defaultQueueSize    = 256
 )
 
+var errMalformedCA = errors.New("no valid certificates found in onos ca file")
+
 // Client for ONOS virtual service API requests
 type Client struct {
 	BaseURL *url.URL     // Base URL to all requests
 	Client  *http.Client // To send and receive requests
 	Enabled bool         // Is the ONOS module enabled?
+
+	user         string        // API basic auth username
+	pass         string        // API basic auth password
+	maxRetries   int           // Retries for idempotent routes
+	retryBackoff time.Duration // Initial delay between retries (doubled on each retry)
+
+	mutex    sync.Mutex
+	degraded bool            // Is the ONOS controller unreachable?
+	queue    []queuedRequest // VS operations waiting for the controller
+	qsize    int             // Maximum queued operations
 }
 
-func Connect() *Client {
+// ONOS client settings
+type Options struct {
+	Scheme       string        // "http" or "https"
+	Host         string        // IP:port of the ONOS controller
+	Path         string        // Base path of the VS API
+	User         string        // API basic auth username
+	Pass         string        // API basic auth password
+	CAFile       string        // Custom CA bundle (PEM) to verify the controller certificate
+	Timeout      time.Duration // Per request timeout
+	MaxRetries   int           // Retries for idempotent routes (0: no retries)
+	RetryBackoff time.Duration // Initial delay between retries
+	QueueSize    int           // Maximum queued VS operations in degraded mode
+}
+
+func Connect(c *config.ONOSConfig) (*Client, error) {
+
+	if !c.Enabled {
+		return &Client{}, nil
+	}
+
+	opts := Options{
+		Scheme:       "http",
+		Host:         net.JoinHostPort(c.ControllerIP, strconv.FormatUint(uint64(c.ControllerPort), 10)),
+		Path:         c.APIPath,
+		User:         c.APIUser,
+		Pass:         c.APIPass,
+		CAFile:       c.TLSCAFile,
+		Timeout:      time.Duration(c.RequestTimeout) * time.Second,
+		MaxRetries:   c.MaxRetries,
+		RetryBackoff: time.Duration(c.RetryBackoff) * time.Millisecond,
+		QueueSize:    int(c.QueueSize),
+	}
+	if c.TLSEnabled {
+		opts.Scheme = "https"
+	}
 
-	enabled, err := strconv.ParseBool(utils.GetEnv("ONOS_ENABLED"))
+	onosc, err := NewClient(opts)
 	if err != nil {
-		enabled = false
+		return nil, err
 	}
 
-	if enabled {
-		ip := utils.GetEnv("ONOS_CONTROLLER_IP")
-		if net.ParseIP(ip) == nil {
-			utils.CheckError(errMalformedIP, utils.FatalMode)
-		}
+	// Check connection (an unreachable controller enables the degraded mode)
+	err = onosc.Request("ping", "")
+	utils.LogWarning(err, "onos", opts.Host)
+
+	return onosc, nil
+}
+
+func NewClient(opts Options) (*Client, error) {
 
-		port, err := strconv.ParseUint(utils.GetEnv("ONOS_CONTROLLER_PORT"), 10, 16)
-		if err != nil || port == 0 {
-			utils.CheckError(errMalformedPort, utils.FatalMode)
+	// Default settings
+	if opts.Scheme == "" {
+		opts.Scheme = "http"
+	}
+	if opts.Path == "" {
+		opts.Path = defaultPath
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+
+	// Custom CA to verify the ONOS controller certificate
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, err
 		}
 
-		// Set ONOS client
-		onosc := &Client{
-			BaseURL: &url.URL{
-				Scheme: "http",
-				Host:   net.JoinHostPort(ip, strconv.FormatUint(port, 10)),
-				Path:   "/onos/vs",
-			},
-			Client: &http.Client{
-				Timeout: 30 * time.Second,
-			},
-			Enabled: true,
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errMalformedCA
 		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
 
-		// Initialize ONOS API routes
-		initRoutes()
+	// Initialize ONOS API routes
+	initRoutes()
 
-		// Check connection
-		err = onosc.Request("ping", "")
-		utils.CheckError(err, utils.FatalMode)
+	return &Client{
+		BaseURL: &url.URL{
+			Scheme: opts.Scheme,
+			Host:   opts.Host,
+			Path:   opts.Path,
+		},
+		Client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+		},
+		Enabled:      true,
+		user:         opts.User,
+		pass:         opts.Pass,
+		maxRetries:   opts.MaxRetries,
+		retryBackoff: opts.RetryBackoff,
+		qsize:        opts.QueueSize,
+	}, nil
+}
 
-		return onosc
-	}
+// Is the client queueing VS operations until the controller is reachable again?
+func (cli *Client) Degraded() bool {
+
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+
+	return cli.degraded
+}
+
+// Number of VS operations waiting to be replayed
+func (cli *Client) QueueLength() int {
+
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
 
-	return &Client{}
+	return len(cli.queue)
 }
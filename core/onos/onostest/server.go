@@ -0,0 +1,230 @@
+package onostest
+
+import (
+	"encoding/json"
+	"github.com/swarleynunez/hidra/core/types"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fake ONOS virtual service controller to run tests without a real controller
+type Server struct {
+	*httptest.Server
+
+	User string // Accepted basic auth username
+	Pass string // Accepted basic auth password
+	Path string // Base path of the VS API
+
+	mutex       sync.Mutex
+	available   bool
+	requests    int
+	vservices   map[uint64]*types.ONOSVirtualService
+	activeState map[uint64]bool
+}
+
+func NewServer(user, pass string) *Server {
+
+	s := newServer(user, pass)
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+func NewTLSServer(user, pass string) *Server {
+
+	s := newServer(user, pass)
+	s.Server = httptest.NewTLSServer(s)
+
+	return s
+}
+
+func newServer(user, pass string) *Server {
+
+	return &Server{
+		User:        user,
+		Pass:        pass,
+		Path:        "/onos/vs",
+		available:   true,
+		vservices:   map[uint64]*types.ONOSVirtualService{},
+		activeState: map[uint64]bool{},
+	}
+}
+
+// Simulate an unreachable controller (503 responses)
+func (s *Server) SetAvailable(available bool) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.available = available
+}
+
+// Number of requests received (including failed ones)
+func (s *Server) Requests() int {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) VirtualService(vsid uint64) (types.ONOSVirtualService, bool) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	vs, found := s.vservices[vsid]
+	if !found {
+		return types.ONOSVirtualService{}, false
+	}
+
+	return *s.copyVS(vs), true
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests++
+
+	if !s.available {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != s.User || pass != s.Pass {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, s.Path) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// Split VS API path into segments
+	var segs []string
+	for _, seg := range strings.Split(strings.TrimPrefix(r.URL.Path, s.Path), "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+
+	status, body := s.route(r, segs)
+	if body != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (s *Server) route(r *http.Request, segs []string) (int, interface{}) {
+
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet: // Ping
+		return http.StatusOK, nil
+	case len(segs) == 1 && segs[0] == "all" && r.Method == http.MethodGet:
+		return http.StatusOK, struct{ VServices []types.ONOSVirtualService }{s.list(nil)}
+	case len(segs) == 1 && segs[0] == "on" && r.Method == http.MethodGet:
+		on := true
+		return http.StatusOK, struct{ VServicesON []types.ONOSVirtualService }{s.list(&on)}
+	case len(segs) == 1 && segs[0] == "off" && r.Method == http.MethodGet:
+		off := false
+		return http.StatusOK, struct{ VServicesOFF []types.ONOSVirtualService }{s.list(&off)}
+	case len(segs) == 1 && segs[0] == "add" && r.Method == http.MethodPost:
+		var vs types.ONOSVirtualService
+		if err := json.NewDecoder(r.Body).Decode(&vs); err != nil {
+			return http.StatusBadRequest, nil
+		}
+		if _, found := s.vservices[vs.ID]; found {
+			return http.StatusConflict, nil
+		}
+		vs.State = "OFF"
+		s.vservices[vs.ID] = &vs
+		return http.StatusOK, nil
+	}
+
+	// Routes with a VS identifier
+	if len(segs) == 0 {
+		return http.StatusNotFound, nil
+	}
+	vsid, err := strconv.ParseUint(segs[0], 10, 64)
+	if err != nil {
+		return http.StatusNotFound, nil
+	}
+	vs, found := s.vservices[vsid]
+	if !found {
+		return http.StatusNotFound, nil
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		return http.StatusOK, struct{ VService types.ONOSVirtualService }{*s.copyVS(vs)}
+	case len(segs) == 2 && segs[1] == "on" && r.Method == http.MethodGet:
+		s.activeState[vsid] = true
+		vs.State = "ON"
+		return http.StatusOK, nil
+	case len(segs) == 2 && segs[1] == "off" && r.Method == http.MethodGet:
+		s.activeState[vsid] = false
+		vs.State = "OFF"
+		return http.StatusOK, nil
+	case len(segs) == 2 && segs[1] == "del" && r.Method == http.MethodGet:
+		delete(s.vservices, vsid)
+		delete(s.activeState, vsid)
+		return http.StatusOK, nil
+	case len(segs) == 2 && segs[1] == "addinstance" && r.Method == http.MethodPost:
+		var inst types.ONOSVSInstance
+		if err := json.NewDecoder(r.Body).Decode(&inst); err != nil {
+			return http.StatusBadRequest, nil
+		}
+		for _, v := range vs.Instances {
+			if v.ID == inst.ID {
+				return http.StatusConflict, nil
+			}
+		}
+		vs.Instances = append(vs.Instances, inst)
+		return http.StatusOK, nil
+	case len(segs) == 3 && segs[2] == "del" && r.Method == http.MethodGet:
+		instid, err := strconv.ParseUint(segs[1], 10, 64)
+		if err != nil {
+			return http.StatusNotFound, nil
+		}
+		for i, v := range vs.Instances {
+			if v.ID == instid {
+				vs.Instances = append(vs.Instances[:i], vs.Instances[i+1:]...)
+				return http.StatusOK, nil
+			}
+		}
+		return http.StatusNotFound, nil
+	}
+
+	return http.StatusNotFound, nil
+}
+
+// VSs sorted by ID (optionally filtered by activation state)
+func (s *Server) list(active *bool) (vss []types.ONOSVirtualService) {
+
+	for vsid, vs := range s.vservices {
+		if active == nil || s.activeState[vsid] == *active {
+			vss = append(vss, *s.copyVS(vs))
+		}
+	}
+	sort.Slice(vss, func(i, j int) bool { return vss[i].ID < vss[j].ID })
+
+	return
+}
+
+func (s *Server) copyVS(vs *types.ONOSVirtualService) *types.ONOSVirtualService {
+
+	c := *vs
+	c.Instances = append([]types.ONOSVSInstance(nil), vs.Instances...)
+
+	return &c
+}
@@ -1,81 +1,227 @@
 package onos
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"github.com/swarleynunez/hidra/core/metrics"
 	"github.com/swarleynunez/hidra/core/utils"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
+	ErrRequestQueued = errors.New("onos controller unreachable, request queued")
+
 	errUnauthorized     = errors.New("wrong onos username or password")
 	errResourceNotFound = errors.New("onos resource not found")
+	errUnavailable      = errors.New("onos controller unavailable")
 	errUnsuccessfulReq  = errors.New("unsuccessful onos request")
 	errRouteNotFound    = errors.New("onos route not found")
 	errParamsMismatch   = errors.New("parameter count mismatch")
+	errQueueFull        = errors.New("onos request queue is full")
 )
 
-func (cli *Client) Request(rname, body string, params ...uint64) error {
+// VS operation waiting for the ONOS controller to be reachable
+type queuedRequest struct {
+	rname  string
+	body   string
+	params []uint64
+}
+
+func (cli *Client) Request(rname, body string, params ...uint64) (err error) {
+
+	defer func() {
+		metrics.ONOSRequest(rname, requestOutcome(err))
+	}()
 
 	// Get route by action name
-	if r, found := Routes[rname]; found {
-		path, err := parsePath(r.Path, params)
-		if err != nil {
-			return err
+	r, found := Routes[rname]
+	if !found {
+		return errRouteNotFound
+	}
+
+	path, err := parsePath(r.Path, params)
+	if err != nil {
+		return
+	}
+
+	// Keep VS operations ordered while the controller is unreachable
+	if r.Queueable && cli.Degraded() {
+		return cli.enqueue(rname, body, params)
+	}
+
+	err = cli.send(&r, path, body)
+	if isUnreachable(err) {
+		cli.setDegraded()
+		if r.Queueable {
+			return cli.enqueue(rname, body, params)
 		}
+	}
 
-		// Set request using parsed path
-		url := cli.BaseURL.String() + path
-		req, err := http.NewRequest(r.Method, url, strings.NewReader(body))
-		if err != nil {
-			return err
+	return
+}
+
+// Send a request retrying transient failures on idempotent routes
+func (cli *Client) send(r *Route, path, body string) (err error) {
+
+	attempts := 1
+	if r.Idempotent {
+		attempts += cli.maxRetries
+	}
+
+	backoff := cli.retryBackoff
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
 		}
 
-		// HTTP headers
-		req.SetBasicAuth(utils.GetEnv("ONOS_API_USER"), utils.GetEnv("ONOS_API_PASS"))
-		if r.Method == "POST" {
-			req.Header.Set("Content-Type", "application/json")
+		err = cli.do(r, path, body)
+		if !isUnreachable(err) {
+			return
 		}
-		req.Close = true
+	}
+
+	return
+}
+
+func (cli *Client) do(r *Route, path, body string) error {
+
+	// Set request using parsed path
+	url := cli.BaseURL.String() + path
+	req, err := http.NewRequest(r.Method, url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// HTTP headers
+	req.SetBasicAuth(cli.user, cli.pass)
+	if r.Method == "POST" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Close = true
 
-		// Send request
-		res, err := cli.Client.Do(req)
+	// Send request
+	res, err := cli.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// Deferring the response body closure
+	defer res.Body.Close()
+
+	// Check HTTP response status code
+	switch res.StatusCode {
+	case 200:
+		// Parse response body
+		var rb strings.Builder
+		_, err = io.Copy(&rb, res.Body)
 		if err != nil {
 			return err
 		}
 
-		// Deferring the response body closure
-		defer res.Body.Close()
-
-		// Check HTTP response status code
-		switch res.StatusCode {
-		case 200:
-			// Parse response body
-			var rb strings.Builder
-			_, err = io.Copy(&rb, res.Body)
-			if err != nil {
-				return err
-			}
-
-			// Handle response body
-			r.Handler(rb.String())
-
-			return nil
-		case 401:
-			return errUnauthorized
-		case 404:
-			return errResourceNotFound
-		default:
-			return errUnsuccessfulReq
+		// Handle response body
+		r.Handler(rb.String())
+
+		return nil
+	case 401:
+		return errUnauthorized
+	case 404:
+		return errResourceNotFound
+	case 502, 503, 504:
+		return errUnavailable
+	default:
+		return errUnsuccessfulReq
+	}
+}
+
+// Degraded mode //
+func (cli *Client) enqueue(rname, body string, params []uint64) error {
+
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+
+	if len(cli.queue) >= cli.qsize {
+		return errQueueFull
+	}
+	cli.queue = append(cli.queue, queuedRequest{rname: rname, body: body, params: params})
+
+	return ErrRequestQueued
+}
+
+func (cli *Client) setDegraded() {
+
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+
+	// Is the recovery loop already running?
+	if cli.degraded {
+		return
+	}
+	cli.degraded = true
+
+	go cli.reconnect()
+}
+
+// Wait for the controller and replay the queued VS operations in order
+func (cli *Client) reconnect() {
+
+	ping := Routes["ping"]
+	backoff := cli.retryBackoff
+
+	for {
+		time.Sleep(backoff)
+		if backoff < maxRetryBackoff {
+			backoff *= 2
+		}
+
+		if err := cli.do(&ping, ping.Path, ""); err != nil {
+			continue
+		}
+
+		if cli.replay() {
+			return
 		}
 	}
+}
 
-	return errRouteNotFound
+// Replay queued VS operations (false if the controller became unreachable again)
+func (cli *Client) replay() bool {
+
+	for {
+		cli.mutex.Lock()
+		if len(cli.queue) == 0 {
+			// Leave degraded mode
+			cli.degraded = false
+			cli.mutex.Unlock()
+			return true
+		}
+		qr := cli.queue[0]
+		cli.mutex.Unlock()
+
+		r := Routes[qr.rname]
+		path, _ := parsePath(r.Path, qr.params)
+		err := cli.send(&r, path, qr.body)
+		if isUnreachable(err) {
+			return false
+		}
+		utils.LogWarning(err)
+
+		// Dequeue the replayed operation
+		cli.mutex.Lock()
+		cli.queue = cli.queue[1:]
+		cli.mutex.Unlock()
+	}
 }
 
+// Helpers //
 func parsePath(path string, params []uint64) (string, error) {
 
 	// Find all parameter template occurrences in path
@@ -94,3 +240,45 @@ func parsePath(path string, params []uint64) (string, error) {
 
 	return path, nil
 }
+
+func requestOutcome(err error) string {
+
+	switch {
+	case err == nil:
+		return metrics.ONOSSuccess
+	case errors.Is(err, ErrRequestQueued):
+		return metrics.ONOSQueued
+	case isUnreachable(err):
+		return metrics.ONOSUnreachable
+	}
+
+	return metrics.ONOSError
+}
+
+// Transport errors and gateway responses mean the controller cannot be reached
+// (TLS misconfigurations are permanent and fail instead)
+func isUnreachable(err error) bool {
+
+	if err == nil || isTLSError(err) {
+		return false
+	}
+
+	var nerr net.Error
+	var uerr *url.Error
+	return errors.Is(err, errUnavailable) || errors.As(err, &nerr) || errors.As(err, &uerr)
+}
+
+func isTLSError(err error) bool {
+
+	var (
+		verr  *tls.CertificateVerificationError
+		aerr  x509.UnknownAuthorityError
+		herr  x509.HostnameError
+		cerr  x509.CertificateInvalidError
+		rerr  tls.RecordHeaderError
+		alert tls.AlertError
+	)
+
+	return errors.As(err, &verr) || errors.As(err, &aerr) || errors.As(err, &herr) ||
+		errors.As(err, &cerr) || errors.As(err, &rerr) || errors.As(err, &alert)
+}
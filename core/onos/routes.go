@@ -2,7 +2,8 @@ package onos
 
 import (
 	"errors"
-	"github.com/swarleynunez/hidra/core/utils"
+	"fmt"
+	"sync"
 )
 
 var (
@@ -11,45 +12,59 @@ var (
 
 // ONOS virtual service API routes
 type Route struct {
-	Method  string            // HTTP method
-	Path    string            // Endpoint path
-	Handler func(body string) // Response handler
+	Method     string            // HTTP method
+	Path       string            // Endpoint path
+	Handler    func(body string) // Response handler
+	Idempotent bool              // Can the request be safely retried?
+	Queueable  bool              // Is it a VS operation to replay in degraded mode?
 }
 
-// Routes mapped by route name
-var Routes = map[string]Route{}
+var (
+	// Routes mapped by route name
+	Routes = map[string]Route{}
+
+	routesOnce sync.Once
+)
 
 func initRoutes() {
 
-	// Named routes. Parameters (any name) between "{" and "}"
-	get("ping", "/", func(body string) {})
-	get("vss", "/all", func(body string) {
-		//var vss struct{ VServices []types.ONOSVirtualService }
-		//utils.UnmarshalJSON(body, &vss)
-		//fmt.Println(vss)
-	})
-	get("vss_on", "/on", func(body string) {
-		//var vss struct{ VServicesON []types.ONOSVirtualService }
-		//utils.UnmarshalJSON(body, &vss)
-		//fmt.Println(vss)
-	})
-	get("vss_off", "/off", func(body string) {
-		//var vss struct{ VServicesOFF []types.ONOSVirtualService }
-		//utils.UnmarshalJSON(body, &vss)
-		//fmt.Println(vss)
-	})
-	get("vs", "/{vs_id}", func(body string) {
-		//var vs struct{ VService types.ONOSVirtualService }
-		//utils.UnmarshalJSON(body, &vs)
-		//fmt.Println(vs)
+	routesOnce.Do(func() {
+		// Named routes. Parameters (any name) between "{" and "}"
+		get("ping", "/", func(body string) {})
+		get("vss", "/all", func(body string) {
+			//var vss struct{ VServices []types.ONOSVirtualService }
+			//utils.UnmarshalJSON(body, &vss)
+			//fmt.Println(vss)
+		})
+		get("vss_on", "/on", func(body string) {
+			//var vss struct{ VServicesON []types.ONOSVirtualService }
+			//utils.UnmarshalJSON(body, &vss)
+			//fmt.Println(vss)
+		})
+		get("vss_off", "/off", func(body string) {
+			//var vss struct{ VServicesOFF []types.ONOSVirtualService }
+			//utils.UnmarshalJSON(body, &vss)
+			//fmt.Println(vss)
+		})
+		get("vs", "/{vs_id}", func(body string) {
+			//var vs struct{ VService types.ONOSVirtualService }
+			//utils.UnmarshalJSON(body, &vs)
+			//fmt.Println(vs)
+		})
+		post("vs_add", "/add", func(body string) {})
+		get("vs_on", "/{vs_id}/on", func(body string) {})
+		get("vs_off", "/{vs_id}/off", func(body string) {})
+		//post("server_set", "/{vs_id}/setserver", func(body string) {})
+		post("inst_add", "/{vs_id}/addinstance", func(body string) {})
+		get("inst_del", "/{vs_id}/{inst_id}/del", func(body string) {})
+		get("vs_del", "/{vs_id}/del", func(body string) {})
+
+		// Read-only routes (VS operations are not retried)
+		idempotent("ping", "vss", "vss_on", "vss_off", "vs")
+
+		// VS operations to queue while the controller is unreachable
+		queueable("vs_add", "vs_on", "vs_off", "inst_add", "inst_del", "vs_del")
 	})
-	post("vs_add", "/add", func(body string) {})
-	get("vs_on", "/{vs_id}/on", func(body string) {})
-	get("vs_off", "/{vs_id}/off", func(body string) {})
-	//post("server_set", "/{vs_id}/setserver", func(body string) {})
-	post("inst_add", "/{vs_id}/addinstance", func(body string) {})
-	get("inst_del", "/{vs_id}/{inst_id}/del", func(body string) {})
-	get("vs_del", "/{vs_id}/del", func(body string) {})
 }
 
 func get(rname, path string, handler func(body string)) {
@@ -57,7 +72,7 @@ func get(rname, path string, handler func(body string)) {
 	if _, found := Routes[rname]; !found {
 		Routes[rname] = Route{Method: "GET", Path: path, Handler: handler}
 	} else {
-		utils.CheckError(errDuplicatedRoute, utils.FatalMode)
+		panic(fmt.Errorf("%w: %q", errDuplicatedRoute, rname)) // Programming error
 	}
 }
 
@@ -66,6 +81,24 @@ func post(rname, path string, handler func(body string)) {
 	if _, found := Routes[rname]; !found {
 		Routes[rname] = Route{Method: "POST", Path: path, Handler: handler}
 	} else {
-		utils.CheckError(errDuplicatedRoute, utils.FatalMode)
+		panic(fmt.Errorf("%w: %q", errDuplicatedRoute, rname)) // Programming error
+	}
+}
+
+func idempotent(rnames ...string) {
+
+	for _, rname := range rnames {
+		r := Routes[rname]
+		r.Idempotent = true
+		Routes[rname] = r
+	}
+}
+
+func queueable(rnames ...string) {
+
+	for _, rname := range rnames {
+		r := Routes[rname]
+		r.Queueable = true
+		Routes[rname] = r
 	}
 }
@@ -0,0 +1,131 @@
+package simnet
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/swarleynunez/hidra/core/config"
+	"github.com/swarleynunez/hidra/core/eth"
+	"github.com/swarleynunez/hidra/core/managers"
+	"github.com/swarleynunez/hidra/core/storage"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	ChainID       = 1337 // Always used by simulated backends
+	BasePort      = 30000
+	passphrase    = "hidra"
+	blockGasLimit = 1000000000
+)
+
+// Initial balance of each node account (1000 ether)
+var nodeBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+
+// Nodes sharing an in-memory chain with fake Docker and ONOS services (and real blob servers)
+type Cluster struct {
+	Backend    *backends.SimulatedBackend
+	Controller common.Address
+	Config     *config.Config
+	Nodes      []*managers.Node
+	Runtimes   []*Runtime // Per node
+	Routers    []*Router  // Per node
+	Blobs      []*storage.Store
+
+	dir     string // Keystores and blob stores
+	servers []*httptest.Server
+}
+
+// Deploy a controller (by the first node) and register all nodes (ports from BasePort)
+func NewCluster(ctx context.Context, size int) (*Cluster, error) {
+
+	dir, err := os.MkdirTemp("", "hidra-cluster-")
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{Config: config.Default(), dir: dir}
+	c.Config.Node.AdvertiseAddrs = "127.0.0.1" // Emulated nodes (loopback blob servers)
+	c.Config.Eth.ChainID = ChainID
+
+	// Node accounts (light scrypt parameters to speed up tests)
+	alloc := core.GenesisAlloc{}
+	deps := make([]*managers.NodeDeps, size)
+	for i := range deps {
+		ks := keystore.NewKeyStore(filepath.Join(dir, strconv.Itoa(i)), keystore.LightScryptN, keystore.LightScryptP)
+		from, err := eth.CreateAccount(ks, passphrase)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if err = ks.Unlock(from, passphrase); err != nil {
+			c.Close()
+			return nil, err
+		}
+		alloc[from.Address] = core.GenesisAccount{Balance: nodeBalance}
+
+		c.Runtimes = append(c.Runtimes, NewRuntime())
+		c.Routers = append(c.Routers, NewRouter())
+		c.Blobs = append(c.Blobs, storage.NewStore(filepath.Join(dir, "blobs-"+strconv.Itoa(i))))
+		c.servers = append(c.servers, httptest.NewServer(storage.Handler(c.Blobs[i])))
+		deps[i] = &managers.NodeDeps{Keystore: ks, Account: from, Runtime: c.Runtimes[i], Router: c.Routers[i], Blobs: c.Blobs[i]}
+	}
+	c.Backend = backends.NewSimulatedBackend(alloc, blockGasLimit)
+
+	// Controller smart contract
+	for i := range deps {
+		deps[i].Chain = c.Backend
+	}
+	deployer, err := managers.NewNode(c.Config, deps[0], true)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.Controller, err = deployer.DeployController(ctx)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.Backend.Commit()
+	c.Config.Eth.Controller = c.Controller.String()
+
+	// Cluster nodes (blob server ports registered from their own settings)
+	for i := range deps {
+		ncfg := *c.Config
+		ncfg.Storage.Addr = c.servers[i].Listener.Addr().String()
+
+		n, err := managers.NewNode(&ncfg, deps[i], false)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if err = n.RegisterNode(ctx, uint16(BasePort+i)); err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.Nodes = append(c.Nodes, n)
+	}
+	c.Backend.Commit()
+
+	return c, nil
+}
+
+// Mine pending transactions
+func (c *Cluster) Commit() {
+	c.Backend.Commit()
+}
+
+func (c *Cluster) Close() {
+
+	if c.Backend != nil {
+		_ = c.Backend.Close()
+	}
+	for _, srv := range c.servers {
+		srv.Close()
+	}
+	_ = os.RemoveAll(c.dir)
+}
@@ -0,0 +1,39 @@
+package simnet
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fake service router recording ONOS requests ("route param1 param2...")
+type Router struct {
+	mutex    sync.Mutex
+	requests []string
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+func (r *Router) Request(rname, body string, params ...uint64) error {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	req := []string{rname}
+	for _, p := range params {
+		req = append(req, strconv.FormatUint(p, 10))
+	}
+	r.requests = append(r.requests, strings.Join(req, " "))
+
+	return nil
+}
+
+func (r *Router) Requests() []string {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.requests...)
+}
@@ -0,0 +1,236 @@
+package simnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	errNoSuchContainer = errors.New("no such container")
+	errNameInUse       = errors.New("container name already in use")
+)
+
+// Fake container runtime (in-memory Docker daemon)
+type Runtime struct {
+	mutex  sync.Mutex
+	images map[string]bool
+	ctrs   map[string]*dockertypes.Container // By name
+	nextID int
+}
+
+func NewRuntime() *Runtime {
+
+	return &Runtime{images: map[string]bool{}, ctrs: map[string]*dockertypes.Container{}}
+}
+
+// Is the container created and running?
+func (rt *Runtime) Running(cname string) bool {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	c, found := rt.ctrs[cname]
+	return found && c.State == "running"
+}
+
+// Images //
+// Bind mounts of a created container
+func (rt *Runtime) Mounts(cname string) []dockertypes.MountPoint {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if c, found := rt.ctrs[cname]; found {
+		return c.Mounts
+	}
+
+	return nil
+}
+
+func (rt *Runtime) ImageList(_ context.Context, _ dockertypes.ImageListOptions) (imgs []dockertypes.ImageSummary, err error) {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	for tag := range rt.images {
+		imgs = append(imgs, dockertypes.ImageSummary{ID: tag, RepoTags: []string{tag}})
+	}
+
+	return
+}
+
+func (rt *Runtime) ImagePull(_ context.Context, ref string, _ dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	rt.images[ref] = true
+
+	return io.NopCloser(bytes.NewReader(nil)), nil
+}
+
+func (rt *Runtime) ImagesPrune(_ context.Context, _ filters.Args) (dockertypes.ImagesPruneReport, error) {
+	return dockertypes.ImagesPruneReport{}, nil
+}
+
+// Containers //
+func (rt *Runtime) ContainerCreate(_ context.Context, config *container.Config, hostConfig *container.HostConfig, _ *network.NetworkingConfig, _ *ocispec.Platform, cname string) (container.CreateResponse, error) {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if rt.ctrs[cname] != nil {
+		return container.CreateResponse{}, errNameInUse
+	}
+
+	rt.nextID++
+	c := &dockertypes.Container{
+		ID:    strconv.Itoa(rt.nextID),
+		Names: []string{"/" + cname},
+		Image: config.Image,
+		State: "created",
+	}
+	for port, bindings := range hostConfig.PortBindings {
+		for _, b := range bindings {
+			hp, _ := strconv.ParseUint(b.HostPort, 10, 16)
+			c.Ports = append(c.Ports, dockertypes.Port{PrivatePort: uint16(port.Int()), PublicPort: uint16(hp), Type: port.Proto()})
+		}
+	}
+	for _, b := range hostConfig.Binds {
+		// SRC:DST[:MODE]
+		parts := strings.SplitN(b, ":", 3)
+		m := dockertypes.MountPoint{Type: "bind", Source: parts[0], RW: true}
+		if len(parts) > 1 {
+			m.Destination = parts[1]
+		}
+		if len(parts) > 2 {
+			m.Mode = parts[2]
+			m.RW = parts[2] != "ro"
+		}
+		c.Mounts = append(c.Mounts, m)
+	}
+	rt.ctrs[cname] = c
+
+	return container.CreateResponse{ID: c.ID}, nil
+}
+
+func (rt *Runtime) ContainerStart(_ context.Context, cname string, _ dockertypes.ContainerStartOptions) error {
+	return rt.setState(cname, "running")
+}
+
+func (rt *Runtime) ContainerRestart(_ context.Context, cname string, _ container.StopOptions) error {
+	return rt.setState(cname, "running")
+}
+
+func (rt *Runtime) ContainerRename(_ context.Context, cname, newName string) error {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	c := rt.ctrs[cname]
+	if c == nil {
+		return errNoSuchContainer
+	}
+	if rt.ctrs[newName] != nil {
+		return errNameInUse
+	}
+
+	delete(rt.ctrs, cname)
+	c.Names = []string{"/" + newName}
+	rt.ctrs[newName] = c
+
+	return nil
+}
+
+func (rt *Runtime) ContainerStop(_ context.Context, cname string, _ container.StopOptions) error {
+	return rt.setState(cname, "exited")
+}
+
+func (rt *Runtime) ContainerRemove(_ context.Context, cname string, _ dockertypes.ContainerRemoveOptions) error {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if rt.ctrs[cname] == nil {
+		return errNoSuchContainer
+	}
+	delete(rt.ctrs, cname)
+
+	return nil
+}
+
+// Only the name filter is supported
+func (rt *Runtime) ContainerList(_ context.Context, opts dockertypes.ContainerListOptions) (ctrs []dockertypes.Container, err error) {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	names := opts.Filters.Get("name")
+	for cname, c := range rt.ctrs {
+		if len(names) > 0 && names[0] != cname {
+			continue
+		}
+		if opts.All || c.State == "running" {
+			ctrs = append(ctrs, *c)
+		}
+	}
+
+	// Deterministic order
+	sort.Slice(ctrs, func(i, j int) bool { return ctrs[i].ID < ctrs[j].ID })
+
+	return
+}
+
+// Empty stats of an existing container
+func (rt *Runtime) ContainerStatsOneShot(_ context.Context, cname string) (dockertypes.ContainerStats, error) {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if rt.ctrs[cname] == nil {
+		return dockertypes.ContainerStats{}, errNoSuchContainer
+	}
+
+	b, err := json.Marshal(dockertypes.StatsJSON{})
+	if err != nil {
+		return dockertypes.ContainerStats{}, err
+	}
+
+	return dockertypes.ContainerStats{Body: io.NopCloser(bytes.NewReader(b))}, nil
+}
+
+// Volumes //
+func (rt *Runtime) DiskUsage(_ context.Context, _ dockertypes.DiskUsageOptions) (dockertypes.DiskUsage, error) {
+	return dockertypes.DiskUsage{}, nil
+}
+
+func (rt *Runtime) VolumesPrune(_ context.Context, _ filters.Args) (dockertypes.VolumesPruneReport, error) {
+	return dockertypes.VolumesPruneReport{}, nil
+}
+
+// Helpers //
+func (rt *Runtime) setState(cname, state string) error {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	c := rt.ctrs[cname]
+	if c == nil {
+		return errNoSuchContainer
+	}
+	c.State = state
+
+	return nil
+}
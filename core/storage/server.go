@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const blobsPath = "/blobs/"
+
+var (
+	errNoPeers = errors.New("no peers to fetch the blob from")
+)
+
+// Blob server (peers fetch blobs by CID, the content is verified by the fetcher)
+func Handler(s *Store) http.Handler {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(blobsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		f, err := s.Open(strings.TrimPrefix(r.URL.Path, blobsPath))
+		if errors.Is(err, errBadCID) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if errors.Is(err, errBlobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", time.Time{}, f)
+	})
+
+	return mux
+}
+
+// Serve the blob store until the context is done
+func Serve(ctx context.Context, addr string, s *Store) error {
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           Handler(s),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Fetch a blob from the first peer (HOST:PORT) that has it
+func (s *Store) Fetch(ctx context.Context, cid string, peers []string, timeout time.Duration) error {
+
+	if !ValidCID(cid) {
+		return errBadCID
+	}
+	if s.Has(cid) {
+		return nil
+	}
+
+	var errs []error
+	for _, peer := range peers {
+		err := s.fetchFrom(ctx, cid, peer, timeout)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", peer, err))
+	}
+	if len(errs) == 0 {
+		return errNoPeers
+	}
+
+	return errors.Join(errs...)
+}
+
+// Has a peer (HOST:PORT) the blob?
+func Probe(ctx context.Context, peer, cid string, timeout time.Duration) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "http://"+peer+blobsPath+cid, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	return false, errors.New(resp.Status)
+}
+
+func (s *Store) fetchFrom(ctx context.Context, cid, peer string, timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+peer+blobsPath+cid, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return s.PutVerified(cid, resp.Body)
+}
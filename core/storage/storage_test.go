@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+
+	s := NewStore(filepath.Join(t.TempDir(), "blobs"))
+
+	cid, err := s.Put(strings.NewReader("hello"))
+	if err != nil || cid != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" || !s.Has(cid) {
+		t.Fatal("ERROR:", t.Name(), cid, err)
+	}
+
+	// Immutable blobs
+	if again, err := s.Put(strings.NewReader("hello")); err != nil || again != cid {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	f, err := s.Open(cid)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	b, _ := io.ReadAll(f)
+	f.Close()
+	if string(b) != "hello" {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Verified content
+	if err = s.PutVerified(cid, strings.NewReader("bye")); err != errCIDMismatch {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if ValidCID(strings.ToUpper(cid)) || ValidCID("../"+cid[3:]) || s.Has("x") {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	blobs, err := s.List()
+	if err != nil || len(blobs) != 1 || blobs[0].CID != cid || blobs[0].Size != 5 {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+	if err = s.Delete(cid); err != nil || s.Has(cid) || s.Delete(cid) != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+
+	src, dst := NewStore(t.TempDir()), NewStore(t.TempDir())
+	cid, err := src.Put(strings.NewReader("blob"))
+	if err != nil {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	srv := httptest.NewServer(Handler(src))
+	defer srv.Close()
+	peer := strings.TrimPrefix(srv.URL, "http://")
+
+	// Peers without the blob are skipped
+	empty := httptest.NewServer(Handler(NewStore(t.TempDir())))
+	defer empty.Close()
+
+	peers := []string{strings.TrimPrefix(empty.URL, "http://"), peer}
+	if err = dst.Fetch(context.Background(), cid, peers, time.Second); err != nil || !dst.Has(cid) {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+
+	missing := strings.Repeat("0", cidLength)
+	if err = dst.Fetch(context.Background(), missing, peers[:1], time.Second); err == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	if err = dst.Fetch(context.Background(), missing, nil, time.Second); err != errNoPeers {
+		t.Fatal("ERROR:", t.Name(), err)
+	}
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/swarleynunez/hidra/core/types"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const cidLength = 2 * sha256.Size // Hex encoded
+
+var (
+	errBadCID       = errors.New("malformed blob cid (hex sha-256)")
+	errCIDMismatch  = errors.New("blob content does not match its cid")
+	errBlobNotFound = errors.New("blob not found")
+)
+
+// Node-local content-addressed blob store (one file per CID)
+type Store struct {
+	dir string // Created with the first blob
+}
+
+func NewStore(dir string) *Store {
+
+	return &Store{dir: dir}
+}
+
+// Lowercase hex SHA-256 (CIDs are also file names)
+func ValidCID(cid string) bool {
+
+	if len(cid) != cidLength || cid != strings.ToLower(cid) {
+		return false
+	}
+	_, err := hex.DecodeString(cid)
+
+	return err == nil
+}
+
+// Store a blob and return its CID (blobs are immutable, storing twice is a no-op)
+func (s *Store) Put(r io.Reader) (string, error) {
+
+	return s.put(r, "")
+}
+
+// Store a blob fetched from a peer (verified against its CID)
+func (s *Store) PutVerified(cid string, r io.Reader) error {
+
+	if !ValidCID(cid) {
+		return errBadCID
+	}
+	_, err := s.put(r, cid)
+
+	return err
+}
+
+func (s *Store) put(r io.Reader, want string) (string, error) {
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+
+	// Content hashed while written to a temporary file
+	tmp, err := os.CreateTemp(s.dir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // Renamed if stored
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cid := hex.EncodeToString(h.Sum(nil))
+	if want != "" && cid != want {
+		return "", errCIDMismatch
+	}
+
+	return cid, os.Rename(tmp.Name(), s.path(cid))
+}
+
+func (s *Store) Open(cid string) (*os.File, error) {
+
+	if !ValidCID(cid) {
+		return nil, errBadCID
+	}
+
+	f, err := os.Open(s.path(cid))
+	if os.IsNotExist(err) {
+		return nil, errBlobNotFound
+	}
+
+	return f, err
+}
+
+func (s *Store) Has(cid string) bool {
+
+	if !ValidCID(cid) {
+		return false
+	}
+	_, err := os.Stat(s.path(cid))
+
+	return err == nil
+}
+
+// Deleting a missing blob is not an error
+func (s *Store) Delete(cid string) error {
+
+	if !ValidCID(cid) {
+		return errBadCID
+	}
+
+	err := os.Remove(s.path(cid))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Store) List() (blobs []types.BlobInfo, err error) {
+
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !ValidCID(e.Name()) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, types.BlobInfo{CID: e.Name(), Size: fi.Size()})
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].CID < blobs[j].CID })
+
+	return blobs, nil
+}
+
+// Copy a blob to a file outside the store
+func (s *Store) Export(cid, path string) error {
+
+	src, err := s.Open(cid)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(cid string) string {
+
+	return filepath.Join(s.dir, cid)
+}
@@ -0,0 +1,111 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Management API models (JSON) //
+type APIApplication struct {
+	Appid        uint64          `json:"appid"`
+	Owner        common.Address  `json:"owner"`
+	Info         ApplicationInfo `json:"info"`
+	Containers   []uint64        `json:"containers"`
+	RegisteredAt int64           `json:"registered_at"` // Unix time
+}
+
+type APIContainer struct {
+	Rcid         uint64         `json:"rcid"`
+	Appid        uint64         `json:"appid"`
+	Host         common.Address `json:"host"` // Last instance host
+	Info         ContainerInfo  `json:"info"`
+	Autodeployed bool           `json:"autodeployed"`
+	RegisteredAt int64          `json:"registered_at"` // Unix time
+}
+
+type APIEvent struct {
+	Eid                uint64         `json:"eid"`
+	Type               EventType      `json:"type"`
+	Sender             common.Address `json:"sender"`
+	Solver             common.Address `json:"solver"`
+	Rcid               uint64         `json:"rcid,omitempty"`
+	HasRequiredReplies bool           `json:"required_replies"`
+	HasRequiredVotes   bool           `json:"required_votes"`
+	SentAt             int64          `json:"sent_at"`             // Unix time
+	SolvedAt           int64          `json:"solved_at,omitempty"` // Unix time
+}
+
+// Event whose phase deadline expired (daemon view)
+type APIStalledEvent struct {
+	Eid       uint64         `json:"eid"`
+	Phase     string         `json:"phase"` // Last reached DEL phase
+	Since     int64          `json:"since"` // Unix time in ms of the last phase
+	Sender    common.Address `json:"sender"`
+	Solver    common.Address `json:"solver"` // Unresponsive solver (RequiredVotes phase)
+	RetriedBy uint64         `json:"retried_by,omitempty"`
+}
+
+// Artifact of the active containers and its replicas
+type APIArtifact struct {
+	CID        string   `json:"cid"`
+	Containers []uint64 `json:"containers"`
+	Replicas   int      `json:"replicas"`   // Nodes storing the blob
+	CheckedAt  int64    `json:"checked_at"` // Unix time in ms
+}
+
+// Peer seen by the packet monitor (current epoch and reputation)
+type APIPeer struct {
+	Node         common.Address `json:"node"`
+	OKPackets    uint64         `json:"ok_packets"`
+	TotalPackets uint64         `json:"total_packets"`
+	Score        float64        `json:"score"`
+	Values       []int          `json:"values"` // Reputation value per epoch
+	Status       string         `json:"status,omitempty"`
+}
+
+type APIReputation struct {
+	Node   common.Address `json:"node"`
+	Score  float64        `json:"score"`
+	Epochs int            `json:"epochs"`
+}
+
+// Requests //
+type APIDeployRequest struct {
+	App        ApplicationInfo `json:"app"`
+	Containers []ContainerInfo `json:"containers"`
+	Autodeploy bool            `json:"autodeploy"`
+}
+
+// Ask the cluster to move a container (the resource is used to choose the solver)
+type APIMigrateRequest struct {
+	Resource resource `json:"res"`
+}
+
+type APIStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Dashboard models //
+type APINode struct {
+	Node  common.Address `json:"node"`
+	Specs NodeSpecs      `json:"specs"`
+	Self  bool           `json:"self"`
+}
+
+// Reputation score given by a node to another one
+type APIReputationEdge struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Score float64        `json:"score"`
+}
+
+// DEL event phase seen by the watchers
+type APITimelineEntry struct {
+	Seq     uint64         `json:"seq"`
+	At      int64          `json:"at"` // Unix time in ms
+	Eid     uint64         `json:"eid"`
+	Phase   string         `json:"phase"`             // NewEvent, RequiredReplies, RequiredVotes, EventSolved or Stalled
+	Node    common.Address `json:"node"`              // Sender or solver
+	Rcid    uint64         `json:"rcid,omitempty"`    // Linked container
+	Replies int            `json:"replies,omitempty"` // Replies when the required ones were reached
+}
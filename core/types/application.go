@@ -19,4 +19,8 @@ type ApplicationInfo struct {
 	IP          net.IP `json:"ip"`    // Virtual service IP
 	Protocol    string `json:"proto"` // Virtual service transport protocol (TCP or UDP)
 	Port        uint16 `json:"port"`  // Virtual service port
+
+	// Placement hint (hosts within the radius or, if not set, the closest host)
+	Clients *NodeLocation `json:"clients,omitempty"` // Location of the application users
+	Radius  float64       `json:"radius,omitempty"`  // In km
 }
@@ -1,10 +1,18 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/docker/go-connections/nat"
 	"math/big"
+	"strconv"
 )
 
+var errUnknownService = errors.New("unknown container service type")
+
+// Names used in rule files
+var serviceNames = []string{"control", "os", "web_server", "database", "daemon", "framework"}
+
 // Container service types
 type serviceType uint8
 
@@ -44,4 +52,42 @@ type ContainerConfig struct {
 	Envs     []string    `json:"envs"`    // Environment variables
 	Volumes  []string    `json:"volumes"` // Binding volumes
 	Ports    nat.PortMap `json:"ports"`   // Binding ports
+
+	// Blobs materialised into the container volume before it is created
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+}
+
+// Blob mounted read-only into a container
+type Artifact struct {
+	CID    string `json:"cid"`
+	Target string `json:"target"` // Absolute file path inside the container
+}
+
+func (st serviceType) String() string {
+
+	return nameOf(serviceNames, int(st))
+}
+
+func (st *serviceType) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(serviceNames, text, errUnknownService)
+	*st = serviceType(i)
+
+	return err
+}
+
+// Encoded container infos carry service types as numbers
+func (st *serviceType) UnmarshalJSON(data []byte) error {
+
+	if n, err := strconv.ParseUint(string(data), 10, 8); err == nil {
+		*st = serviceType(n)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	return st.UnmarshalText([]byte(text))
 }
@@ -10,7 +10,7 @@ import (
 type task uint8
 
 const (
-	// About web resources, storage... TODO: IPFS?
+	// About blobs (content-addressed, replicated to the solver)
 	CreateTask task = iota
 	ReadTask
 	UpdateTask
@@ -37,8 +37,13 @@ type Event struct {
 }
 
 type EventType struct {
-	RequiredTask task     `json:"task"` // Task to be executed locally by cluster nodes
-	Resource     resource `json:"res"`  // Resource used to choose an event solver
+	RequiredTask task             `json:"task"`               // Task to be executed locally by cluster nodes
+	Resource     resource         `json:"res"`                // Resource used to choose an event solver
+	Retry        uint64           `json:"retry,omitempty"`    // Stalled event re-issued by this one
+	Attempt      uint64           `json:"attempt,omitempty"`  // Re-issues of the original event
+	Excluded     []common.Address `json:"excluded,omitempty"` // Nodes that cannot be voted as solvers
+	Blob         string           `json:"blob,omitempty"`     // Blob CID (CRUD tasks)
+	Prev         string           `json:"prev,omitempty"`     // Blob CID replaced by an UpdateTask
 }
 
 type EventReply struct {
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Experiment records (hidra report) //
+// Solved event seen by a node (unix times in ms, 0 if a phase was not seen)
+type ExperimentEvent struct {
+	Eid       uint64         `json:"eid"`
+	Node      common.Address `json:"node"` // Recording node
+	Sender    common.Address `json:"sender"`
+	Solver    common.Address `json:"solver"`
+	Rcid      uint64         `json:"rcid"`
+	Replies   int            `json:"replies"`
+	Start     int64          `json:"start"`
+	RepliesAt int64          `json:"replies_at"`
+	VotesAt   int64          `json:"votes_at"`
+	End       int64          `json:"end"`
+	CpuUsage  float64        `json:"cpu_percent"` // Mean process usage while the event was solved
+	MemRSS    uint64         `json:"mem_rss"`     // Mean process resident memory (bytes)
+	Samples   int            `json:"samples"`     // Process samples taken
+}
+
+type ExperimentSummary struct {
+	Metric string  `json:"metric"`
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
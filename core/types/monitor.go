@@ -1,9 +1,21 @@
 package types
 
+import "time"
+
 // MonitorV1
-type CycleCounter struct { // Rule cycle counter (rcc)
-	Measures uint64
-	Triggers uint64
+type RuleStatus uint8
+
+const (
+	RuleInactive RuleStatus = iota
+	RulePending             // Condition triggered, waiting for the pending time
+	RuleFiring              // Rule action executed
+	RuleResolved            // Condition cleared after firing
+)
+
+type RuleState struct {
+	Status RuleStatus
+	Since  time.Time   // Last status change
+	Value  interface{} // Last evaluated usage
 }
 
 // MonitorV2
@@ -15,7 +27,14 @@ type PacketCounter struct {
 }
 
 // Experiments
-type EventTimes struct {
-	Start int64
-	End   int64
+type EventTimes struct { // Unix times in ms
+	Start   int64 // NewEvent
+	Replies int64 // RequiredReplies
+	Votes   int64 // RequiredVotes
+	End     int64 // EventSolved
+}
+
+func (s RuleStatus) String() string {
+
+	return [...]string{"inactive", "pending", "firing", "resolved"}[s]
 }
@@ -1,11 +1,20 @@
 package types
 
 import (
+	"errors"
 	"github.com/ethereum/go-ethereum/common"
+	"math"
 	"math/big"
 	"net"
+	"strconv"
+	"strings"
 )
 
+// Mean Earth radius (km)
+const earthRadius = 6371.0
+
+var errMalformedLocation = errors.New("malformed location (LAT,LONG in degrees)")
+
 // Node resource types
 type resource uint8
 
@@ -19,6 +28,15 @@ const (
 	AllResources
 )
 
+// Node lifecycle (advertised in the node specs)
+type NodeStatus uint8
+
+const (
+	ActiveNode       NodeStatus = iota
+	DrainingNode                // Hosted containers being migrated, not a solver candidate
+	DeregisteredNode            // Left the cluster (the controller cannot unregister nodes)
+)
+
 // DDR node model
 type NodeData struct {
 	Controller   common.Address
@@ -29,18 +47,90 @@ type NodeData struct {
 
 // Node "static" specifications
 type NodeSpecs struct {
-	Arch      string  `json:"arch"`
-	Cores     uint64  `json:"cores"`       // Logical cores number
-	CpuFreq   float64 `json:"freq,string"` // Physical cores frequency (in MHz)
-	MemTotal  uint64  `json:"mem"`         // In bytes
-	DiskTotal uint64  `json:"disk"`        // In bytes
-	OS        string  `json:"os"`
-	IP        net.IP  `json:"ip"`
-	Port      uint16  `json:"port"` // Due to the emulation of fog nodes
-	//Location  NodeLocation `json:"loc"`
-}
-
-/*type NodeLocation struct {
+	Arch      string        `json:"arch"`
+	Cores     uint64        `json:"cores"`       // Logical cores number
+	CpuFreq   float64       `json:"freq,string"` // Physical cores frequency (in MHz)
+	MemTotal  uint64        `json:"mem"`         // In bytes
+	DiskTotal uint64        `json:"disk"`        // In bytes
+	OS        string        `json:"os"`
+	IP        net.IP        `json:"ip"`                  // Primary advertised address
+	Addrs     []net.IP      `json:"addrs,omitempty"`     // All advertised addresses (multi-homed nodes)
+	Port      uint16        `json:"port"`                // Due to the emulation of fog nodes
+	BlobPort  uint16        `json:"blob_port,omitempty"` // Blob server port (0 if not advertised)
+	Status    NodeStatus    `json:"status,omitempty"`
+	Location  *NodeLocation `json:"loc,omitempty"` // Nil if not advertised
+	Agg       string        `json:"agg,omitempty"` // Reply scores aggregation (empty: topk)
+}
+
+type NodeLocation struct {
 	Latitude  float64 `json:"lat"`
 	Longitude float64 `json:"long"`
-}*/
+}
+
+func (s NodeStatus) String() string {
+
+	names := [...]string{"active", "draining", "deregistered"}
+	if int(s) >= len(names) {
+		return "unknown(" + strconv.Itoa(int(s)) + ")" // Advertised by a newer node
+	}
+
+	return names[s]
+}
+
+// Is the IP one of the advertised addresses?
+func (s *NodeSpecs) HasIP(ip net.IP) bool {
+
+	if ip == nil {
+		return false
+	}
+	if s.IP.Equal(ip) {
+		return true
+	}
+	for _, a := range s.Addrs {
+		if a.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l NodeLocation) String() string {
+
+	return strconv.FormatFloat(l.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+}
+
+// LAT,LONG in decimal degrees
+func (l *NodeLocation) UnmarshalText(text []byte) error {
+
+	lat, long, found := strings.Cut(string(text), ",")
+	if !found {
+		return errMalformedLocation
+	}
+
+	var err error
+	if l.Latitude, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil {
+		return errMalformedLocation
+	}
+	if l.Longitude, err = strconv.ParseFloat(strings.TrimSpace(long), 64); err != nil {
+		return errMalformedLocation
+	}
+	if !(math.Abs(l.Latitude) <= 90) || !(math.Abs(l.Longitude) <= 180) { // NaN included
+		return errMalformedLocation
+	}
+
+	return nil
+}
+
+// Great-circle distance (haversine formula, in km)
+func (l *NodeLocation) Distance(o *NodeLocation) float64 {
+
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dlat := rad(o.Latitude - l.Latitude)
+	dlong := rad(o.Longitude - l.Longitude)
+	h := math.Pow(math.Sin(dlat/2), 2) +
+		math.Cos(rad(l.Latitude))*math.Cos(rad(o.Latitude))*math.Pow(math.Sin(dlong/2), 2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+
+	madrid := &NodeLocation{Latitude: 40.4168, Longitude: -3.7038}
+	paris := &NodeLocation{Latitude: 48.8566, Longitude: 2.3522}
+	sydney := &NodeLocation{Latitude: -33.8688, Longitude: 151.2093}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1 }
+	if madrid.Distance(madrid) != 0 ||
+		!near(madrid.Distance(paris), 1053) ||
+		!near(madrid.Distance(paris), paris.Distance(madrid)) ||
+		!near(madrid.Distance(sydney), 17685) {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	// Antipodes (half of the Earth circumference)
+	a, b := &NodeLocation{Latitude: 10, Longitude: 20}, &NodeLocation{Latitude: -10, Longitude: -160}
+	if !near(a.Distance(b), math.Pi*earthRadius) {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestLocationText(t *testing.T) {
+
+	var l NodeLocation
+	if l.UnmarshalText([]byte("40.4168, -3.7038")) != nil || l.Latitude != 40.4168 || l.Longitude != -3.7038 ||
+		l.String() != "40.4168,-3.7038" {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	for _, s := range []string{"", "40.4", "91,0", "0,181", "NaN,0", "x,1"} {
+		if l.UnmarshalText([]byte(s)) == nil {
+			t.Fatal("ERROR:", t.Name(), s)
+		}
+	}
+}
+
+func TestNodeStatusString(t *testing.T) {
+
+	if ActiveNode.String() != "active" || DeregisteredNode.String() != "deregistered" ||
+		NodeStatus(7).String() != "unknown(7)" {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -18,8 +18,24 @@ type EpochInfo struct {
 }
 
 type ReputationInfo struct {
-	Values []uint8
-	Score  float64
+	Values   []uint8
+	Score    float64
+	Evidence []EpochSummary // Latest closed epochs (reply evidence)
+}
+
+type EpochSummary struct {
+	OKPackets    uint64
+	TotalPackets uint64
+	Latency      uint64 // Mean (ms)
+}
+
+// Reputation score sent in an event reply and the evidence behind it
+type ReputationClaim struct {
+	Score        float64
+	Epochs       uint64 // Summarized epochs (0: no evidence)
+	OKPackets    uint64
+	TotalPackets uint64
+	Latency      uint64 // Mean of the summarized epochs (ms)
 }
 
 type ReputationScoreCounter struct {
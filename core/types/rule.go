@@ -1,14 +1,68 @@
 package types
 
-// TODO: add a task to execute by the enforcer
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	errEmptyRuleName     = errors.New("rule name not set")
+	errUnknownResource   = errors.New("unknown rule resource")
+	errUnknownMetric     = errors.New("unknown rule metric type")
+	errUnknownComparator = errors.New("unknown rule comparator")
+	errUnknownRuleAction = errors.New("unknown rule action")
+	errMetricNotAllowed  = errors.New("metric type not supported by the rule resource")
+	errLimitMismatch     = errors.New("rule limit type does not match the metric type")
+	errLimitOutOfRange   = errors.New("rule limit out of range")
+	errProceedNotSet     = errors.New("proceed action without command or container")
+	errUnknownAggr       = errors.New("unknown rule aggregation")
+	errMixedCondition    = errors.New("condition mixes all, any and resource fields")
+	errEmptyCondition    = errors.New("empty all/any condition")
+	errClearDirection    = errors.New("clear threshold does not release the trigger threshold")
+	errAggrNotNumeric    = errors.New("aggregation requires a numeric metric type")
+	errEmptyScope        = errors.New("rule scope without container or service type")
+)
+
 type Rule struct {
-	NameID     string // Unique
-	Resource   resource
-	MetricType RuleMetricType
-	Comparator RuleComparator
-	Limit      interface{} // uint64, float64 or string
-	Action     action
-	Msg        string
+	NameID        string           `json:"name" yaml:"name"` // Unique
+	RuleCondition `yaml:",inline"` // Single resource condition (if Condition is not set)
+	Condition     *RuleCondition   `json:"condition,omitempty" yaml:"condition,omitempty"` // Composite condition
+	For           uint64           `json:"for,omitempty" yaml:"for,omitempty"`             // Pending time (ms) before firing (0 for the cycle time)
+	Action        action           `json:"action" yaml:"action"`
+	Msg           string           `json:"msg" yaml:"msg"`
+	Proceed       *ProceedTask     `json:"proceed,omitempty" yaml:"proceed,omitempty"` // Only for ProceedAction
+	Scope         *RuleScope       `json:"scope,omitempty" yaml:"scope,omitempty"`     // Hosted containers (the node if not set)
+}
+
+// Hosted containers checked by a rule (each one separately)
+type RuleScope struct {
+	Rcid    uint64       `json:"rcid,omitempty" yaml:"rcid,omitempty"`       // A specific container
+	Service *serviceType `json:"service,omitempty" yaml:"service,omitempty"` // Containers of a service type
+}
+
+// Resource condition (leaf) or AND/OR combination of conditions
+type RuleCondition struct {
+	All []RuleCondition `json:"all,omitempty" yaml:"all,omitempty"` // AND
+	Any []RuleCondition `json:"any,omitempty" yaml:"any,omitempty"` // OR
+
+	Resource    resource       `json:"resource,omitempty" yaml:"resource,omitempty"`
+	MetricType  RuleMetricType `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Comparator  RuleComparator `json:"comparator,omitempty" yaml:"comparator,omitempty"`
+	Limit       interface{}    `json:"limit,omitempty" yaml:"limit,omitempty"`             // Trigger threshold (uint64, float64 or string)
+	Clear       interface{}    `json:"clear,omitempty" yaml:"clear,omitempty"`             // Clear threshold (hysteresis, the limit if not set)
+	Aggregation aggregation    `json:"aggregation,omitempty" yaml:"aggregation,omitempty"` // Over the window samples
+	Window      uint64         `json:"window,omitempty" yaml:"window,omitempty"`           // In ms (0 for the last sample)
+}
+
+// Local task executed by ProceedAction rules
+type ProceedTask struct {
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"` // Local command and its arguments
+	Rcid    uint64   `json:"rcid,omitempty" yaml:"rcid,omitempty"`       // Hosted container to restart
+	Timeout uint64   `json:"timeout,omitempty" yaml:"timeout,omitempty"` // In seconds (0 for no timeout)
 }
 
 // Rule metric types for each resource
@@ -32,6 +86,17 @@ const (
 	GreaterOrEqualComp
 )
 
+// Aggregation functions over a window of samples
+type aggregation uint8
+
+const (
+	LastAggregation aggregation = iota
+	AvgAggregation
+	MinAggregation
+	MaxAggregation
+	P95Aggregation
+)
+
 // Rule actions for the enforcer
 type action uint8
 
@@ -42,3 +107,391 @@ const (
 	ProceedAction                 // Execute something locally
 	SendEventAction               // Ask for cluster help
 )
+
+// Names used in rule files
+var (
+	resourceNames   = []string{"none", "cpu", "mem", "disk", "pkt_sent", "pkt_recv", "all"}
+	metricNames     = []string{"units", "percent", "tag"}
+	comparatorNames = []string{"==", "!=", "<", "<=", ">", ">="}
+	actionNames     = []string{"ignore", "warn", "log", "proceed", "send_event"}
+	aggrNames       = []string{"last", "avg", "min", "max", "p95"}
+)
+
+// Supported metric types per resource
+var resourceMetrics = map[resource][]RuleMetricType{
+	CpuResource:     {PercentMetric},
+	MemResource:     {UnitsMetric, PercentMetric},
+	DiskResource:    {UnitsMetric, PercentMetric},
+	PktSentResource: {UnitsMetric},
+	PktRecvResource: {UnitsMetric},
+}
+
+// Check a rule and convert its limits to the type expected by their metric types
+func (r *Rule) Validate() error {
+
+	if r.NameID == "" {
+		return errEmptyRuleName
+	}
+
+	// Single resource rules are stored as a one leaf condition
+	if r.Condition == nil {
+		c := r.RuleCondition
+		r.Condition = &c
+	} else if !r.RuleCondition.isEmpty() {
+		return ruleError(r, errMixedCondition)
+	}
+
+	if err := r.Condition.validate(); err != nil {
+		return ruleError(r, err)
+	}
+	r.RuleCondition = RuleCondition{}
+	if r.Condition.IsLeaf() {
+		r.RuleCondition = *r.Condition
+	}
+
+	if r.Scope != nil {
+		if r.Scope.Rcid == 0 && r.Scope.Service == nil {
+			return ruleError(r, errEmptyScope)
+		}
+		if r.Scope.Service != nil && int(*r.Scope.Service) >= len(serviceNames) {
+			return ruleError(r, errUnknownService)
+		}
+	}
+
+	if int(r.Action) >= len(actionNames) {
+		return ruleError(r, errUnknownRuleAction)
+	}
+	if r.Action == ProceedAction && (r.Proceed == nil || (len(r.Proceed.Command) == 0 && r.Proceed.Rcid == 0)) {
+		return ruleError(r, errProceedNotSet)
+	}
+
+	return nil
+}
+
+// Does the rule target a hosted container?
+func (r *Rule) Matches(rcid uint64, ctype *ContainerType) bool {
+
+	if r.Scope == nil {
+		return false
+	}
+	if r.Scope.Rcid > 0 && r.Scope.Rcid != rcid {
+		return false
+	}
+	if r.Scope.Service != nil && *r.Scope.Service != ctype.ServiceType {
+		return false
+	}
+
+	return true
+}
+
+func (c *RuleCondition) IsLeaf() bool {
+
+	return len(c.All) == 0 && len(c.Any) == 0
+}
+
+// Leaf conditions in evaluation order
+func (c *RuleCondition) Leaves() (leaves []*RuleCondition) {
+
+	if c.IsLeaf() {
+		return []*RuleCondition{c}
+	}
+
+	for i := range c.All {
+		leaves = append(leaves, c.All[i].Leaves()...)
+	}
+	for i := range c.Any {
+		leaves = append(leaves, c.Any[i].Leaves()...)
+	}
+
+	return
+}
+
+// Resource of a condition (AllResources if it combines several resources)
+func (c *RuleCondition) MainResource() resource {
+
+	leaves := c.Leaves()
+	for _, l := range leaves[1:] {
+		if l.Resource != leaves[0].Resource {
+			return AllResources
+		}
+	}
+
+	return leaves[0].Resource
+}
+
+func (c *RuleCondition) isEmpty() bool {
+
+	return c.IsLeaf() && c.Resource == NoResource && c.Limit == nil && c.Clear == nil
+}
+
+func (c *RuleCondition) validate() error {
+
+	// Combined conditions
+	if !c.IsLeaf() {
+		if (len(c.All) > 0 && len(c.Any) > 0) || c.Resource != NoResource || c.Limit != nil {
+			return errMixedCondition
+		}
+
+		for i := range c.All {
+			if err := c.All[i].validate(); err != nil {
+				return err
+			}
+		}
+		for i := range c.Any {
+			if err := c.Any[i].validate(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// Resource and metric type
+	metrics, found := resourceMetrics[c.Resource]
+	if !found {
+		if c.Resource == NoResource {
+			return errEmptyCondition
+		}
+		return errUnknownResource
+	}
+	var allowed bool
+	for _, mt := range metrics {
+		if mt == c.MetricType {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return errMetricNotAllowed
+	}
+
+	if int(c.Comparator) >= len(comparatorNames) {
+		return errUnknownComparator
+	}
+	if int(c.Aggregation) >= len(aggrNames) {
+		return errUnknownAggr
+	}
+	if c.Aggregation != LastAggregation && c.MetricType == TagMetric {
+		return errAggrNotNumeric
+	}
+
+	// Limit types
+	limit, err := normalizeLimit(c.MetricType, c.Limit)
+	if err != nil {
+		return err
+	}
+	c.Limit = limit
+
+	if c.Clear == nil {
+		c.Clear = c.Limit
+	}
+	clear, err := normalizeLimit(c.MetricType, c.Clear)
+	if err != nil {
+		return err
+	}
+	c.Clear = clear
+
+	return checkClearDirection(c.Comparator, c.Limit, c.Clear)
+}
+
+// The clear threshold must be at the releasing side of the trigger threshold
+func checkClearDirection(comp RuleComparator, limit, clear interface{}) error {
+
+	l, lok := ToFloat64(limit)
+	c, cok := ToFloat64(clear)
+	if !lok || !cok {
+		if limit != clear {
+			return errClearDirection
+		}
+		return nil
+	}
+
+	switch comp {
+	case GreaterComp, GreaterOrEqualComp:
+		if c > l {
+			return errClearDirection
+		}
+	case LessComp, LessOrEqualComp:
+		if c < l {
+			return errClearDirection
+		}
+	default:
+		if c != l {
+			return errClearDirection
+		}
+	}
+
+	return nil
+}
+
+// Numeric values (uint64 or float64) as float64
+func ToFloat64(v interface{}) (float64, bool) {
+
+	switch n := v.(type) {
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+func normalizeLimit(mt RuleMetricType, limit interface{}) (interface{}, error) {
+
+	switch mt {
+	case UnitsMetric:
+		// Non-negative integers
+		switch v := limit.(type) {
+		case uint64:
+			return v, nil
+		case int:
+			if v >= 0 {
+				return uint64(v), nil
+			}
+		case int64:
+			if v >= 0 {
+				return uint64(v), nil
+			}
+		case float64:
+			if v >= 0 && v == math.Trunc(v) && v <= math.MaxUint64 {
+				return uint64(v), nil
+			}
+		default:
+			return nil, errLimitMismatch
+		}
+		return nil, errLimitOutOfRange
+	case PercentMetric:
+		var pct float64
+		switch v := limit.(type) {
+		case float64:
+			pct = v
+		case int:
+			pct = float64(v)
+		case int64:
+			pct = float64(v)
+		case uint64:
+			pct = float64(v)
+		default:
+			return nil, errLimitMismatch
+		}
+		if pct < 0 || pct > 100 {
+			return nil, errLimitOutOfRange
+		}
+		return pct, nil
+	case TagMetric:
+		if v, ok := limit.(string); ok {
+			return v, nil
+		}
+		return nil, errLimitMismatch
+	}
+
+	return nil, errUnknownMetric
+}
+
+func ruleError(r *Rule, err error) error {
+
+	return fmt.Errorf("rule %q: %w", r.NameID, err)
+}
+
+// Encoding (rule files and logs) //
+func (r resource) String() string {
+
+	return nameOf(resourceNames, int(r))
+}
+
+func (mt RuleMetricType) String() string {
+
+	return nameOf(metricNames, int(mt))
+}
+
+func (c RuleComparator) String() string {
+
+	return nameOf(comparatorNames, int(c))
+}
+
+func (a action) String() string {
+
+	return nameOf(actionNames, int(a))
+}
+
+func (ag aggregation) String() string {
+
+	return nameOf(aggrNames, int(ag))
+}
+
+func nameOf(names []string, i int) string {
+
+	if i < len(names) {
+		return names[i]
+	}
+
+	return strconv.Itoa(i)
+}
+
+func (r *resource) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(resourceNames, text, errUnknownResource)
+	*r = resource(i)
+
+	return err
+}
+
+// Encoded event types carry resources as numbers
+func (r *resource) UnmarshalJSON(data []byte) error {
+
+	if n, err := strconv.ParseUint(string(data), 10, 8); err == nil {
+		*r = resource(n)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	return r.UnmarshalText([]byte(text))
+}
+
+func (mt *RuleMetricType) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(metricNames, text, errUnknownMetric)
+	*mt = RuleMetricType(i)
+
+	return err
+}
+
+func (c *RuleComparator) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(comparatorNames, text, errUnknownComparator)
+	*c = RuleComparator(i)
+
+	return err
+}
+
+func (a *action) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(actionNames, text, errUnknownRuleAction)
+	*a = action(i)
+
+	return err
+}
+
+func (ag *aggregation) UnmarshalText(text []byte) error {
+
+	i, err := unmarshalName(aggrNames, text, errUnknownAggr)
+	*ag = aggregation(i)
+
+	return err
+}
+
+func unmarshalName(names []string, text []byte, err error) (int, error) {
+
+	s := strings.ToLower(strings.TrimSpace(string(text)))
+	for i := range names {
+		if names[i] == s {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", err, s)
+}
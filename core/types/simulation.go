@@ -0,0 +1,44 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Cluster simulator (hidra simulate) //
+// Packet performance of a simulated node (seen by its peers)
+type NodeProfile struct {
+	LossProb   uint64 `json:"loss_prob"`   // %
+	MaxLatency uint64 `json:"max_latency"` // ms
+}
+
+// Node crash between two events (Until 0: never recovered)
+type NodeFailure struct {
+	Node  int    `json:"node"`
+	From  uint64 `json:"from"`
+	Until uint64 `json:"until,omitempty"`
+}
+
+type SimulatedNode struct {
+	Index   int            `json:"index"`
+	Address common.Address `json:"address"`
+	Profile NodeProfile    `json:"profile"`
+}
+
+// Simulated times are relative to the event sending (ms)
+type SimulatedEvent struct {
+	Eid         uint64    `json:"eid"` // On-chain ID (0 if every node was down)
+	Sender      int       `json:"sender"`
+	Solver      int       `json:"solver"` // -1 if no solver was voted
+	Phase       string    `json:"phase"`  // Last reached DEL phase
+	RepliesTime uint64    `json:"replies_ms,omitempty"`
+	VotesTime   uint64    `json:"votes_ms,omitempty"`
+	Latency     uint64    `json:"latency_ms,omitempty"`
+	Failed      []int     `json:"failed,omitempty"`
+	Reputations []float64 `json:"reputations"` // Mean score given by the alive peers (per node)
+}
+
+type SimulationReport struct {
+	Seed   int64            `json:"seed"`
+	Nodes  []SimulatedNode  `json:"nodes"`
+	Events []SimulatedEvent `json:"events"`
+}
@@ -0,0 +1,7 @@
+package types
+
+// Blob kept by the node-local content-addressed store
+type BlobInfo struct {
+	CID  string `json:"cid"`  // Hex SHA-256 of the content
+	Size int64  `json:"size"` // In bytes
+}
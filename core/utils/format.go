@@ -152,7 +152,7 @@ func MarshalJSON(v interface{}) string {
 
 	// Encode any struct to JSON
 	bytes, err := json.Marshal(v)
-	CheckError(err, WarningMode)
+	LogWarning(err)
 
 	return string(bytes)
 }
@@ -164,5 +164,5 @@ func UnmarshalJSON(data string, v interface{}) {
 
 	// Decode JSON to any struct
 	err := json.Unmarshal(bytes, v)
-	CheckError(err, WarningMode)
+	LogWarning(err)
 }
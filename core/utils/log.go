@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slog"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// Process logger (logfmt to stderr until InitLogger is called)
+	_logger  = newLogger(os.Stderr, "logfmt", new(slog.LevelVar))
+	_lmutex  sync.RWMutex
+	_logSink io.Closer // Rotated log files (if any)
+
+	errUnknownLogLevel  = errors.New("unknown log level (debug, info, warn or error)")
+	errUnknownLogFormat = errors.New("unknown log format (logfmt or json)")
+)
+
+// Logger settings
+type LogConfig struct {
+	Level      string   // debug, info, warn or error
+	Format     string   // logfmt or json
+	Outputs    []string // stderr, stdout or file paths (rotated by size)
+	MaxSize    int64    // In MB (log files)
+	MaxBackups int      // Rotated files to keep (log files)
+}
+
+func InitLogger(cfg *LogConfig) error {
+
+	level := new(slog.LevelVar)
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("%w: %q", errUnknownLogLevel, cfg.Level)
+	}
+
+	format := strings.ToLower(cfg.Format)
+	if format != "logfmt" && format != "json" {
+		return fmt.Errorf("%w: %q", errUnknownLogFormat, cfg.Format)
+	}
+
+	// Sinks
+	var (
+		writers []io.Writer
+		files   multiCloser
+	)
+	for _, out := range cfg.Outputs {
+		switch out = strings.TrimSpace(out); out {
+		case "", "stderr":
+			writers = append(writers, os.Stderr)
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		default:
+			rf := &RotatingFile{Path: out, MaxSize: cfg.MaxSize * 1024 * 1024, MaxBackups: cfg.MaxBackups}
+			writers = append(writers, rf)
+			files = append(files, rf)
+		}
+	}
+
+	_lmutex.Lock()
+	defer _lmutex.Unlock()
+
+	// Close the previous log files
+	if _logSink != nil {
+		_ = _logSink.Close()
+	}
+	_logSink = files
+
+	_logger = newLogger(io.MultiWriter(writers...), format, level)
+
+	return nil
+}
+
+// Add the node address to every log line
+func SetLogNode(addr string) {
+
+	_lmutex.Lock()
+	defer _lmutex.Unlock()
+
+	_logger = _logger.With("node", addr)
+}
+
+func Logger() *slog.Logger {
+
+	_lmutex.RLock()
+	defer _lmutex.RUnlock()
+
+	return _logger
+}
+
+// Leveled logging (args as key-value pairs: "eid", eid, "rcid", rcid...)
+func Debug(msg string, args ...interface{}) {
+	logAt(slog.LevelDebug, msg, args)
+}
+
+func Info(msg string, args ...interface{}) {
+	logAt(slog.LevelInfo, msg, args)
+}
+
+func Warn(msg string, args ...interface{}) {
+	logAt(slog.LevelWarn, msg, args)
+}
+
+func Error(msg string, args ...interface{}) {
+	logAt(slog.LevelError, msg, args)
+}
+
+// Error logging (nothing is logged for nil errors)
+func LogWarning(err error, args ...interface{}) {
+
+	if err != nil {
+		logAt(slog.LevelWarn, err.Error(), args)
+	}
+}
+
+func LogError(err error, args ...interface{}) {
+
+	if err != nil {
+		logAt(slog.LevelError, err.Error(), args)
+	}
+}
+
+// Only for CLI commands (library code must return its errors)
+func Fatal(err error, args ...interface{}) {
+
+	if err != nil {
+		logAt(slog.LevelError, err.Error(), args)
+		os.Exit(1)
+	}
+}
+
+// Helpers //
+func newLogger(w io.Writer, format string, level *slog.LevelVar) *slog.Logger {
+
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// file.go:line instead of the full path
+			if a.Key == slog.SourceKey {
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					return slog.String("caller", filepath.Base(src.File)+":"+strconv.Itoa(src.Line))
+				}
+			}
+			return a
+		},
+	}
+
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+func logAt(level slog.Level, msg string, args []interface{}) {
+
+	l := Logger()
+	if !l.Enabled(context.Background(), level) {
+		return
+	}
+
+	// Skip runtime.Callers, logAt and the exported helper
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(context.Background(), r)
+}
+
+type multiCloser []io.Closer
+
+func (mc multiCloser) Close() (err error) {
+
+	for _, c := range mc {
+		if cerr := c.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+
+	return
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONLogger(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "hidra.log")
+	err := InitLogger(&LogConfig{Level: "warn", Format: "json", Outputs: []string{path}})
+	if err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+	defer InitLogger(&LogConfig{Level: "info", Format: "logfmt"})
+
+	// Filtered by level
+	Info("NewEvent", "eid", 1)
+	Warn("EventSolved", "eid", 2)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	var entry map[string]interface{}
+	if json.Unmarshal([]byte(lines[0]), &entry) != nil ||
+		entry["msg"] != "EventSolved" ||
+		entry["eid"] != float64(2) ||
+		!strings.HasPrefix(entry["caller"].(string), "log_test.go:") {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
+
+func TestInitLoggerErrors(t *testing.T) {
+
+	if InitLogger(&LogConfig{Level: "verbose", Format: "json"}) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+
+	if InitLogger(&LogConfig{Level: "info", Format: "xml"}) == nil {
+		t.Fatal("ERROR:", t.Name())
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Log file rotated by size (file.log, file.log.1, ..., file.log.N)
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64 // In bytes (0 for no rotation)
+	MaxBackups int   // Rotated files to keep
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Rotate before exceeding the maximum size
+	if rf.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.MaxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+
+	err := rf.file.Close()
+	rf.file = nil
+
+	return err
+}
+
+func (rf *RotatingFile) open() error {
+
+	err := os.MkdirAll(filepath.Dir(rf.Path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(rf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	rf.file, rf.size = f, info.Size()
+
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+
+	err := rf.file.Close()
+	rf.file = nil
+	if err != nil {
+		return err
+	}
+
+	// Shift backups (the oldest one is overwritten)
+	for i := rf.MaxBackups; i > 0; i-- {
+		src := rf.Path
+		if i > 1 {
+			src += "." + strconv.Itoa(i-1)
+		}
+
+		err = os.Rename(src, rf.Path+"."+strconv.Itoa(i))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Without backups the current file is just truncated
+	if rf.MaxBackups <= 0 {
+		err = os.Truncate(rf.Path, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rf.open()
+}
@@ -4,48 +4,58 @@ import "github.com/swarleynunez/hidra/core/types"
 
 var Rules = [...]types.Rule{
 	/*{
-		NameID:     "rule_1",
-		Resource:   types.CpuResource,
-		MetricType: types.PercentMetric,
-		Comparator: types.GreaterComp,
-		Limit:      float64(1),
-		Action:     types.IgnoreAction,
-		Msg:        "CPU usage % exceeded",
+		NameID: "rule_1",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.CpuResource,
+			MetricType: types.PercentMetric,
+			Comparator: types.GreaterComp,
+			Limit:      float64(1),
+		},
+		Action: types.IgnoreAction,
+		Msg:    "CPU usage % exceeded",
 	},
 	{
-		NameID:     "rule_2",
-		Resource:   types.MemResource,
-		MetricType: types.PercentMetric,
-		Comparator: types.GreaterComp,
-		Limit:      float64(1),
-		Action:     types.IgnoreAction,
-		Msg:        "RAM usage % exceeded",
+		NameID: "rule_2",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.MemResource,
+			MetricType: types.PercentMetric,
+			Comparator: types.GreaterComp,
+			Limit:      float64(1),
+		},
+		Action: types.IgnoreAction,
+		Msg:    "RAM usage % exceeded",
 	},
 	{
-		NameID:     "rule_3",
-		Resource:   types.DiskResource,
-		MetricType: types.PercentMetric,
-		Comparator: types.GreaterComp,
-		Limit:      float64(1),
-		Action:     types.IgnoreAction,
-		Msg:        "Disk space usage % exceeded",
+		NameID: "rule_3",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.DiskResource,
+			MetricType: types.PercentMetric,
+			Comparator: types.GreaterComp,
+			Limit:      float64(1),
+		},
+		Action: types.IgnoreAction,
+		Msg:    "Disk space usage % exceeded",
 	},
 	{
-		NameID:     "rule_4",
-		Resource:   types.PktSentResource,
-		MetricType: types.UnitsMetric,
-		Comparator: types.GreaterComp,
-		Limit:      uint64(1),
-		Action:     types.IgnoreAction,
-		Msg:        "Sent packet limit exceeded",
+		NameID: "rule_4",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.PktSentResource,
+			MetricType: types.UnitsMetric,
+			Comparator: types.GreaterComp,
+			Limit:      uint64(1),
+		},
+		Action: types.IgnoreAction,
+		Msg:    "Sent packet limit exceeded",
 	},
 	{
-		NameID:     "rule_5",
-		Resource:   types.PktRecvResource,
-		MetricType: types.UnitsMetric,
-		Comparator: types.GreaterComp,
-		Limit:      uint64(1),
-		Action:     types.IgnoreAction,
-		Msg:        "Received packet limit exceeded",
+		NameID: "rule_5",
+		RuleCondition: types.RuleCondition{
+			Resource:   types.PktRecvResource,
+			MetricType: types.UnitsMetric,
+			Comparator: types.GreaterComp,
+			Limit:      uint64(1),
+		},
+		Action: types.IgnoreAction,
+		Msg:    "Received packet limit exceeded",
 	},*/
 }